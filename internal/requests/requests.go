@@ -133,6 +133,41 @@ func (req CreateProject) Validate() error {
 	return validations.Validate(v...)
 }
 
+// UpdateProjectQuota request. Zero values mean the default applies.
+type UpdateProjectQuota struct {
+	MaxTokens          int      `json:"max_tokens"`
+	MaxTokenTTLSeconds int64    `json:"max_token_ttl_seconds"`
+	AllowedTargetTypes []string `json:"allowed_target_types"`
+}
+
+// Validate validates UpdateProjectQuota.
+func (req UpdateProjectQuota) Validate() error {
+	v := []func() error{
+		func() error {
+			if req.MaxTokens < 0 {
+				return errors.New("max_tokens must not be negative")
+			}
+			return nil
+		},
+		func() error {
+			if req.MaxTokenTTLSeconds < 0 {
+				return errors.New("max_token_ttl_seconds must not be negative")
+			}
+			return nil
+		},
+		func() error {
+			for _, t := range req.AllowedTargetTypes {
+				if t == "" {
+					return errors.New("allowed_target_types must not contain empty values")
+				}
+			}
+			return nil
+		},
+	}
+
+	return validations.Validate(v...)
+}
+
 // TargetOperation represents a target operation request.
 // TODO evaluate this vs. CreateGitWorkflow.
 type TargetOperation struct {
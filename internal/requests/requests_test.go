@@ -589,3 +589,49 @@ func TestCreateProjectValidate(t *testing.T) {
 		})
 	}
 }
+
+func TestUpdateProjectQuotaValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		req     UpdateProjectQuota
+		wantErr error
+	}{
+		{
+			name: "valid",
+			req: UpdateProjectQuota{
+				MaxTokens:          5,
+				MaxTokenTTLSeconds: 3600,
+				AllowedTargetTypes: []string{"aws_account"},
+			},
+		},
+		{
+			name: "empty quota is valid",
+			req:  UpdateProjectQuota{},
+		},
+		{
+			name:    "negative max tokens",
+			req:     UpdateProjectQuota{MaxTokens: -1},
+			wantErr: errors.New("max_tokens must not be negative"),
+		},
+		{
+			name:    "negative max token ttl",
+			req:     UpdateProjectQuota{MaxTokenTTLSeconds: -1},
+			wantErr: errors.New("max_token_ttl_seconds must not be negative"),
+		},
+		{
+			name:    "empty target type",
+			req:     UpdateProjectQuota{AllowedTargetTypes: []string{""}},
+			wantErr: errors.New("allowed_target_types must not contain empty values"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.wantErr != nil {
+				assert.EqualError(t, tt.req.Validate(), tt.wantErr.Error())
+			} else {
+				assert.Equal(t, tt.wantErr, tt.req.Validate())
+			}
+		})
+	}
+}
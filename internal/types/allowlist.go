@@ -0,0 +1,90 @@
+package types
+
+import (
+	"fmt"
+	"path/filepath"
+)
+
+// ARNAllowlist restricts which ARNs a target may reference. An empty allowlist
+// allows all ARNs. Patterns use filepath matching rules, e.g.
+// arn:aws:iam::012345678901:role/* allows any role without a path in the
+// account. Patterns should be checked with Validate when they are loaded.
+type ARNAllowlist struct {
+	ARNs     []string
+	Patterns []string
+}
+
+// IsEmpty returns whether the allowlist has no entries.
+func (a ARNAllowlist) IsEmpty() bool {
+	return len(a.ARNs) == 0 && len(a.Patterns) == 0
+}
+
+// Validate returns an error wrapping filepath.ErrBadPattern for the first
+// malformed pattern.
+func (a ARNAllowlist) Validate() error {
+	for _, pattern := range a.Patterns {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid arn allowlist pattern '%s': %w", pattern, err)
+		}
+	}
+	return nil
+}
+
+// Allows returns whether the ARN is on the allowlist. A malformed pattern
+// never matches; see Validate.
+func (a ARNAllowlist) Allows(arn string) bool {
+	if a.IsEmpty() {
+		return true
+	}
+
+	for _, allowed := range a.ARNs {
+		if arn == allowed {
+			return true
+		}
+	}
+
+	for _, pattern := range a.Patterns {
+		if ok, _ := filepath.Match(pattern, arn); ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// validate returns an error for any target ARN not on the allowlist.
+func (a ARNAllowlist) validate(properties TargetProperties) error {
+	if !a.Allows(properties.RoleArn) {
+		return fmt.Errorf("role_arn '%s' is not in the arn allowlist", properties.RoleArn)
+	}
+
+	for _, arn := range properties.PolicyArns {
+		if !a.Allows(arn) {
+			return fmt.Errorf("policy_arns contains '%s' which is not in the arn allowlist", arn)
+		}
+	}
+
+	return nil
+}
+
+// ValidateOption configures target validation.
+type ValidateOption func(*validateOptions)
+
+type validateOptions struct {
+	arnAllowlist ARNAllowlist
+}
+
+// WithARNAllowlist rejects targets referencing an ARN not on the allowlist.
+func WithARNAllowlist(a ARNAllowlist) ValidateOption {
+	return func(o *validateOptions) {
+		o.arnAllowlist = a
+	}
+}
+
+func newValidateOptions(opts []ValidateOption) validateOptions {
+	o := validateOptions{}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
@@ -0,0 +1,146 @@
+package types
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestARNAllowlistAllows(t *testing.T) {
+	allowlist := ARNAllowlist{
+		ARNs:     []string{"arn:aws:iam::012345678901:role/exact-role"},
+		Patterns: []string{"arn:aws:iam::111111111111:role/*", "arn:aws:iam::aws:policy/*"},
+	}
+
+	tests := []struct {
+		name      string
+		allowlist ARNAllowlist
+		arn       string
+		want      bool
+	}{
+		{"empty allowlist allows all", ARNAllowlist{}, "arn:aws:iam::012345678901:role/any-role", true},
+		{"exact arn", allowlist, "arn:aws:iam::012345678901:role/exact-role", true},
+		{"exact arn does not glob", allowlist, "arn:aws:iam::012345678901:role/exact-role-2", false},
+		{"pattern", allowlist, "arn:aws:iam::111111111111:role/some-role", true},
+		{"pattern does not match role path", allowlist, "arn:aws:iam::111111111111:role/path/some-role", false},
+		{"pattern other account", allowlist, "arn:aws:iam::222222222222:role/some-role", false},
+		{"managed policy pattern", allowlist, "arn:aws:iam::aws:policy/ReadOnlyAccess", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.allowlist.Allows(tt.arn))
+		})
+	}
+}
+
+func TestARNAllowlistValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		patterns []string
+		wantErr  string
+	}{
+		{name: "no patterns"},
+		{name: "valid patterns", patterns: []string{"arn:aws:iam::012345678901:role/*", "arn:aws:iam::aws:policy/[A-Z]*"}},
+		{
+			name:     "unterminated class",
+			patterns: []string{"arn:aws:iam::012345678901:role/*", "arn:aws:iam::012345678901:role/[a-z"},
+			wantErr:  "invalid arn allowlist pattern 'arn:aws:iam::012345678901:role/[a-z': syntax error in pattern",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ARNAllowlist{Patterns: tt.patterns}.Validate()
+			if tt.wantErr != "" {
+				assert.True(t, errors.Is(err, filepath.ErrBadPattern))
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			assert.Nil(t, err)
+		})
+	}
+}
+
+func TestTargetPropertiesValidateARNAllowlist(t *testing.T) {
+	allowlist := WithARNAllowlist(ARNAllowlist{
+		Patterns: []string{"arn:aws:iam::012345678901:role/*", "arn:aws:iam::012345678901:policy/allowed-*"},
+	})
+
+	tests := []struct {
+		name       string
+		properties TargetProperties
+		opts       []ValidateOption
+		wantErr    error
+	}{
+		{
+			name: "allowed",
+			properties: TargetProperties{
+				CredentialType: "assumed_role",
+				RoleArn:        "arn:aws:iam::012345678901:role/test-role",
+				PolicyArns:     []string{"arn:aws:iam::012345678901:policy/allowed-policy"},
+			},
+			opts: []ValidateOption{allowlist},
+		},
+		{
+			name: "no allowlist allows all",
+			properties: TargetProperties{
+				CredentialType: "assumed_role",
+				RoleArn:        "arn:aws:iam::999999999999:role/test-role",
+			},
+		},
+		{
+			name: "role arn blocked",
+			properties: TargetProperties{
+				CredentialType: "assumed_role",
+				RoleArn:        "arn:aws:iam::999999999999:role/test-role",
+			},
+			opts:    []ValidateOption{allowlist},
+			wantErr: errors.New("role_arn 'arn:aws:iam::999999999999:role/test-role' is not in the arn allowlist"),
+		},
+		{
+			name: "policy arn blocked",
+			properties: TargetProperties{
+				CredentialType: "assumed_role",
+				RoleArn:        "arn:aws:iam::012345678901:role/test-role",
+				PolicyArns: []string{
+					"arn:aws:iam::012345678901:policy/allowed-policy",
+					"arn:aws:iam::012345678901:policy/other-policy",
+				},
+			},
+			opts:    []ValidateOption{allowlist},
+			wantErr: errors.New("policy_arns contains 'arn:aws:iam::012345678901:policy/other-policy' which is not in the arn allowlist"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.properties.Validate(tt.opts...)
+			if tt.wantErr != nil {
+				assert.EqualError(t, err, tt.wantErr.Error())
+				return
+			}
+
+			assert.Nil(t, err)
+		})
+	}
+}
+
+func TestTargetValidateARNAllowlist(t *testing.T) {
+	target := Target{
+		Name: "target1",
+		Type: "aws_account",
+		Properties: TargetProperties{
+			CredentialType: "assumed_role",
+			RoleArn:        "arn:aws:iam::999999999999:role/test-role",
+		},
+	}
+
+	assert.Nil(t, target.Validate())
+	assert.EqualError(t,
+		target.Validate(WithARNAllowlist(ARNAllowlist{ARNs: []string{"arn:aws:iam::012345678901:role/test-role"}})),
+		"role_arn 'arn:aws:iam::999999999999:role/test-role' is not in the arn allowlist",
+	)
+}
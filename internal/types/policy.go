@@ -0,0 +1,130 @@
+package types
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// policyVersions are the IAM policy language versions.
+var policyVersions = []string{"2012-10-17", "2008-10-17"}
+
+// policyDocument is the subset of an IAM policy document that is validated.
+type policyDocument struct {
+	Version   string           `json:"Version"`
+	Statement policyStatements `json:"Statement"`
+}
+
+// policyStatements accepts either a single statement or a list, as IAM does.
+type policyStatements []policyStatement
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (s *policyStatements) UnmarshalJSON(b []byte) error {
+	if bytes.HasPrefix(bytes.TrimSpace(b), []byte("{")) {
+		var st policyStatement
+		if err := json.Unmarshal(b, &st); err != nil {
+			return err
+		}
+		*s = policyStatements{st}
+		return nil
+	}
+
+	var list []policyStatement
+	if err := json.Unmarshal(b, &list); err != nil {
+		return err
+	}
+	*s = list
+	return nil
+}
+
+// policyStatement is a single IAM policy statement. Elements that may be a
+// string or a list are kept raw and only checked for presence.
+type policyStatement struct {
+	Effect      string          `json:"Effect"`
+	Action      json.RawMessage `json:"Action"`
+	NotAction   json.RawMessage `json:"NotAction"`
+	Resource    json.RawMessage `json:"Resource"`
+	NotResource json.RawMessage `json:"NotResource"`
+}
+
+// validate returns an error naming the statement index if the statement has
+// no valid Effect, Action or Resource.
+func (s policyStatement) validate(i int) error {
+	if s.Effect != "Allow" && s.Effect != "Deny" {
+		return fmt.Errorf("policy_document statement %d must have an Effect of 'Allow' or 'Deny'", i)
+	}
+
+	if !isPolicyElementSet(s.Action) && !isPolicyElementSet(s.NotAction) {
+		return fmt.Errorf("policy_document statement %d must have an Action", i)
+	}
+
+	if !isPolicyElementSet(s.Resource) && !isPolicyElementSet(s.NotResource) {
+		return fmt.Errorf("policy_document statement %d must have a Resource or NotResource", i)
+	}
+
+	return nil
+}
+
+// isPolicyElementSet returns whether a string or list policy element is
+// present and non-empty.
+func isPolicyElementSet(raw json.RawMessage) bool {
+	var v interface{}
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return false
+	}
+
+	switch v := v.(type) {
+	case string:
+		return v != ""
+	case []interface{}:
+		return len(v) > 0
+	default:
+		return false
+	}
+}
+
+// validatePolicyDocument validates that the policy document is well-formed IAM
+// JSON with a Version and at least one valid statement. An empty document is
+// valid.
+func validatePolicyDocument(doc string) error {
+	if doc == "" {
+		return nil
+	}
+
+	var p policyDocument
+	if err := json.Unmarshal([]byte(doc), &p); err != nil {
+		return errors.New("policy_document is not valid JSON")
+	}
+
+	if p.Version == "" {
+		return errors.New("policy_document missing Version")
+	}
+
+	if !isPolicyVersion(p.Version) {
+		return fmt.Errorf("policy_document Version must be one of '%s'", strings.Join(policyVersions, " "))
+	}
+
+	if len(p.Statement) == 0 {
+		return errors.New("policy_document missing Statement")
+	}
+
+	for i, s := range p.Statement {
+		if err := s.validate(i); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// isPolicyVersion returns whether v is a known IAM policy language version.
+func isPolicyVersion(v string) bool {
+	for _, known := range policyVersions {
+		if v == known {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,98 @@
+package types
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidatePolicyDocument(t *testing.T) {
+	tests := []struct {
+		name    string
+		doc     string
+		wantErr error
+	}{
+		{
+			name: "empty document",
+		},
+		{
+			name: "valid",
+			doc:  `{"Version": "2012-10-17", "Statement": [{"Effect": "Allow", "Action": "s3:ListBuckets", "Resource": "*"}]}`,
+		},
+		{
+			name: "valid single statement with lists",
+			doc:  `{"Version": "2012-10-17", "Statement": {"Effect": "Deny", "Action": ["s3:GetObject", "s3:PutObject"], "Resource": ["arn:aws:s3:::bucket/*"]}}`,
+		},
+		{
+			name: "valid with NotResource",
+			doc:  `{"Version": "2012-10-17", "Statement": [{"Effect": "Deny", "Action": "s3:*", "NotResource": "arn:aws:s3:::bucket"}]}`,
+		},
+		{
+			name:    "not json",
+			doc:     `not json`,
+			wantErr: errors.New("policy_document is not valid JSON"),
+		},
+		{
+			name:    "not a json object",
+			doc:     `["Version"]`,
+			wantErr: errors.New("policy_document is not valid JSON"),
+		},
+		{
+			name:    "truncated",
+			doc:     `{"Version": "2012-10-17", "Statement": [{"Effect": "Allow"`,
+			wantErr: errors.New("policy_document is not valid JSON"),
+		},
+		{
+			name:    "missing version",
+			doc:     `{"Statement": [{"Effect": "Allow", "Action": "s3:ListBuckets", "Resource": "*"}]}`,
+			wantErr: errors.New("policy_document missing Version"),
+		},
+		{
+			name:    "unknown version",
+			doc:     `{"Version": "2022-01-01", "Statement": [{"Effect": "Allow", "Action": "s3:ListBuckets", "Resource": "*"}]}`,
+			wantErr: errors.New("policy_document Version must be one of '2012-10-17 2008-10-17'"),
+		},
+		{
+			name:    "missing statement",
+			doc:     `{"Version": "2012-10-17"}`,
+			wantErr: errors.New("policy_document missing Statement"),
+		},
+		{
+			name:    "empty statement list",
+			doc:     `{"Version": "2012-10-17", "Statement": []}`,
+			wantErr: errors.New("policy_document missing Statement"),
+		},
+		{
+			name:    "missing effect",
+			doc:     `{"Version": "2012-10-17", "Statement": [{"Action": "s3:ListBuckets", "Resource": "*"}]}`,
+			wantErr: errors.New("policy_document statement 0 must have an Effect of 'Allow' or 'Deny'"),
+		},
+		{
+			name:    "invalid effect",
+			doc:     `{"Version": "2012-10-17", "Statement": [{"Effect": "allow", "Action": "s3:ListBuckets", "Resource": "*"}]}`,
+			wantErr: errors.New("policy_document statement 0 must have an Effect of 'Allow' or 'Deny'"),
+		},
+		{
+			name:    "missing action",
+			doc:     `{"Version": "2012-10-17", "Statement": [{"Effect": "Allow", "Action": "s3:ListBuckets", "Resource": "*"}, {"Effect": "Allow", "Resource": "*"}]}`,
+			wantErr: errors.New("policy_document statement 1 must have an Action"),
+		},
+		{
+			name:    "empty action list",
+			doc:     `{"Version": "2012-10-17", "Statement": [{"Effect": "Allow", "Action": [], "Resource": "*"}]}`,
+			wantErr: errors.New("policy_document statement 0 must have an Action"),
+		},
+		{
+			name:    "missing resource",
+			doc:     `{"Version": "2012-10-17", "Statement": [{"Effect": "Allow", "Action": "s3:ListBuckets"}]}`,
+			wantErr: errors.New("policy_document statement 0 must have a Resource or NotResource"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.wantErr, validatePolicyDocument(tt.doc))
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package types
+
+import (
+	"errors"
+	"sort"
+	"sync"
+
+	"github.com/cello-proj/cello/internal/validations"
+)
+
+// TargetTypeValidator validates the properties of a target of one type.
+type TargetTypeValidator func(TargetProperties) error
+
+// targetTypeValidator is the internal form of TargetTypeValidator, which also
+// receives the validate options.
+type targetTypeValidator func(TargetProperties, validateOptions) error
+
+var (
+	targetTypesMu sync.RWMutex
+	targetTypes   = map[string]targetTypeValidator{
+		"aws_account":        validateAWSAccount,
+		"azure_subscription": ignoreOptions(validateAzureSubscription),
+		"gcp_project":        ignoreOptions(validateGCPProject),
+	}
+)
+
+// RegisterTargetType registers a target type accepted by Target.Validate,
+// replacing any existing validator for it.
+func RegisterTargetType(name string, validate TargetTypeValidator) {
+	targetTypesMu.Lock()
+	defer targetTypesMu.Unlock()
+
+	targetTypes[name] = ignoreOptions(validate)
+}
+
+// TargetTypes returns the registered target types, sorted.
+func TargetTypes() []string {
+	targetTypesMu.RLock()
+	defer targetTypesMu.RUnlock()
+
+	res := make([]string, 0, len(targetTypes))
+	for name := range targetTypes {
+		res = append(res, name)
+	}
+	sort.Strings(res)
+	return res
+}
+
+// lookupTargetType returns the validator for the target type.
+func lookupTargetType(name string) (targetTypeValidator, bool) {
+	targetTypesMu.RLock()
+	defer targetTypesMu.RUnlock()
+
+	v, ok := targetTypes[name]
+	return v, ok
+}
+
+// ignoreOptions adapts a TargetTypeValidator that takes no options.
+func ignoreOptions(validate TargetTypeValidator) targetTypeValidator {
+	return func(properties TargetProperties, _ validateOptions) error {
+		return validate(properties)
+	}
+}
+
+// validateGCPProject validates the properties of a gcp_project target.
+func validateGCPProject(properties TargetProperties) error {
+	if properties.ServiceAccountEmail == "" {
+		return errors.New("service_account_email is required")
+	}
+
+	if !validations.IsValidEmail(properties.ServiceAccountEmail) {
+		return errors.New("service_account_email must be a valid email")
+	}
+
+	return nil
+}
+
+// validateAzureSubscription validates the properties of an azure_subscription
+// target.
+func validateAzureSubscription(properties TargetProperties) error {
+	if properties.SubscriptionID == "" {
+		return errors.New("subscription_id is required")
+	}
+
+	if !validations.IsValidUUID(properties.SubscriptionID) {
+		return errors.New("subscription_id must be a uuid")
+	}
+
+	return nil
+}
@@ -0,0 +1,95 @@
+package types
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTargetValidateTypes(t *testing.T) {
+	tests := []struct {
+		name    string
+		target  Target
+		wantErr error
+	}{
+		{
+			name: "valid gcp_project",
+			target: Target{
+				Name:       "target1",
+				Type:       "gcp_project",
+				Properties: TargetProperties{ServiceAccountEmail: "deployer@my-project.iam.gserviceaccount.com"},
+			},
+		},
+		{
+			name: "gcp_project requires service_account_email",
+			target: Target{
+				Name: "target1",
+				Type: "gcp_project",
+			},
+			wantErr: errors.New("service_account_email is required"),
+		},
+		{
+			name: "gcp_project service_account_email must be an email",
+			target: Target{
+				Name:       "target1",
+				Type:       "gcp_project",
+				Properties: TargetProperties{ServiceAccountEmail: "deployer"},
+			},
+			wantErr: errors.New("service_account_email must be a valid email"),
+		},
+		{
+			name: "valid azure_subscription",
+			target: Target{
+				Name:       "target1",
+				Type:       "azure_subscription",
+				Properties: TargetProperties{SubscriptionID: "00000000-0000-0000-0000-000000000000"},
+			},
+		},
+		{
+			name: "azure_subscription requires subscription_id",
+			target: Target{
+				Name: "target1",
+				Type: "azure_subscription",
+			},
+			wantErr: errors.New("subscription_id is required"),
+		},
+		{
+			name: "azure_subscription subscription_id must be a uuid",
+			target: Target{
+				Name:       "target1",
+				Type:       "azure_subscription",
+				Properties: TargetProperties{SubscriptionID: "my-subscription"},
+			},
+			wantErr: errors.New("subscription_id must be a uuid"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.target.Validate()
+			if tt.wantErr == nil {
+				assert.Nil(t, err)
+				return
+			}
+			assert.EqualError(t, err, tt.wantErr.Error())
+		})
+	}
+}
+
+func TestRegisterTargetType(t *testing.T) {
+	t.Cleanup(func() {
+		targetTypesMu.Lock()
+		delete(targetTypes, "custom_cloud")
+		targetTypesMu.Unlock()
+	})
+
+	target := Target{Name: "target1", Type: "custom_cloud"}
+	assert.EqualError(t, target.Validate(), "type must be one of 'aws_account azure_subscription gcp_project'")
+
+	errCustom := errors.New("custom validation failed")
+	RegisterTargetType("custom_cloud", func(TargetProperties) error { return errCustom })
+
+	assert.Contains(t, TargetTypes(), "custom_cloud")
+	assert.Equal(t, errCustom, target.Validate())
+}
@@ -2,6 +2,9 @@ package types
 
 import (
 	"errors"
+	"fmt"
+	"strings"
+	"time"
 
 	"github.com/cello-proj/cello/internal/validations"
 )
@@ -12,34 +15,58 @@ type Target struct {
 	Type       string           `json:"type" valid:"required~type is required"`
 }
 
-// TargetProperties for target
+// maxPolicyArns is the maximum number of policy ARNs an aws_account target may
+// reference, the limit on managed policies passed when assuming a role.
+const maxPolicyArns = 5
+
+// TargetProperties for target. Which properties apply depends on the target
+// type.
 type TargetProperties struct {
-	CredentialType string   `json:"credential_type" valid:"required~credential_type is required"`
+	CredentialType string   `json:"credential_type"`
 	PolicyArns     []string `json:"policy_arns"`
 	PolicyDocument string   `json:"policy_document"`
-	RoleArn        string   `json:"role_arn" valid:"required~role_arn is required"`
+	RoleArn        string   `json:"role_arn"`
+
+	// ServiceAccountEmail is the service account of a gcp_project target.
+	ServiceAccountEmail string `json:"service_account_email,omitempty"`
+	// SubscriptionID is the subscription of an azure_subscription target.
+	SubscriptionID string `json:"subscription_id,omitempty"`
 }
 
 // Validate validates Target.
-func (target Target) Validate() error {
+func (target Target) Validate(opts ...ValidateOption) error {
 	v := []func() error{
 		func() error { return validations.ValidateStruct(target) },
 		func() error {
-			if target.Type != "aws_account" {
-				return errors.New("type must be one of 'aws_account'")
+			validate, ok := lookupTargetType(target.Type)
+			if !ok {
+				return fmt.Errorf("type must be one of '%s'", strings.Join(TargetTypes(), " "))
 			}
-			return nil
+			return validate(target.Properties, newValidateOptions(opts))
 		},
-		target.Properties.Validate,
 	}
 
 	return validations.Validate(v...)
 }
 
-// Validate validates TargetProperties.
-func (properties TargetProperties) Validate() error {
+// Validate validates TargetProperties of an aws_account target.
+func (properties TargetProperties) Validate(opts ...ValidateOption) error {
+	return validateAWSAccount(properties, newValidateOptions(opts))
+}
+
+// validateAWSAccount validates the properties of an aws_account target.
+func validateAWSAccount(properties TargetProperties, o validateOptions) error {
 	v := []func() error{
-		func() error { return validations.ValidateStruct(properties) },
+		func() error {
+			if properties.CredentialType == "" {
+				return errors.New("credential_type is required")
+			}
+
+			if properties.RoleArn == "" {
+				return errors.New("role_arn is required")
+			}
+			return nil
+		},
 		func() error {
 			if properties.CredentialType != "assumed_role" {
 				return errors.New("credential_type must be one of 'assumed_role'")
@@ -49,17 +76,46 @@ func (properties TargetProperties) Validate() error {
 				return errors.New("role_arn must be a valid arn")
 			}
 
-			if len(properties.PolicyArns) > 5 {
-				return errors.New("policy_arns cannot be more than 5")
+			if err := validations.ValidateIAMARN(properties.RoleArn); err != nil {
+				return fmt.Errorf("role_arn '%s' must be an iam arn: %w", properties.RoleArn, err)
+			}
+
+			if !validations.IsARNResourceType(properties.RoleArn, "role") {
+				return errors.New("role_arn must be a role arn (resource type 'role/')")
+			}
+
+			// Duplicates are rejected rather than dropped so they cannot
+			// count towards the limit unnoticed.
+			seen := map[string]bool{}
+			for _, arn := range properties.PolicyArns {
+				key := strings.ToLower(arn)
+				if seen[key] {
+					return fmt.Errorf("policy_arns contains duplicate '%s'", arn)
+				}
+				seen[key] = true
+			}
+
+			if len(properties.PolicyArns) > maxPolicyArns {
+				return fmt.Errorf("policy_arns cannot be more than %d", maxPolicyArns)
 			}
 
 			for _, arn := range properties.PolicyArns {
 				if !validations.IsValidARN(arn) {
 					return errors.New("policy_arns contains an invalid arn")
 				}
+
+				if err := validations.ValidateIAMARN(arn); err != nil {
+					return fmt.Errorf("policy_arns contains '%s' which must be an iam arn: %w", arn, err)
+				}
+
+				if !validations.IsARNResourceType(arn, "policy") {
+					return errors.New("policy_arns must only contain policy arns (resource type 'policy/')")
+				}
 			}
 			return nil
 		},
+		func() error { return validatePolicyDocument(properties.PolicyDocument) },
+		func() error { return o.arnAllowlist.validate(properties) },
 	}
 
 	return validations.Validate(v...)
@@ -84,3 +140,28 @@ type Token struct {
 	RoleID       string       `json:"role_id"`
 	Secret       string       `json:"secret"`
 }
+
+// TokenTimeLayout is the layout of token CreatedAt and ExpiresAt timestamps.
+const TokenTimeLayout = time.RFC3339
+
+// IsExpired returns whether the token expired at or before now. An error is
+// returned if ExpiresAt cannot be parsed.
+func (t Token) IsExpired(now time.Time) (bool, error) {
+	ttl, err := t.TimeToLive(now)
+	if err != nil {
+		return false, err
+	}
+
+	return ttl <= 0, nil
+}
+
+// TimeToLive returns the time left before the token expires, negative if it
+// has expired. An error is returned if ExpiresAt cannot be parsed.
+func (t Token) TimeToLive(now time.Time) (time.Duration, error) {
+	expiresAt, err := time.Parse(TokenTimeLayout, t.ExpiresAt)
+	if err != nil {
+		return 0, fmt.Errorf("invalid expires_at '%s': %w", t.ExpiresAt, err)
+	}
+
+	return expiresAt.Sub(now), nil
+}
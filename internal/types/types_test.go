@@ -3,6 +3,7 @@ package types
 import (
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -36,6 +37,15 @@ func TestTargetPropertiesValidate(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "policy_document must be valid",
+			properties: TargetProperties{
+				CredentialType: "assumed_role",
+				RoleArn:        "arn:aws:iam::012345678901:role/test-role",
+				PolicyDocument: "{ \"Version\": \"2012-10-17\" }",
+			},
+			wantErr: errors.New("policy_document missing Statement"),
+		},
 		{
 			name: "role_arn must be an arn",
 			properties: TargetProperties{
@@ -72,6 +82,99 @@ func TestTargetPropertiesValidate(t *testing.T) {
 			},
 			wantErr: errors.New("policy_arns contains an invalid arn"),
 		},
+		{
+			name: "duplicate policy arns",
+			properties: TargetProperties{
+				CredentialType: "assumed_role",
+				PolicyArns: []string{
+					"arn:aws:iam::012345678901:policy/test-policy-1",
+					"arn:aws:iam::012345678901:policy/test-policy-1",
+				},
+				RoleArn: "arn:aws:iam::012345678901:role/test-role",
+			},
+			wantErr: errors.New("policy_arns contains duplicate 'arn:aws:iam::012345678901:policy/test-policy-1'"),
+		},
+		{
+			name: "case variant duplicate policy arns",
+			properties: TargetProperties{
+				CredentialType: "assumed_role",
+				PolicyArns: []string{
+					"arn:aws:iam::012345678901:policy/Test-Policy-1",
+					"arn:aws:iam::012345678901:policy/test-policy-1",
+				},
+				RoleArn: "arn:aws:iam::012345678901:role/test-role",
+			},
+			wantErr: errors.New("policy_arns contains duplicate 'arn:aws:iam::012345678901:policy/test-policy-1'"),
+		},
+		{
+			name: "over the limit only because of duplicates",
+			properties: TargetProperties{
+				CredentialType: "assumed_role",
+				PolicyArns: []string{
+					"arn:aws:iam::012345678901:policy/test-policy-1",
+					"arn:aws:iam::012345678901:policy/test-policy-2",
+					"arn:aws:iam::012345678901:policy/test-policy-3",
+					"arn:aws:iam::012345678901:policy/test-policy-4",
+					"arn:aws:iam::012345678901:policy/test-policy-5",
+					"arn:aws:iam::012345678901:policy/test-policy-5",
+				},
+				RoleArn: "arn:aws:iam::012345678901:role/test-role",
+			},
+			wantErr: errors.New("policy_arns contains duplicate 'arn:aws:iam::012345678901:policy/test-policy-5'"),
+		},
+		{
+			name: "role_arn must be an iam arn",
+			properties: TargetProperties{
+				CredentialType: "assumed_role",
+				RoleArn:        "arn:aws:s3:::bucket",
+			},
+			wantErr: errors.New("role_arn 'arn:aws:s3:::bucket' must be an iam arn: service 's3' must be 'iam'"),
+		},
+		{
+			name: "role_arn must be in a supported partition",
+			properties: TargetProperties{
+				CredentialType: "assumed_role",
+				RoleArn:        "arn:aws-iso:iam::012345678901:role/test-role",
+			},
+			wantErr: errors.New("role_arn 'arn:aws-iso:iam::012345678901:role/test-role' must be an iam arn: partition 'aws-iso' must be one of 'aws aws-cn aws-us-gov'"),
+		},
+		{
+			name: "valid aws-us-gov arns",
+			properties: TargetProperties{
+				CredentialType: "assumed_role",
+				PolicyArns:     []string{"arn:aws-us-gov:iam::aws:policy/ReadOnlyAccess"},
+				RoleArn:        "arn:aws-us-gov:iam::012345678901:role/test-role",
+			},
+		},
+		{
+			name: "policy arns must be iam arns",
+			properties: TargetProperties{
+				CredentialType: "assumed_role",
+				PolicyArns:     []string{"arn:aws:s3:::bucket/*"},
+				RoleArn:        "arn:aws:iam::012345678901:role/test-role",
+			},
+			wantErr: errors.New("policy_arns contains 'arn:aws:s3:::bucket/*' which must be an iam arn: service 's3' must be 'iam'"),
+		},
+		{
+			name: "role_arn must be a role",
+			properties: TargetProperties{
+				CredentialType: "assumed_role",
+				RoleArn:        "arn:aws:iam::012345678901:policy/test-policy",
+			},
+			wantErr: errors.New("role_arn must be a role arn (resource type 'role/')"),
+		},
+		{
+			name: "policy arns must be policies",
+			properties: TargetProperties{
+				CredentialType: "assumed_role",
+				PolicyArns: []string{
+					"arn:aws:iam::012345678901:policy/test-policy-1",
+					"arn:aws:iam::012345678901:role/test-role",
+				},
+				RoleArn: "arn:aws:iam::012345678901:role/test-role",
+			},
+			wantErr: errors.New("policy_arns must only contain policy arns (resource type 'policy/')"),
+		},
 	}
 
 	for _, tt := range tests {
@@ -190,7 +293,7 @@ func TestCTargetValidate(t *testing.T) {
 				},
 				Type: "bad",
 			},
-			wantErr: errors.New("type must be one of 'aws_account'"),
+			wantErr: errors.New("type must be one of 'aws_account azure_subscription gcp_project'"),
 		},
 		{
 			name: "missing credential_type",
@@ -274,6 +377,21 @@ func TestCTargetValidate(t *testing.T) {
 			},
 			wantErr: errors.New("policy_arns contains an invalid arn"),
 		},
+		{
+			name: "role_arn and policy_arns swapped",
+			target: Target{
+				Name: "target1",
+				Properties: TargetProperties{
+					CredentialType: "assumed_role",
+					PolicyArns: []string{
+						"arn:aws:iam::012345678901:role/test-role",
+					},
+					RoleArn: "arn:aws:iam::012345678901:policy/test-policy",
+				},
+				Type: "aws_account",
+			},
+			wantErr: errors.New("role_arn must be a role arn (resource type 'role/')"),
+		},
 	}
 
 	for _, tt := range tests {
@@ -286,3 +404,65 @@ func TestCTargetValidate(t *testing.T) {
 		})
 	}
 }
+
+func TestTokenIsExpired(t *testing.T) {
+	now := time.Date(2022, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		expiresAt string
+		want      bool
+		wantTTL   time.Duration
+		wantErr   bool
+	}{
+		{
+			name:      "future",
+			expiresAt: "2022-01-01T13:00:00Z",
+			wantTTL:   time.Hour,
+		},
+		{
+			name:      "past",
+			expiresAt: "2022-01-01T11:00:00Z",
+			want:      true,
+			wantTTL:   -time.Hour,
+		},
+		{
+			name:      "expires now",
+			expiresAt: "2022-01-01T12:00:00Z",
+			want:      true,
+		},
+		{
+			name:      "other time zone",
+			expiresAt: "2022-01-01T05:30:00-07:00",
+			wantTTL:   30 * time.Minute,
+		},
+		{
+			name:      "malformed",
+			expiresAt: "2022-01-01 13:00:00",
+			wantErr:   true,
+		},
+		{
+			name:    "empty",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token := Token{ExpiresAt: tt.expiresAt}
+
+			got, err := token.IsExpired(now)
+			ttl, ttlErr := token.TimeToLive(now)
+			if tt.wantErr {
+				assert.NotNil(t, err)
+				assert.NotNil(t, ttlErr)
+				return
+			}
+
+			assert.Nil(t, err)
+			assert.Nil(t, ttlErr)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.wantTTL, ttl)
+		})
+	}
+}
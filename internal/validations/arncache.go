@@ -0,0 +1,78 @@
+package validations
+
+import (
+	"container/list"
+	"sync"
+)
+
+var iamARNCache *lruCache
+
+// SetARNCacheSize caches the results of ValidateIAMARN for up to size distinct
+// ARNs, evicting the least recently used. A size of zero, the default,
+// disables the cache. IsValidARN is cheaper than a cache lookup so it is not
+// cached.
+func SetARNCacheSize(size int) {
+	if size <= 0 {
+		iamARNCache = nil
+		return
+	}
+	iamARNCache = newLRUCache(size)
+}
+
+// lruCache is a bounded, concurrency safe cache of validation results.
+type lruCache struct {
+	mu      sync.Mutex
+	size    int
+	order   *list.List
+	entries map[string]*list.Element
+}
+
+type lruEntry struct {
+	key    string
+	result interface{}
+}
+
+func newLRUCache(size int) *lruCache {
+	return &lruCache{
+		size:    size,
+		order:   list.New(),
+		entries: make(map[string]*list.Element, size),
+	}
+}
+
+// get returns the cached result for key, or calls validate and caches its
+// result. Validation runs without the lock held.
+func (c *lruCache) get(key string, validate func(string) interface{}) interface{} {
+	c.mu.Lock()
+	if e, ok := c.entries[key]; ok {
+		c.order.MoveToFront(e)
+		result := e.Value.(*lruEntry).result
+		c.mu.Unlock()
+		return result
+	}
+	c.mu.Unlock()
+
+	result := validate(key)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.entries[key]; ok {
+		return result
+	}
+	c.entries[key] = c.order.PushFront(&lruEntry{key: key, result: result})
+	if c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*lruEntry).key)
+	}
+	return result
+}
+
+// len returns the number of cached results.
+func (c *lruCache) len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.order.Len()
+}
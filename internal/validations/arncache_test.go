@@ -0,0 +1,74 @@
+package validations
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestARNCacheResults(t *testing.T) {
+	arns := []string{
+		"arn:aws:iam::012345678901:policy/test-policy",
+		"arn:aws:iam::012345678901:role/test-role",
+		"invalid-arn",
+		"arn:aws:s3:::bucket",
+		"arn:aws:iam",
+		"",
+	}
+
+	want := make([]error, len(arns))
+	for i, a := range arns {
+		want[i] = ValidateIAMARN(a)
+	}
+
+	SetARNCacheSize(2)
+	defer SetARNCacheSize(0)
+
+	// Twice, so results come from both misses and hits.
+	for i := 0; i < 2; i++ {
+		for j, a := range arns {
+			assert.Equal(t, want[j], ValidateIAMARN(a), a)
+		}
+	}
+}
+
+func TestLRUCacheEviction(t *testing.T) {
+	c := newLRUCache(2)
+	calls := 0
+	validate := func(string) interface{} {
+		calls++
+		return true
+	}
+
+	c.get("a", validate)
+	c.get("b", validate)
+	c.get("a", validate)
+	c.get("c", validate)
+	assert.Equal(t, 3, calls)
+	assert.Equal(t, 2, c.len())
+
+	// b was least recently used, so it was evicted.
+	c.get("a", validate)
+	assert.Equal(t, 3, calls)
+	c.get("b", validate)
+	assert.Equal(t, 4, calls)
+}
+
+func BenchmarkValidateIAMARN(b *testing.B) {
+	arns := make([]string, 10)
+	for i := range arns {
+		arns[i] = fmt.Sprintf("arn:aws:iam::012345678901:role/some/path/test-role-%d", i)
+	}
+
+	for _, size := range []int{0, 100} {
+		b.Run(fmt.Sprintf("cache size %d", size), func(b *testing.B) {
+			SetARNCacheSize(size)
+			defer SetARNCacheSize(0)
+
+			for i := 0; i < b.N; i++ {
+				_ = ValidateIAMARN(arns[i%len(arns)])
+			}
+		})
+	}
+}
@@ -1,8 +1,11 @@
 package validations
 
 import (
+	"errors"
+	"fmt"
 	"path/filepath"
 	"regexp"
+	"strings"
 
 	"github.com/asaskevich/govalidator"
 	"github.com/aws/aws-sdk-go/aws/arn"
@@ -64,6 +67,78 @@ func IsValidARN(s string) bool {
 	return arn.IsARN(s)
 }
 
+var (
+	// iamPartitions are the AWS partitions an IAM ARN may belong to.
+	iamPartitions = []string{"aws", "aws-cn", "aws-us-gov"}
+
+	iamAccountID = regexp.MustCompile(`^[0-9]{12}$`)
+)
+
+// ValidateIAMARN returns an error describing why the string is not an IAM
+// ARN in a supported partition. The account must be a 12 digit account id, or
+// 'aws' for AWS managed policies. Results are cached when SetARNCacheSize is
+// set.
+func ValidateIAMARN(s string) error {
+	if c := iamARNCache; c != nil {
+		err, _ := c.get(s, func(s string) interface{} { return validateIAMARN(s) }).(error)
+		return err
+	}
+	return validateIAMARN(s)
+}
+
+func validateIAMARN(s string) error {
+	a, err := arn.Parse(s)
+	if err != nil {
+		return errors.New("not a valid arn")
+	}
+
+	partitionOK := false
+	for _, p := range iamPartitions {
+		if a.Partition == p {
+			partitionOK = true
+			break
+		}
+	}
+	if !partitionOK {
+		return fmt.Errorf("partition '%s' must be one of '%s'", a.Partition, strings.Join(iamPartitions, " "))
+	}
+
+	if a.Service != "iam" {
+		return fmt.Errorf("service '%s' must be 'iam'", a.Service)
+	}
+
+	if a.Region != "" {
+		return fmt.Errorf("region '%s' must be empty for iam", a.Region)
+	}
+
+	if a.AccountID != "aws" && !iamAccountID.MatchString(a.AccountID) {
+		return fmt.Errorf("account '%s' must be a 12 digit account id", a.AccountID)
+	}
+
+	return nil
+}
+
+// IsARNResourceType determines if the string is a valid AWS ARN whose resource
+// is of the provided type (e.g. 'role' for 'role/my-role').
+func IsARNResourceType(s, resourceType string) bool {
+	a, err := arn.Parse(s)
+	if err != nil {
+		return false
+	}
+
+	return strings.HasPrefix(a.Resource, resourceType+"/")
+}
+
+// IsValidEmail determines if the string is a valid email address.
+func IsValidEmail(s string) bool {
+	return govalidator.IsEmail(s)
+}
+
+// IsValidUUID determines if the string is a valid UUID.
+func IsValidUUID(s string) bool {
+	return govalidator.IsUUID(s)
+}
+
 // IsValidImageURI determines if the image URI is a valid container image URI
 // format.
 func IsValidImageURI(imageURI string) bool {
@@ -215,3 +215,157 @@ func TestIsValidARN(t *testing.T) {
 		})
 	}
 }
+
+func TestIsARNResourceType(t *testing.T) {
+	tests := []struct {
+		name         string
+		testString   string
+		resourceType string
+		want         bool
+	}{
+		{
+			name:         "matching resource type",
+			testString:   "arn:aws:iam::012345678901:role/test-role",
+			resourceType: "role",
+			want:         true,
+		},
+		{
+			name:         "different resource type",
+			testString:   "arn:aws:iam::012345678901:policy/test-policy",
+			resourceType: "role",
+		},
+		{
+			name:         "resource type prefix only",
+			testString:   "arn:aws:iam::012345678901:roles/test-role",
+			resourceType: "role",
+		},
+		{
+			name:         "invalid arn",
+			testString:   "invalid-arn",
+			resourceType: "role",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, IsARNResourceType(tt.testString, tt.resourceType))
+		})
+	}
+}
+
+func TestIsValidEmail(t *testing.T) {
+	tests := []struct {
+		name       string
+		testString string
+		want       bool
+	}{
+		{
+			name:       "valid email",
+			testString: "deployer@my-project.iam.gserviceaccount.com",
+			want:       true,
+		},
+		{
+			name:       "missing domain",
+			testString: "deployer@",
+		},
+		{
+			name:       "not an email",
+			testString: "deployer",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, IsValidEmail(tt.testString))
+		})
+	}
+}
+
+func TestIsValidUUID(t *testing.T) {
+	tests := []struct {
+		name       string
+		testString string
+		want       bool
+	}{
+		{
+			name:       "valid uuid",
+			testString: "00000000-0000-0000-0000-000000000000",
+			want:       true,
+		},
+		{
+			name:       "not a uuid",
+			testString: "my-subscription",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, IsValidUUID(tt.testString))
+		})
+	}
+}
+
+func TestValidateIAMARN(t *testing.T) {
+	tests := []struct {
+		name       string
+		testString string
+		wantErr    string
+	}{
+		{
+			name:       "iam role",
+			testString: "arn:aws:iam::012345678901:role/test-role",
+		},
+		{
+			name:       "iam policy",
+			testString: "arn:aws:iam::012345678901:policy/test-policy",
+		},
+		{
+			name:       "aws managed policy",
+			testString: "arn:aws:iam::aws:policy/ReadOnlyAccess",
+		},
+		{
+			name:       "aws-us-gov partition",
+			testString: "arn:aws-us-gov:iam::012345678901:role/test-role",
+		},
+		{
+			name:       "aws-cn partition",
+			testString: "arn:aws-cn:iam::012345678901:role/test-role",
+		},
+		{
+			name:       "not an arn",
+			testString: "not-an-arn",
+			wantErr:    "not a valid arn",
+		},
+		{
+			name:       "unknown partition",
+			testString: "arn:aws-iso:iam::012345678901:role/test-role",
+			wantErr:    "partition 'aws-iso' must be one of 'aws aws-cn aws-us-gov'",
+		},
+		{
+			name:       "s3 arn",
+			testString: "arn:aws:s3:::bucket",
+			wantErr:    "service 's3' must be 'iam'",
+		},
+		{
+			name:       "regional arn",
+			testString: "arn:aws:iam:us-east-1:012345678901:role/test-role",
+			wantErr:    "region 'us-east-1' must be empty for iam",
+		},
+		{
+			name:       "invalid account",
+			testString: "arn:aws:iam::1234:role/test-role",
+			wantErr:    "account '1234' must be a 12 digit account id",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateIAMARN(tt.testString)
+			if tt.wantErr == "" {
+				assert.Nil(t, err)
+				return
+			}
+			assert.EqualError(t, err, tt.wantErr)
+		})
+	}
+}
@@ -0,0 +1,2 @@
+REVOKE ALL PRIVILEGES ON token_tombstones FROM cello;
+DROP TABLE IF EXISTS token_tombstones;
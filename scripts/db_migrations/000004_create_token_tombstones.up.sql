@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS token_tombstones
+(
+    token_id VARCHAR(200) NOT NULL,
+    project VARCHAR(80) NOT NULL,
+    deleted_at TIMESTAMPTZ NOT NULL,
+    CONSTRAINT token_tombstones_pkey PRIMARY KEY (token_id)
+);
+CREATE INDEX IF NOT EXISTS token_tombstones_project_deleted_at_idx ON token_tombstones (project, deleted_at);
+GRANT ALL PRIVILEGES ON token_tombstones TO cello;
@@ -0,0 +1 @@
+ALTER TABLE IF EXISTS projects DROP COLUMN IF EXISTS quota;
@@ -0,0 +1 @@
+ALTER TABLE IF EXISTS projects ADD COLUMN quota JSONB;
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS projects_repository_idx;
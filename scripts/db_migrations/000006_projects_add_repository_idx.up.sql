@@ -0,0 +1 @@
+CREATE INDEX IF NOT EXISTS projects_repository_idx ON projects (repository);
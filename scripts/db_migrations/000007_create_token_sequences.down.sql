@@ -0,0 +1,2 @@
+REVOKE ALL PRIVILEGES ON token_sequences FROM cello;
+DROP TABLE IF EXISTS token_sequences;
@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS token_sequences
+(
+    project VARCHAR(80) NOT NULL,
+    value BIGINT NOT NULL,
+    CONSTRAINT token_sequences_pkey PRIMARY KEY (project),
+    FOREIGN KEY (project) REFERENCES projects(project) on delete cascade on update cascade
+);
+GRANT ALL PRIVILEGES ON token_sequences TO cello;
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS tokens_project_created_at_token_id_idx;
@@ -0,0 +1 @@
+CREATE INDEX IF NOT EXISTS tokens_project_created_at_token_id_idx ON tokens (project, created_at DESC, token_id DESC);
@@ -0,0 +1 @@
+ALTER TABLE IF EXISTS tokens ADD COLUMN role_id VARCHAR(200) NOT NULL DEFAULT '';
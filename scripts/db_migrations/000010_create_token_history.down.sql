@@ -0,0 +1,3 @@
+REVOKE USAGE, SELECT ON SEQUENCE token_history_id_seq FROM cello;
+REVOKE ALL PRIVILEGES ON token_history FROM cello;
+DROP TABLE IF EXISTS token_history;
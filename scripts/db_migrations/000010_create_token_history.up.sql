@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS token_history
+(
+    id BIGSERIAL NOT NULL,
+    token_id VARCHAR(200) NOT NULL,
+    project VARCHAR(80) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL,
+    deleted_at TIMESTAMPTZ,
+    CONSTRAINT token_history_pkey PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS token_history_project_created_at_idx ON token_history (project, created_at);
+CREATE INDEX IF NOT EXISTS token_history_token_id_idx ON token_history (token_id);
+INSERT INTO token_history (token_id, project, created_at) SELECT token_id, project, created_at FROM tokens;
+GRANT ALL PRIVILEGES ON token_history TO cello;
+GRANT USAGE, SELECT ON SEQUENCE token_history_id_seq TO cello;
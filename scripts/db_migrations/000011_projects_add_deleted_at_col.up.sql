@@ -0,0 +1 @@
+ALTER TABLE IF EXISTS projects ADD COLUMN deleted_at TIMESTAMPTZ;
@@ -0,0 +1 @@
+ALTER TABLE IF EXISTS tokens DROP COLUMN IF EXISTS secret_hash;
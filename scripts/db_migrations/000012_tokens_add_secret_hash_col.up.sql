@@ -0,0 +1 @@
+ALTER TABLE IF EXISTS tokens ADD COLUMN secret_hash VARCHAR(64);
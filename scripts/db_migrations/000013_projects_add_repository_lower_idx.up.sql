@@ -0,0 +1 @@
+CREATE INDEX IF NOT EXISTS projects_repository_lower_idx ON projects (LOWER(repository));
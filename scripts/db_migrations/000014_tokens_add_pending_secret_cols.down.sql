@@ -0,0 +1,2 @@
+ALTER TABLE IF EXISTS tokens DROP COLUMN IF EXISTS pending_secret_expires_at;
+ALTER TABLE IF EXISTS tokens DROP COLUMN IF EXISTS pending_secret_hash;
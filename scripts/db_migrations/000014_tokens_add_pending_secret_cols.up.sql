@@ -0,0 +1,2 @@
+ALTER TABLE IF EXISTS tokens ADD COLUMN pending_secret_hash VARCHAR(64);
+ALTER TABLE IF EXISTS tokens ADD COLUMN pending_secret_expires_at TIMESTAMPTZ;
@@ -0,0 +1 @@
+ALTER TABLE IF EXISTS tokens ADD COLUMN last_used_at TIMESTAMPTZ;
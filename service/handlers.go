@@ -21,14 +21,9 @@ import (
 	"github.com/go-kit/log"
 	"github.com/go-kit/log/level"
 	"github.com/gorilla/mux"
-	upper "github.com/upper/db/v4"
 	"gopkg.in/yaml.v2"
 )
 
-const (
-	numOfTokensLimit = 2
-)
-
 // Represents a JWT token.
 type token struct {
 	Token string `json:"token"`
@@ -507,34 +502,42 @@ func newCelloToken(provider string, tok types.Token) *token {
 
 // projectExists checks if a project exists using both the credential provider and database
 func (h handler) projectExists(ctx context.Context, l log.Logger, cp credentials.Provider, w http.ResponseWriter, projectName string) (bool, error) {
+	_, exists, err := h.readProject(ctx, l, cp, w, projectName)
+	return exists, err
+}
+
+// readProject returns the project from the database if it exists in both the
+// credential provider and database. An error response has been written when
+// the project is not returned.
+func (h handler) readProject(ctx context.Context, l log.Logger, cp credentials.Provider, w http.ResponseWriter, projectName string) (db.ProjectEntry, bool, error) {
 	// Checking credential provider
 	level.Debug(l).Log("message", "checking if project exists")
 	projectExists, err := cp.ProjectExists(projectName)
 	if err != nil {
 		level.Error(l).Log("message", "error checking credentials provider for project", "error", err)
 		h.errorResponse(w, "error retrieving project", http.StatusInternalServerError)
-		return false, err
+		return db.ProjectEntry{}, false, err
 	}
 
 	if !projectExists {
 		level.Debug(l).Log("message", "project does not exist in credentials provider")
 		h.errorResponse(w, "project does not exist", http.StatusNotFound)
-		return false, err
+		return db.ProjectEntry{}, false, err
 	}
 
 	// Checking database
-	_, err = h.dbClient.ReadProjectEntry(ctx, projectName)
+	projectEntry, err := h.dbClient.ReadProjectEntry(ctx, projectName)
 	if err != nil {
 		level.Error(l).Log("message", "error retrieving project from database", "error", err)
-		if errors.Is(err, upper.ErrNoMoreRows) {
+		if errors.Is(err, db.ErrProjectNotFound) {
 			h.errorResponse(w, "project does not exist", http.StatusNotFound)
 		} else {
 			h.errorResponse(w, "error retrieving project", http.StatusInternalServerError)
 		}
-		return false, err
+		return db.ProjectEntry{}, false, err
 	}
 
-	return true, err
+	return projectEntry, true, nil
 }
 
 // Creates a project
@@ -602,14 +605,23 @@ func (h handler) createProject(w http.ResponseWriter, r *http.Request) {
 		Repository: capp.Repository,
 	})
 	if err != nil {
+		if errors.Is(err, db.ErrProjectExists) {
+			level.Error(l).Log("message", "project already exists in db", "error", err)
+			h.errorResponse(w, "project already exists", http.StatusConflict)
+			return
+		}
 		level.Error(l).Log("message", "error inserting project to db", "error", err)
 		h.errorResponse(w, "error creating project", http.StatusInternalServerError)
 		return
 	}
+
 	level.Debug(l).Log("message", "creating project")
 	token, err := cp.CreateProject(capp.Name)
 	if err != nil {
 		level.Error(l).Log("message", "error creating project", "error", err)
+		if err := h.dbClient.DeleteProjectEntry(ctx, capp.Name); err != nil {
+			level.Error(l).Log("message", "error removing project from db", "error", err)
+		}
 		h.errorResponse(w, "error creating project", http.StatusInternalServerError)
 		return
 	}
@@ -637,6 +649,73 @@ func (h handler) createProject(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// Updates the quota of a project
+func (h handler) updateProjectQuota(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	projectName := vars["projectName"]
+
+	l := h.requestLogger(r, "op", "update-project-quota", "project", projectName)
+
+	level.Debug(l).Log("message", "validating authorization header for update project quota")
+	ah := r.Header.Get("Authorization")
+	a, err := credentials.NewAuthorization(ah)
+	if err != nil {
+		h.errorResponse(w, "error unauthorized, invalid authorization header format", http.StatusUnauthorized)
+		return
+	}
+	if err := a.Validate(a.ValidateAuthorizedAdmin(h.env.AdminSecret)); err != nil {
+		h.errorResponse(w, "error unauthorized, invalid authorization header", http.StatusUnauthorized)
+		return
+	}
+
+	ctx := r.Context()
+
+	var req requests.UpdateProjectQuota
+	reqBody, err := io.ReadAll(r.Body)
+	if err != nil {
+		level.Error(l).Log("message", "error reading request body", "error", err)
+		h.errorResponse(w, "error reading request body", http.StatusInternalServerError)
+		return
+	}
+	if err := json.Unmarshal(reqBody, &req); err != nil {
+		level.Error(l).Log("message", "error decoding request", "error", err)
+		h.errorResponse(w, "error decoding request", http.StatusBadRequest)
+		return
+	}
+	if err := req.Validate(); err != nil {
+		level.Error(l).Log("message", "error invalid request", "error", err)
+		h.errorResponse(w, fmt.Sprintf("invalid request, %s", err.Error()), http.StatusBadRequest)
+		return
+	}
+
+	level.Debug(l).Log("message", "creating credential provider")
+	cp, err := h.newCredentialsProvider(*a, h.env, r.Header, credentials.NewVaultConfig, credentials.NewVaultSvc)
+	if err != nil {
+		level.Error(l).Log("message", "error creating credentials provider", "error", err)
+		h.errorResponse(w, "error creating credentials provider", http.StatusInternalServerError)
+		return
+	}
+
+	projectEntry, projectExists, err := h.readProject(ctx, l, cp, w, projectName)
+	if err != nil || !projectExists {
+		return
+	}
+
+	projectEntry.Quota = db.ProjectQuota{
+		MaxTokens:          req.MaxTokens,
+		MaxTokenTTLSeconds: req.MaxTokenTTLSeconds,
+		AllowedTargetTypes: req.AllowedTargetTypes,
+	}
+
+	level.Debug(l).Log("message", "updating project quota")
+	if err := h.dbClient.UpdateProjectEntry(ctx, projectEntry); err != nil {
+		level.Error(l).Log("message", "error updating project in db", "error", err)
+		h.errorResponse(w, "error updating project quota", http.StatusInternalServerError)
+		return
+	}
+	fmt.Fprint(w, "{}")
+}
+
 // Get a project
 func (h handler) getProject(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
@@ -661,7 +740,7 @@ func (h handler) getProject(w http.ResponseWriter, r *http.Request) {
 	projectEntry, err := h.dbClient.ReadProjectEntry(ctx, projectName)
 	if err != nil {
 		level.Error(l).Log("message", "error retrieving project", "error", err)
-		if errors.Is(err, upper.ErrNoMoreRows) {
+		if errors.Is(err, db.ErrProjectNotFound) {
 			h.errorResponse(w, "error retrieving project", http.StatusNotFound)
 		} else {
 			h.errorResponse(w, "error retrieving project", http.StatusInternalServerError)
@@ -786,7 +865,13 @@ func (h handler) createTarget(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := types.Target(ctr).Validate(); err != nil {
+	if err := types.Target(ctr).Validate(h.targetValidateOptions()...); err != nil {
+		level.Error(l).Log("message", "error invalid request", "error", err)
+		h.errorResponse(w, fmt.Sprintf("invalid request, %s", err), http.StatusBadRequest)
+		return
+	}
+
+	if err := credentials.ValidateTargetType(ctr.Type); err != nil {
 		level.Error(l).Log("message", "error invalid request", "error", err)
 		h.errorResponse(w, fmt.Sprintf("invalid request, %s", err), http.StatusBadRequest)
 		return
@@ -826,6 +911,19 @@ func (h handler) createTarget(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	projectEntry, err := h.dbClient.ReadProjectEntry(r.Context(), projectName)
+	if err != nil {
+		level.Error(l).Log("message", "error retrieving project from database", "error", err)
+		h.errorResponse(w, "error retrieving project", http.StatusInternalServerError)
+		return
+	}
+
+	if err := projectEntry.Quota.ValidateTargetType(ctr.Type); err != nil {
+		level.Error(l).Log("message", "error invalid request", "error", err)
+		h.errorResponse(w, fmt.Sprintf("invalid request, %s", err), http.StatusBadRequest)
+		return
+	}
+
 	level.Debug(l).Log("message", "creating target")
 	err = cp.CreateTarget(projectName, types.Target(ctr))
 	if err != nil {
@@ -1010,7 +1108,7 @@ func (h handler) updateTarget(w http.ResponseWriter, r *http.Request) {
 	target.Name = targetName
 	target.Type = targetType
 
-	if err := target.Validate(); err != nil {
+	if err := target.Validate(h.targetValidateOptions()...); err != nil {
 		level.Error(l).Log("message", "error invalid request", "error", err)
 		h.errorResponse(w, fmt.Sprintf("invalid request, %s", err), http.StatusBadRequest)
 		return
@@ -1086,7 +1184,7 @@ func (h handler) deleteToken(w http.ResponseWriter, r *http.Request) {
 	dbProjectToken, err := h.dbClient.ReadTokenEntry(ctx, tokenID)
 	if err != nil {
 		// do not return an error if project token is not found
-		if !errors.Is(err, upper.ErrNoMoreRows) {
+		if !errors.Is(err, db.ErrTokenNotFound) {
 			level.Error(l).Log("message", "error retrieving token from DB", "error", err)
 			h.errorResponse(w, "error retrieving token", http.StatusInternalServerError)
 			return
@@ -1144,33 +1242,56 @@ func (h handler) createToken(w http.ResponseWriter, r *http.Request) {
 		h.errorResponse(w, "error creating credentials provider", http.StatusInternalServerError)
 		return
 	}
-	projectExists, err := h.projectExists(ctx, l, cp, w, projectName)
-
+	projectEntry, projectExists, err := h.readProject(ctx, l, cp, w, projectName)
 	if err != nil || !projectExists {
 		return
 	}
 
-	tokens, err := h.dbClient.ListTokenEntries(ctx, projectName)
+	if h.env.RequireTargetsForTokens {
+		level.Debug(l).Log("message", "checking project has targets")
+		if err := credentials.RequireTargets(cp, projectName); err != nil {
+			if errors.Is(err, credentials.ErrNoTargets) {
+				h.errorResponse(w, "project has no targets", http.StatusBadRequest)
+				return
+			}
+			level.Error(l).Log("message", "error listing targets", "error", err)
+			h.errorResponse(w, "error listing targets", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	tokenCount, err := h.dbClient.CountTokenEntries(ctx, projectName)
 	if err != nil {
-		level.Error(l).Log("message", "error listing tokens from DB", "error", err)
+		level.Error(l).Log("message", "error counting tokens from DB", "error", err)
 		h.errorResponse(w, "error listing tokens", http.StatusInternalServerError)
 		return
 	}
 
-	if len(tokens) >= numOfTokensLimit {
+	if err := projectEntry.Quota.ValidateTokenCount(tokenCount); err != nil {
 		level.Error(l).Log("message", "number of tokens allowed per project has been reached")
 		h.errorResponse(w, "token limit reached", http.StatusInternalServerError)
 		return
 	}
 
 	level.Debug(l).Log("message", "creating token")
-	token, err := cp.CreateToken(projectName)
+	token, err := cp.CreateToken(projectName, projectEntry.Quota.MaxTokenTTL())
 	if err != nil {
 		level.Error(l).Log("message", "error creating token with credentials provider", "error", err)
 		h.errorResponse(w, "error creating token with credentials provider", http.StatusInternalServerError)
 		return
 	}
 
+	// The quota ttl is passed to the credentials provider, so this only fails
+	// when the provider did not honor it.
+	if err := projectEntry.Quota.ValidateTokenTTL(token.CreatedAt, token.ExpiresAt); err != nil {
+		level.Error(l).Log("message", "token ttl exceeds project quota", "error", err)
+		if err := cp.DeleteProjectToken(projectName, token.ProjectToken.ID); err != nil {
+			level.Error(l).Log("message", "error deleting token from credentials provider", "error", err)
+		}
+		h.errorResponse(w, "token ttl exceeds project quota", http.StatusInternalServerError)
+		return
+	}
+
 	level.Debug(l).Log("message", "inserting into db")
 	err = h.dbClient.CreateTokenEntry(ctx, token)
 	if err != nil {
@@ -1260,6 +1381,14 @@ func (h handler) errorResponse(w http.ResponseWriter, message string, httpStatus
 	fmt.Fprint(w, r)
 }
 
+// targetValidateOptions returns the target validation options configured for
+// the service.
+func (h handler) targetValidateOptions() []types.ValidateOption {
+	return []types.ValidateOption{
+		types.WithARNAllowlist(types.ARNAllowlist{Patterns: h.env.TargetARNAllowlist}),
+	}
+}
+
 func generateEnvVariablesString(environmentVariables map[string]string) string {
 	if len(environmentVariables) == 0 {
 		return ""
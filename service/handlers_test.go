@@ -13,6 +13,7 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/cello-proj/cello/internal/types"
 	"github.com/cello-proj/cello/service/internal/credentials"
@@ -23,7 +24,6 @@ import (
 
 	"github.com/go-kit/log"
 	"github.com/stretchr/testify/assert"
-	upper "github.com/upper/db/v4"
 )
 
 const (
@@ -49,6 +49,11 @@ type test struct {
 	dbMock     *th.DBClientMock
 	gitMock    *th.GitClientMock
 	wfMock     *th.WorkflowMock
+
+	requireTargets bool
+
+	// verify, if set, runs after the response has been checked.
+	verify func(t *testing.T, tt test)
 }
 
 func TestCreateProject(t *testing.T) {
@@ -110,6 +115,42 @@ func TestCreateProject(t *testing.T) {
 				ProjectExistsFunc: func(s string) (bool, error) { return true, nil },
 			},
 		},
+		{
+			name:       "project name cannot already exist in db",
+			req:        loadJSON(t, "TestCreateProject/project_name_cannot_already_exist.json"),
+			want:       http.StatusConflict,
+			respFile:   "TestCreateProject/project_name_cannot_already_exist_response.json",
+			authHeader: adminAuthHeader,
+			url:        "/projects",
+			method:     "POST",
+			cpMock: &th.CredsProviderMock{
+				ProjectExistsFunc: func(s string) (bool, error) { return false, nil },
+			},
+			dbMock: &th.DBClientMock{
+				CreateProjectEntryFunc: func(ctx context.Context, pe db.ProjectEntry) error { return db.ErrProjectExists },
+			},
+		},
+		{
+			name:       "removes db entry when credentials provider fails to create project",
+			req:        loadJSON(t, "TestCreateProject/can_create_project_request.json"),
+			want:       http.StatusInternalServerError,
+			authHeader: adminAuthHeader,
+			url:        "/projects",
+			method:     "POST",
+			cpMock: &th.CredsProviderMock{
+				ProjectExistsFunc: func(s string) (bool, error) { return false, nil },
+				CreateProjectFunc: func(s string) (types.Token, error) {
+					return types.Token{}, errors.New("vault error")
+				},
+			},
+			dbMock: &th.DBClientMock{
+				CreateProjectEntryFunc: func(ctx context.Context, pe db.ProjectEntry) error { return nil },
+				DeleteProjectEntryFunc: func(ctx context.Context, project string) error { return nil },
+			},
+			verify: func(t *testing.T, tt test) {
+				assert.Len(t, tt.dbMock.DeleteProjectEntryCalls(), 1)
+			},
+		},
 		{
 			name:       "project fails to create db entry",
 			req:        loadJSON(t, "TestCreateProject/project_fails_to_create_dbentry.json"),
@@ -166,7 +207,7 @@ func TestCreateToken(t *testing.T) {
 			url:        "/projects/undeletableprojecttargets/tokens",
 			method:     "POST",
 			cpMock: &th.CredsProviderMock{
-				CreateTokenFunc: func(s string) (types.Token, error) {
+				CreateTokenFunc: func(s string, ttl time.Duration) (types.Token, error) {
 					return types.Token{
 						CreatedAt: "2022-06-21T14:56:10.341066-07:00",
 						ExpiresAt: "2023-06-21T14:56:10.341066-07:00",
@@ -182,18 +223,17 @@ func TestCreateToken(t *testing.T) {
 			},
 			dbMock: &th.DBClientMock{
 				CreateTokenEntryFunc: func(ctx context.Context, t types.Token) error { return nil },
-				ListTokenEntriesFunc: func(ctx context.Context, p string) ([]db.TokenEntry, error) {
-					return []db.TokenEntry{{
-						CreatedAt: "2022-06-21T14:56:10.341066-07:00",
-						ExpiresAt: "2023-06-21T14:56:10.341066-07:00",
-						ProjectID: "project1",
-						TokenID:   "secret-id-accessor",
-					}}, nil
+				CountTokenEntriesFunc: func(ctx context.Context, p string) (int, error) {
+					return 1, nil
 				},
 				ReadProjectEntryFunc: func(ctx context.Context, p string) (db.ProjectEntry, error) {
 					return db.ProjectEntry{ProjectID: "project1", Repository: "repo"}, nil
 				},
 			},
+			verify: func(t *testing.T, tt test) {
+				// The project is read once, for both the existence check and its quota.
+				assert.Len(t, tt.dbMock.ReadProjectEntryCalls(), 1)
+			},
 		},
 		{
 			name:       "project does not exist",
@@ -224,17 +264,12 @@ func TestCreateToken(t *testing.T) {
 			url:        "/projects/tokendberror/tokens",
 			method:     "POST",
 			cpMock: &th.CredsProviderMock{
-				CreateTokenFunc:   func(s string) (types.Token, error) { return types.Token{}, errors.New("error") },
+				CreateTokenFunc:   func(s string, ttl time.Duration) (types.Token, error) { return types.Token{}, errors.New("error") },
 				ProjectExistsFunc: func(s string) (bool, error) { return true, nil },
 			},
 			dbMock: &th.DBClientMock{
-				ListTokenEntriesFunc: func(ctx context.Context, p string) ([]db.TokenEntry, error) {
-					return []db.TokenEntry{{
-						CreatedAt: "2022-06-21T14:56:10.341066-07:00",
-						ExpiresAt: "2023-06-21T14:56:10.341066-07:00",
-						ProjectID: "project1",
-						TokenID:   "secret-id-accessor",
-					}}, nil
+				CountTokenEntriesFunc: func(ctx context.Context, p string) (int, error) {
+					return 1, nil
 				},
 				ReadProjectEntryFunc: func(ctx context.Context, p string) (db.ProjectEntry, error) {
 					return db.ProjectEntry{ProjectID: "project1", Repository: "repo"}, nil
@@ -253,18 +288,8 @@ func TestCreateToken(t *testing.T) {
 				ProjectExistsFunc: func(s string) (bool, error) { return true, nil },
 			},
 			dbMock: &th.DBClientMock{
-				ListTokenEntriesFunc: func(ctx context.Context, p string) ([]db.TokenEntry, error) {
-					return []db.TokenEntry{{
-						CreatedAt: "2022-06-21T14:56:10.341066-07:00",
-						ExpiresAt: "2023-06-21T14:56:10.341066-07:00",
-						ProjectID: "project1",
-						TokenID:   "secret-id-accessor",
-					}, {
-						CreatedAt: "2022-07-21T14:00:00.000000-07:00",
-						ExpiresAt: "2023-07-21T14:00:00.000000-07:00",
-						ProjectID: "project1",
-						TokenID:   "secret-id-accessor",
-					}}, nil
+				CountTokenEntriesFunc: func(ctx context.Context, p string) (int, error) {
+					return 2, nil
 				},
 				ReadProjectEntryFunc: func(ctx context.Context, p string) (db.ProjectEntry, error) {
 					return db.ProjectEntry{ProjectID: "project1", Repository: "repo"}, nil
@@ -283,14 +308,175 @@ func TestCreateToken(t *testing.T) {
 				ProjectExistsFunc: func(s string) (bool, error) { return true, nil },
 			},
 			dbMock: &th.DBClientMock{
-				ListTokenEntriesFunc: func(ctx context.Context, p string) ([]db.TokenEntry, error) {
-					return []db.TokenEntry{}, errors.New("error")
+				CountTokenEntriesFunc: func(ctx context.Context, p string) (int, error) {
+					return 0, errors.New("error")
+				},
+				ReadProjectEntryFunc: func(ctx context.Context, p string) (db.ProjectEntry, error) {
+					return db.ProjectEntry{ProjectID: "project1", Repository: "repo"}, nil
+				},
+			},
+		},
+		{
+			name:       "project quota token limit reached",
+			req:        loadJSON(t, "TestCreateToken/request.json"),
+			want:       http.StatusInternalServerError,
+			respFile:   "TestCreateToken/token_limit_reached_response.json",
+			authHeader: adminAuthHeader,
+			url:        "/projects/projectlisttokenslimit/tokens",
+			method:     "POST",
+			cpMock: &th.CredsProviderMock{
+				ProjectExistsFunc: func(s string) (bool, error) { return true, nil },
+			},
+			dbMock: &th.DBClientMock{
+				CountTokenEntriesFunc: func(ctx context.Context, p string) (int, error) {
+					return 1, nil
+				},
+				ReadProjectEntryFunc: func(ctx context.Context, p string) (db.ProjectEntry, error) {
+					return db.ProjectEntry{ProjectID: "project1", Repository: "repo", Quota: db.ProjectQuota{MaxTokens: 1}}, nil
+				},
+			},
+		},
+		{
+			name:       "token ttl not honored by credentials provider",
+			req:        loadJSON(t, "TestCreateToken/request.json"),
+			want:       http.StatusInternalServerError,
+			respFile:   "TestCreateToken/token_ttl_exceeds_quota_response.json",
+			authHeader: adminAuthHeader,
+			url:        "/projects/undeletableprojecttargets/tokens",
+			method:     "POST",
+			cpMock: &th.CredsProviderMock{
+				CreateTokenFunc: func(s string, ttl time.Duration) (types.Token, error) {
+					return types.Token{
+						CreatedAt: "2022-06-21T14:56:10.341066-07:00",
+						ExpiresAt: "2023-06-21T14:56:10.341066-07:00",
+						ProjectID: "project1",
+						ProjectToken: types.ProjectToken{
+							ID: "secret-id-accessor",
+						},
+						RoleID: "role-id",
+						Secret: "secret",
+					}, nil
+				},
+				DeleteProjectTokenFunc: func(s1, s2 string) error { return nil },
+				ProjectExistsFunc:      func(s string) (bool, error) { return true, nil },
+			},
+			dbMock: &th.DBClientMock{
+				CountTokenEntriesFunc: func(ctx context.Context, p string) (int, error) {
+					return 0, nil
+				},
+				ReadProjectEntryFunc: func(ctx context.Context, p string) (db.ProjectEntry, error) {
+					return db.ProjectEntry{ProjectID: "project1", Repository: "repo", Quota: db.ProjectQuota{MaxTokenTTLSeconds: 3600}}, nil
+				},
+			},
+		},
+		{
+			name:       "passes project max token ttl to credentials provider",
+			req:        loadJSON(t, "TestCreateToken/request.json"),
+			want:       http.StatusOK,
+			respFile:   "TestCreateToken/can_create_token_with_quota_ttl_response.json",
+			authHeader: adminAuthHeader,
+			url:        "/projects/undeletableprojecttargets/tokens",
+			method:     "POST",
+			cpMock: &th.CredsProviderMock{
+				CreateTokenFunc: func(s string, ttl time.Duration) (types.Token, error) {
+					if ttl != time.Hour {
+						return types.Token{}, fmt.Errorf("unexpected ttl %s", ttl)
+					}
+					return types.Token{
+						CreatedAt: "2022-06-21T14:56:10.341066-07:00",
+						ExpiresAt: "2022-06-21T15:56:10.341066-07:00",
+						ProjectID: "project1",
+						ProjectToken: types.ProjectToken{
+							ID: "secret-id-accessor",
+						},
+						RoleID: "role-id",
+						Secret: "secret",
+					}, nil
+				},
+				ProjectExistsFunc: func(s string) (bool, error) { return true, nil },
+			},
+			dbMock: &th.DBClientMock{
+				CreateTokenEntryFunc: func(ctx context.Context, t types.Token) error { return nil },
+				CountTokenEntriesFunc: func(ctx context.Context, p string) (int, error) {
+					return 0, nil
+				},
+				ReadProjectEntryFunc: func(ctx context.Context, p string) (db.ProjectEntry, error) {
+					return db.ProjectEntry{ProjectID: "project1", Repository: "repo", Quota: db.ProjectQuota{MaxTokenTTLSeconds: 3600}}, nil
+				},
+			},
+		},
+		{
+			name:           "can create token when targets are required and project has targets",
+			req:            loadJSON(t, "TestCreateToken/request.json"),
+			want:           http.StatusOK,
+			respFile:       "TestCreateToken/can_create_token_response.json",
+			authHeader:     adminAuthHeader,
+			url:            "/projects/undeletableprojecttargets/tokens",
+			method:         "POST",
+			requireTargets: true,
+			cpMock: &th.CredsProviderMock{
+				CreateTokenFunc: func(s string, ttl time.Duration) (types.Token, error) {
+					return types.Token{
+						CreatedAt: "2022-06-21T14:56:10.341066-07:00",
+						ExpiresAt: "2023-06-21T14:56:10.341066-07:00",
+						ProjectID: "project1",
+						ProjectToken: types.ProjectToken{
+							ID: "secret-id-accessor",
+						},
+						RoleID: "role-id",
+						Secret: "secret",
+					}, nil
+				},
+				ListTargetsFunc:   func(s string) ([]string, error) { return []string{"target1"}, nil },
+				ProjectExistsFunc: func(s string) (bool, error) { return true, nil },
+			},
+			dbMock: &th.DBClientMock{
+				CreateTokenEntryFunc: func(ctx context.Context, t types.Token) error { return nil },
+				CountTokenEntriesFunc: func(ctx context.Context, p string) (int, error) {
+					return 1, nil
 				},
 				ReadProjectEntryFunc: func(ctx context.Context, p string) (db.ProjectEntry, error) {
 					return db.ProjectEntry{ProjectID: "project1", Repository: "repo"}, nil
 				},
 			},
 		},
+		{
+			name:           "fails to create token when targets are required and project has no targets",
+			req:            loadJSON(t, "TestCreateToken/request.json"),
+			want:           http.StatusBadRequest,
+			body:           `{"error_message":"project has no targets"}`,
+			authHeader:     adminAuthHeader,
+			url:            "/projects/projectnotargets/tokens",
+			method:         "POST",
+			requireTargets: true,
+			cpMock: &th.CredsProviderMock{
+				ListTargetsFunc:   func(s string) ([]string, error) { return []string{}, nil },
+				ProjectExistsFunc: func(s string) (bool, error) { return true, nil },
+			},
+			dbMock: &th.DBClientMock{
+				ReadProjectEntryFunc: func(ctx context.Context, p string) (db.ProjectEntry, error) {
+					return db.ProjectEntry{ProjectID: "projectnotargets", Repository: "repo"}, nil
+				},
+			},
+		},
+		{
+			name:           "fails to create token when targets are required and listing targets fails",
+			req:            loadJSON(t, "TestCreateToken/request.json"),
+			want:           http.StatusInternalServerError,
+			authHeader:     adminAuthHeader,
+			url:            "/projects/projectnotargets/tokens",
+			method:         "POST",
+			requireTargets: true,
+			cpMock: &th.CredsProviderMock{
+				ListTargetsFunc:   func(s string) ([]string, error) { return nil, errors.New("error") },
+				ProjectExistsFunc: func(s string) (bool, error) { return true, nil },
+			},
+			dbMock: &th.DBClientMock{
+				ReadProjectEntryFunc: func(ctx context.Context, p string) (db.ProjectEntry, error) {
+					return db.ProjectEntry{ProjectID: "projectnotargets", Repository: "repo"}, nil
+				},
+			},
+		},
 	}
 	runTests(t, tests)
 }
@@ -462,6 +648,93 @@ func TestDeleteProject(t *testing.T) {
 	runTests(t, tests)
 }
 
+func TestUpdateProjectQuota(t *testing.T) {
+	tests := []test{
+		{
+			name:       "can update project quota",
+			req:        loadJSON(t, "TestUpdateProjectQuota/request.json"),
+			want:       http.StatusOK,
+			body:       "{}",
+			authHeader: adminAuthHeader,
+			url:        "/projects/project1/quota",
+			method:     "PUT",
+			cpMock: &th.CredsProviderMock{
+				ProjectExistsFunc: func(s string) (bool, error) { return true, nil },
+			},
+			dbMock: &th.DBClientMock{
+				ReadProjectEntryFunc: func(ctx context.Context, p string) (db.ProjectEntry, error) {
+					return db.ProjectEntry{ProjectID: "project1", Repository: "git@github.com:myorg/myrepo.git"}, nil
+				},
+				UpdateProjectEntryFunc: func(ctx context.Context, pe db.ProjectEntry) error {
+					want := db.ProjectEntry{
+						ProjectID:  "project1",
+						Repository: "git@github.com:myorg/myrepo.git",
+						Quota: db.ProjectQuota{
+							MaxTokens:          5,
+							MaxTokenTTLSeconds: 3600,
+							AllowedTargetTypes: []string{"aws_account"},
+						},
+					}
+					if !assert.ObjectsAreEqual(want, pe) {
+						return fmt.Errorf("unexpected project entry %+v", pe)
+					}
+					return nil
+				},
+			},
+		},
+		{
+			name:       "fails to update project quota when not admin",
+			req:        loadJSON(t, "TestUpdateProjectQuota/request.json"),
+			want:       http.StatusUnauthorized,
+			authHeader: userAuthHeader,
+			url:        "/projects/project1/quota",
+			method:     "PUT",
+		},
+		{
+			name:       "fails to update project quota when request is invalid",
+			req:        loadJSON(t, "TestUpdateProjectQuota/invalid_request.json"),
+			want:       http.StatusBadRequest,
+			respFile:   "TestUpdateProjectQuota/invalid_response.json",
+			authHeader: adminAuthHeader,
+			url:        "/projects/project1/quota",
+			method:     "PUT",
+		},
+		{
+			name:       "fails to update project quota when project does not exist",
+			req:        loadJSON(t, "TestUpdateProjectQuota/request.json"),
+			want:       http.StatusNotFound,
+			respFile:   "TestUpdateProjectQuota/project_does_not_exist_response.json",
+			authHeader: adminAuthHeader,
+			url:        "/projects/project1/quota",
+			method:     "PUT",
+			cpMock: &th.CredsProviderMock{
+				ProjectExistsFunc: func(s string) (bool, error) { return false, nil },
+			},
+		},
+		{
+			name:       "fails to update project quota when db update fails",
+			req:        loadJSON(t, "TestUpdateProjectQuota/request.json"),
+			want:       http.StatusInternalServerError,
+			respFile:   "TestUpdateProjectQuota/update_error_response.json",
+			authHeader: adminAuthHeader,
+			url:        "/projects/project1/quota",
+			method:     "PUT",
+			cpMock: &th.CredsProviderMock{
+				ProjectExistsFunc: func(s string) (bool, error) { return true, nil },
+			},
+			dbMock: &th.DBClientMock{
+				ReadProjectEntryFunc: func(ctx context.Context, p string) (db.ProjectEntry, error) {
+					return db.ProjectEntry{ProjectID: "project1", Repository: "git@github.com:myorg/myrepo.git"}, nil
+				},
+				UpdateProjectEntryFunc: func(ctx context.Context, pe db.ProjectEntry) error {
+					return errors.New("db error")
+				},
+			},
+		},
+	}
+	runTests(t, tests)
+}
+
 func TestGetProject(t *testing.T) {
 	tests := []test{
 		{
@@ -491,7 +764,7 @@ func TestGetProject(t *testing.T) {
 			url:        "/projects/projectdoesnotexist",
 			dbMock: &th.DBClientMock{
 				ReadProjectEntryFunc: func(ctx context.Context, project string) (db.ProjectEntry, error) {
-					return db.ProjectEntry{}, upper.ErrNoMoreRows
+					return db.ProjectEntry{}, db.ErrProjectNotFound
 				},
 			},
 		},
@@ -514,6 +787,42 @@ func TestCreateTarget(t *testing.T) {
 				ProjectExistsFunc: func(s string) (bool, error) { return true, nil },
 				TargetExistsFunc:  func(s1, s2 string) (bool, error) { return false, nil },
 			},
+			dbMock: &th.DBClientMock{
+				ReadProjectEntryFunc: func(ctx context.Context, p string) (db.ProjectEntry, error) {
+					return db.ProjectEntry{ProjectID: "projectalreadyexists", Repository: "repo"}, nil
+				},
+			},
+		},
+		{
+			name:       "target type must be allowed by project quota",
+			req:        loadJSON(t, "TestCreateTarget/can_create_target_request.json"),
+			want:       http.StatusBadRequest,
+			respFile:   "TestCreateTarget/target_type_not_allowed_response.json",
+			authHeader: adminAuthHeader,
+			url:        "/projects/projectalreadyexists/targets",
+			method:     "POST",
+			cpMock: &th.CredsProviderMock{
+				ProjectExistsFunc: func(s string) (bool, error) { return true, nil },
+				TargetExistsFunc:  func(s1, s2 string) (bool, error) { return false, nil },
+			},
+			dbMock: &th.DBClientMock{
+				ReadProjectEntryFunc: func(ctx context.Context, p string) (db.ProjectEntry, error) {
+					return db.ProjectEntry{
+						ProjectID:  "projectalreadyexists",
+						Repository: "repo",
+						Quota:      db.ProjectQuota{AllowedTargetTypes: []string{"other_type"}},
+					}, nil
+				},
+			},
+		},
+		{
+			name:       "target type must be supported by credentials provider",
+			req:        loadJSON(t, "TestCreateTarget/unsupported_target_type_request.json"),
+			want:       http.StatusBadRequest,
+			respFile:   "TestCreateTarget/unsupported_target_type_response.json",
+			authHeader: adminAuthHeader,
+			url:        "/projects/projectalreadyexists/targets",
+			method:     "POST",
 		},
 		{
 			name:       "fails to create target when not admin",
@@ -1436,7 +1745,8 @@ func runTests(t *testing.T, tests []test) {
 				config:                 config,
 				gitClient:              &th.GitClientMock{},
 				env: env.Vars{
-					AdminSecret: testPassword,
+					AdminSecret:             testPassword,
+					RequireTargetsForTokens: tt.requireTargets,
 				},
 			}
 
@@ -1497,6 +1807,10 @@ func runTests(t *testing.T, tests []test) {
 					assert.JSONEq(t, wantBodyStr, bodyStr)
 				}
 			}
+
+			if tt.verify != nil {
+				tt.verify(t, tt)
+			}
 		})
 	}
 }
@@ -0,0 +1,277 @@
+package credentials
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/arn"
+	"github.com/aws/aws-sdk-go/aws/client"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/cello-proj/cello/internal/types"
+)
+
+// maxTargetNameLength is the longest name types.Target.Validate accepts.
+const maxTargetNameLength = 32
+
+var invalidTargetNameChars = regexp.MustCompile(`[^a-zA-Z0-9_]`)
+
+type discoveryIAMAPI interface {
+	ListRolesPagesWithContext(ctx aws.Context, input *iam.ListRolesInput, fn func(*iam.ListRolesOutput, bool) bool, opts ...request.Option) error
+	ListAttachedRolePoliciesPagesWithContext(ctx aws.Context, input *iam.ListAttachedRolePoliciesInput, fn func(*iam.ListAttachedRolePoliciesOutput, bool) bool, opts ...request.Option) error
+}
+
+// TargetDiscoverer finds roles in an AWS account that cello can assume. It
+// only reads from IAM.
+type TargetDiscoverer struct {
+	iamSvc    discoveryIAMAPI
+	principal string
+}
+
+// NewTargetDiscoverer returns a new TargetDiscoverer using the provided AWS
+// session, which must be able to list roles and their attached policies in
+// the accounts to discover. Principal is the ARN of the role or user cello
+// assumes target roles as.
+func NewTargetDiscoverer(p client.ConfigProvider, principal string) TargetDiscoverer {
+	return TargetDiscoverer{
+		iamSvc:    iam.New(p),
+		principal: principal,
+	}
+}
+
+// DiscoverTargets returns a candidate aws_account target for each role in the
+// account whose trust policy allows the principal to assume it, with the
+// role's attached managed policies. The targets are not validated and are
+// meant to be reviewed before they are created.
+func (d TargetDiscoverer) DiscoverTargets(ctx context.Context, accountID string) ([]types.Target, error) {
+	principal, err := arn.Parse(d.principal)
+	if err != nil {
+		return nil, fmt.Errorf("principal must be a valid arn: %w", err)
+	}
+
+	var roles []*iam.Role
+	var roleErr error
+	err = d.iamSvc.ListRolesPagesWithContext(ctx, &iam.ListRolesInput{}, func(out *iam.ListRolesOutput, last bool) bool {
+		for _, r := range out.Roles {
+			a, err := arn.Parse(aws.StringValue(r.Arn))
+			if err != nil {
+				roleErr = fmt.Errorf("role arn '%s' is invalid: %w", aws.StringValue(r.Arn), err)
+				return false
+			}
+			if a.AccountID != accountID {
+				roleErr = fmt.Errorf("credentials are for account '%s', not '%s'", a.AccountID, accountID)
+				return false
+			}
+
+			trusted, err := trustsPrincipal(aws.StringValue(r.AssumeRolePolicyDocument), principal)
+			if err != nil {
+				roleErr = fmt.Errorf("unable to read trust policy of role '%s': %w", aws.StringValue(r.Arn), err)
+				return false
+			}
+			if trusted {
+				roles = append(roles, r)
+			}
+		}
+		return true
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to list roles: %w", err)
+	}
+	if roleErr != nil {
+		return nil, roleErr
+	}
+
+	targets := make([]types.Target, 0, len(roles))
+	for _, r := range roles {
+		policyArns, err := d.attachedPolicyArns(ctx, aws.StringValue(r.RoleName))
+		if err != nil {
+			return nil, err
+		}
+
+		targets = append(targets, types.Target{
+			Name: targetName(aws.StringValue(r.RoleName)),
+			Type: "aws_account",
+			Properties: types.TargetProperties{
+				CredentialType: "assumed_role",
+				PolicyArns:     policyArns,
+				RoleArn:        aws.StringValue(r.Arn),
+			},
+		})
+	}
+
+	return targets, nil
+}
+
+// attachedPolicyArns returns the ARNs of the managed policies attached to the
+// role.
+func (d TargetDiscoverer) attachedPolicyArns(ctx context.Context, roleName string) ([]string, error) {
+	res := []string{}
+	input := &iam.ListAttachedRolePoliciesInput{RoleName: aws.String(roleName)}
+	err := d.iamSvc.ListAttachedRolePoliciesPagesWithContext(ctx, input, func(out *iam.ListAttachedRolePoliciesOutput, last bool) bool {
+		for _, p := range out.AttachedPolicies {
+			res = append(res, aws.StringValue(p.PolicyArn))
+		}
+		return true
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to list policies of role '%s': %w", roleName, err)
+	}
+	return res, nil
+}
+
+// targetName derives a valid target name from the role name.
+func targetName(roleName string) string {
+	name := invalidTargetNameChars.ReplaceAllString(roleName, "_")
+	if len(name) > maxTargetNameLength {
+		name = name[:maxTargetNameLength]
+	}
+	return name
+}
+
+// stringOrList is a policy element that may be a single string or a list.
+type stringOrList []string
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (s *stringOrList) UnmarshalJSON(b []byte) error {
+	if bytes.HasPrefix(bytes.TrimSpace(b), []byte(`"`)) {
+		var v string
+		if err := json.Unmarshal(b, &v); err != nil {
+			return err
+		}
+		*s = stringOrList{v}
+		return nil
+	}
+
+	var list []string
+	if err := json.Unmarshal(b, &list); err != nil {
+		return err
+	}
+	*s = list
+	return nil
+}
+
+// trustPolicy is the subset of a role trust policy used to find who may
+// assume the role.
+type trustPolicy struct {
+	Statement trustStatements `json:"Statement"`
+}
+
+// trustStatements accepts either a single statement or a list, as IAM does.
+type trustStatements []trustStatement
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (s *trustStatements) UnmarshalJSON(b []byte) error {
+	if bytes.HasPrefix(bytes.TrimSpace(b), []byte("{")) {
+		var st trustStatement
+		if err := json.Unmarshal(b, &st); err != nil {
+			return err
+		}
+		*s = trustStatements{st}
+		return nil
+	}
+
+	var list []trustStatement
+	if err := json.Unmarshal(b, &list); err != nil {
+		return err
+	}
+	*s = list
+	return nil
+}
+
+type trustStatement struct {
+	Effect    string          `json:"Effect"`
+	Action    stringOrList    `json:"Action"`
+	Principal json.RawMessage `json:"Principal"`
+}
+
+// awsPrincipals returns the AWS principals of the statement. A wildcard
+// principal is returned as "*". A statement without a Principal, such as one
+// using NotPrincipal, has none.
+func (s trustStatement) awsPrincipals() ([]string, error) {
+	if len(s.Principal) == 0 {
+		return nil, nil
+	}
+
+	var wildcard string
+	if err := json.Unmarshal(s.Principal, &wildcard); err == nil {
+		return []string{wildcard}, nil
+	}
+
+	var p struct {
+		AWS stringOrList `json:"AWS"`
+	}
+	if err := json.Unmarshal(s.Principal, &p); err != nil {
+		return nil, err
+	}
+	return p.AWS, nil
+}
+
+// allowsAssumeRole returns whether the statement allows sts:AssumeRole.
+func (s trustStatement) allowsAssumeRole() bool {
+	if s.Effect != "Allow" {
+		return false
+	}
+	for _, a := range s.Action {
+		switch strings.ToLower(a) {
+		case "sts:assumerole", "sts:*", "*":
+			return true
+		}
+	}
+	return false
+}
+
+// trustsPrincipal returns whether the URL encoded trust policy allows the
+// principal to assume the role, directly or through its account. Conditions
+// are not evaluated.
+func trustsPrincipal(doc string, principal arn.ARN) (bool, error) {
+	decoded, err := url.QueryUnescape(doc)
+	if err != nil {
+		return false, err
+	}
+
+	var policy trustPolicy
+	if err := json.Unmarshal([]byte(decoded), &policy); err != nil {
+		return false, err
+	}
+
+	trusted := map[string]bool{
+		"*":                    true,
+		principal.String():     true,
+		principal.AccountID:    true,
+		accountRoot(principal): true,
+	}
+
+	for _, st := range policy.Statement {
+		if !st.allowsAssumeRole() {
+			continue
+		}
+
+		principals, err := st.awsPrincipals()
+		if err != nil {
+			return false, err
+		}
+		for _, p := range principals {
+			if trusted[p] {
+				return true, nil
+			}
+		}
+	}
+
+	return false, nil
+}
+
+// accountRoot returns the root ARN of the account of the principal.
+func accountRoot(principal arn.ARN) string {
+	return arn.ARN{
+		Partition: principal.Partition,
+		Service:   "iam",
+		AccountID: principal.AccountID,
+		Resource:  "root",
+	}.String()
+}
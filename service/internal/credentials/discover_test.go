@@ -0,0 +1,137 @@
+package credentials
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/cello-proj/cello/internal/types"
+	"github.com/stretchr/testify/assert"
+)
+
+const testPrincipal = "arn:aws:iam::111111111111:role/cello"
+
+type mockDiscoveryIAM struct {
+	roles    []*iam.Role
+	policies map[string][]string
+	err      error
+}
+
+func (m mockDiscoveryIAM) ListRolesPagesWithContext(ctx aws.Context, input *iam.ListRolesInput, fn func(*iam.ListRolesOutput, bool) bool, opts ...request.Option) error {
+	if m.err != nil {
+		return m.err
+	}
+	fn(&iam.ListRolesOutput{Roles: m.roles}, true)
+	return nil
+}
+
+func (m mockDiscoveryIAM) ListAttachedRolePoliciesPagesWithContext(ctx aws.Context, input *iam.ListAttachedRolePoliciesInput, fn func(*iam.ListAttachedRolePoliciesOutput, bool) bool, opts ...request.Option) error {
+	out := &iam.ListAttachedRolePoliciesOutput{}
+	for _, p := range m.policies[aws.StringValue(input.RoleName)] {
+		out.AttachedPolicies = append(out.AttachedPolicies, &iam.AttachedPolicy{PolicyArn: aws.String(p)})
+	}
+	fn(out, true)
+	return nil
+}
+
+func testRole(name, trust string) *iam.Role {
+	return &iam.Role{
+		Arn:                      aws.String("arn:aws:iam::012345678901:role/" + name),
+		RoleName:                 aws.String(name),
+		AssumeRolePolicyDocument: aws.String(url.QueryEscape(trust)),
+	}
+}
+
+func TestDiscoverTargets(t *testing.T) {
+	tests := []struct {
+		name      string
+		accountID string
+		iam       mockDiscoveryIAM
+		want      []types.Target
+		wantErr   string
+	}{
+		{
+			name:      "discovers assumable roles",
+			accountID: "012345678901",
+			iam: mockDiscoveryIAM{
+				roles: []*iam.Role{
+					testRole("deploy-role", `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Principal":{"AWS":"arn:aws:iam::111111111111:role/cello"},"Action":"sts:AssumeRole"}]}`),
+					testRole("account-trust", `{"Version":"2012-10-17","Statement":{"Effect":"Allow","Principal":{"AWS":["arn:aws:iam::111111111111:root"]},"Action":["sts:AssumeRole"]}}`),
+					testRole("other-account", `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Principal":{"AWS":"arn:aws:iam::222222222222:root"},"Action":"sts:AssumeRole"}]}`),
+					testRole("service-role", `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Principal":{"Service":"ec2.amazonaws.com"},"Action":"sts:AssumeRole"}]}`),
+					testRole("denied", `{"Version":"2012-10-17","Statement":[{"Effect":"Deny","Principal":{"AWS":"arn:aws:iam::111111111111:role/cello"},"Action":"sts:AssumeRole"}]}`),
+					testRole("not-principal", `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","NotPrincipal":{"AWS":"arn:aws:iam::222222222222:root"},"Action":"sts:AssumeRole"}]}`),
+				},
+				policies: map[string][]string{
+					"deploy-role": {"arn:aws:iam::aws:policy/ReadOnlyAccess"},
+				},
+			},
+			want: []types.Target{
+				{
+					Name: "deploy_role",
+					Type: "aws_account",
+					Properties: types.TargetProperties{
+						CredentialType: "assumed_role",
+						PolicyArns:     []string{"arn:aws:iam::aws:policy/ReadOnlyAccess"},
+						RoleArn:        "arn:aws:iam::012345678901:role/deploy-role",
+					},
+				},
+				{
+					Name: "account_trust",
+					Type: "aws_account",
+					Properties: types.TargetProperties{
+						CredentialType: "assumed_role",
+						PolicyArns:     []string{},
+						RoleArn:        "arn:aws:iam::012345678901:role/account-trust",
+					},
+				},
+			},
+		},
+		{
+			name:      "credentials for another account",
+			accountID: "999999999999",
+			iam: mockDiscoveryIAM{
+				roles: []*iam.Role{testRole("deploy-role", `{"Statement":[]}`)},
+			},
+			wantErr: "credentials are for account '012345678901', not '999999999999'",
+		},
+		{
+			name:      "invalid trust policy",
+			accountID: "012345678901",
+			iam: mockDiscoveryIAM{
+				roles: []*iam.Role{testRole("deploy-role", `not json`)},
+			},
+			wantErr: "unable to read trust policy of role 'arn:aws:iam::012345678901:role/deploy-role': invalid character 'o' in literal null (expecting 'u')",
+		},
+		{
+			name:      "error listing roles",
+			accountID: "012345678901",
+			iam:       mockDiscoveryIAM{err: errors.New("boom")},
+			wantErr:   "unable to list roles: boom",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := TargetDiscoverer{iamSvc: tt.iam, principal: testPrincipal}
+
+			got, err := d.DiscoverTargets(context.Background(), tt.accountID)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+
+			assert.Nil(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestTargetName(t *testing.T) {
+	assert.Equal(t, "my_role_name", targetName("my-role.name"))
+	assert.Len(t, targetName("a-very-long-role-name-that-exceeds-the-limit"), maxTargetNameLength)
+}
@@ -0,0 +1,62 @@
+package credentials
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/arn"
+	"github.com/aws/aws-sdk-go/aws/client"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/sts"
+	"github.com/cello-proj/cello/internal/types"
+)
+
+const liveValidationSessionName = "cello-target-validation"
+
+type stsAPI interface {
+	AssumeRoleWithContext(ctx aws.Context, input *sts.AssumeRoleInput, opts ...request.Option) (*sts.AssumeRoleOutput, error)
+}
+
+// LiveValidator validates targets against the live AWS account. Unlike
+// Target.Validate it makes network calls and requires AWS credentials.
+type LiveValidator struct {
+	stsSvc stsAPI
+}
+
+// NewLiveValidator returns a new LiveValidator using the provided AWS session.
+func NewLiveValidator(p client.ConfigProvider) LiveValidator {
+	return LiveValidator{
+		stsSvc: sts.New(p),
+	}
+}
+
+// ValidateTargetLive confirms the target role can be assumed by the current
+// AWS principal. The role usually lives in another account, so it is only
+// checked with sts:AssumeRole on the full ARN; STS does not distinguish a
+// missing role from one whose trust policy rejects cello. It should be called
+// after Target.Validate.
+func (v LiveValidator) ValidateTargetLive(ctx context.Context, t types.Target) error {
+	roleArn := t.Properties.RoleArn
+
+	a, err := arn.Parse(roleArn)
+	if err != nil {
+		return fmt.Errorf("role_arn must be a valid arn: %w", err)
+	}
+
+	if !strings.HasPrefix(a.Resource, "role/") {
+		return fmt.Errorf("role_arn '%s' is not a role", roleArn)
+	}
+
+	input := &sts.AssumeRoleInput{
+		DurationSeconds: aws.Int64(900),
+		RoleArn:         aws.String(roleArn),
+		RoleSessionName: aws.String(liveValidationSessionName),
+	}
+	if _, err := v.stsSvc.AssumeRoleWithContext(ctx, input); err != nil {
+		return fmt.Errorf("role_arn '%s' is not assumable: %w", roleArn, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,68 @@
+package credentials
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/sts"
+	"github.com/cello-proj/cello/internal/types"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockSTS struct {
+	err   error
+	input *sts.AssumeRoleInput
+}
+
+func (m *mockSTS) AssumeRoleWithContext(ctx aws.Context, input *sts.AssumeRoleInput, opts ...request.Option) (*sts.AssumeRoleOutput, error) {
+	m.input = input
+	return &sts.AssumeRoleOutput{}, m.err
+}
+
+func TestValidateTargetLive(t *testing.T) {
+	tests := []struct {
+		name    string
+		roleArn string
+		stsErr  error
+		wantErr string
+	}{
+		{
+			name:    "valid",
+			roleArn: "arn:aws:iam::012345678901:role/test-role",
+		},
+		{
+			name:    "valid with path",
+			roleArn: "arn:aws:iam::012345678901:role/some/path/test-role",
+		},
+		{
+			name:    "not a role",
+			roleArn: "arn:aws:iam::012345678901:policy/test-policy",
+			wantErr: "role_arn 'arn:aws:iam::012345678901:policy/test-policy' is not a role",
+		},
+		{
+			name:    "not assumable",
+			roleArn: "arn:aws:iam::012345678901:role/test-role",
+			stsErr:  errors.New("access denied"),
+			wantErr: "role_arn 'arn:aws:iam::012345678901:role/test-role' is not assumable: access denied",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stsSvc := &mockSTS{err: tt.stsErr}
+			v := LiveValidator{stsSvc: stsSvc}
+
+			target := types.Target{Properties: types.TargetProperties{RoleArn: tt.roleArn}}
+			err := v.ValidateTargetLive(context.Background(), target)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.roleArn, aws.StringValue(stsSvc.input.RoleArn))
+			}
+		})
+	}
+}
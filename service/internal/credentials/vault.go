@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/cello-proj/cello/internal/responses"
 	"github.com/cello-proj/cello/internal/types"
@@ -24,7 +25,7 @@ const (
 type Provider interface {
 	CreateProject(string) (types.Token, error)
 	CreateTarget(string, types.Target) error
-	CreateToken(string) (types.Token, error)
+	CreateToken(string, time.Duration) (types.Token, error)
 	UpdateTarget(string, types.Target) error
 	DeleteProject(string) error
 	DeleteTarget(string, string) error
@@ -63,8 +64,17 @@ var (
 	ErrTargetNotFound = errors.New("target not found")
 	// ErrProjectTokenNotFound conveys that the token was not found.
 	ErrProjectTokenNotFound = errors.New("project token not found")
+	// ErrNoTargets conveys that the project has no targets.
+	ErrNoTargets = errors.New("project has no targets")
+	// ErrUnsupportedTargetType conveys that targets of the type cannot be
+	// stored by the credentials provider.
+	ErrUnsupportedTargetType = errors.New("unsupported target type")
 )
 
+// vaultTargetTypes are the target types backed by the Vault AWS secrets
+// engine.
+var vaultTargetTypes = []string{"aws_account"}
+
 type VaultProvider struct {
 	roleID          string
 	secretID        string
@@ -193,14 +203,16 @@ func genProjectAppRole(name string) string {
 	return fmt.Sprintf("%s/%s-%s", vaultAppRolePrefix, vaultProjectPrefix, name)
 }
 
-func (v VaultProvider) CreateToken(name string) (types.Token, error) {
+// CreateToken creates a token for the project. A ttl of zero uses the
+// lifetime configured on the project's app role.
+func (v VaultProvider) CreateToken(name string, ttl time.Duration) (types.Token, error) {
 	token := types.Token{}
 
 	if !v.isAdmin() {
 		return token, errors.New("admin credentials must be used to create token")
 	}
 
-	secret, err := v.generateSecrets(name)
+	secret, err := v.generateSecrets(name, ttl)
 	if err != nil {
 		return token, err
 	}
@@ -241,7 +253,7 @@ func (v VaultProvider) CreateProject(name string) (types.Token, error) {
 		return token, err
 	}
 
-	return v.CreateToken(name)
+	return v.CreateToken(name, 0)
 }
 
 // CreateTarget creates a target for the project.
@@ -252,6 +264,10 @@ func (v VaultProvider) CreateTarget(projectName string, target types.Target) err
 		return errors.New("admin credentials must be used to create target")
 	}
 
+	if err := ValidateTargetType(target.Type); err != nil {
+		return err
+	}
+
 	options := map[string]interface{}{
 		"credential_type": target.Properties.CredentialType,
 		"policy_arns":     target.Properties.PolicyArns,
@@ -462,6 +478,30 @@ func (v VaultProvider) ListTargets(project string) ([]string, error) {
 	return list, nil
 }
 
+// ValidateTargetType returns ErrUnsupportedTargetType if targets of the type
+// cannot be stored in Vault.
+func ValidateTargetType(targetType string) error {
+	for _, t := range vaultTargetTypes {
+		if t == targetType {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w '%s', must be one of '%s'", ErrUnsupportedTargetType, targetType, strings.Join(vaultTargetTypes, " "))
+}
+
+// RequireTargets returns ErrNoTargets if the project has no targets.
+func RequireTargets(p Provider, project string) error {
+	targets, err := p.ListTargets(project)
+	if err != nil {
+		return err
+	}
+
+	if len(targets) == 0 {
+		return ErrNoTargets
+	}
+	return nil
+}
+
 func (v VaultProvider) ProjectExists(name string) (bool, error) {
 	p, err := v.GetProject(name)
 	if errors.Is(err, ErrNotFound) {
@@ -495,10 +535,13 @@ func (v VaultProvider) readSecretIDAccessor(appRoleName, accessor string) (*vaul
 	return secret, nil
 }
 
-func (v VaultProvider) generateSecrets(appRoleName string) (*vault.Secret, error) {
+func (v VaultProvider) generateSecrets(appRoleName string, ttl time.Duration) (*vault.Secret, error) {
 	options := map[string]interface{}{
 		"force": true,
 	}
+	if ttl > 0 {
+		options["ttl"] = fmt.Sprintf("%ds", int64(ttl/time.Second))
+	}
 
 	secret, err := v.vaultLogicalSvc.Write(fmt.Sprintf("%s/secret-id", genProjectAppRole(appRoleName)), options)
 	if err != nil {
@@ -1,8 +1,10 @@
 package credentials
 
 import (
+	"errors"
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/cello-proj/cello/internal/types"
 
@@ -84,14 +86,22 @@ func TestVaultCreateProject(t *testing.T) {
 
 func TestVaultCreateTarget(t *testing.T) {
 	tests := []struct {
-		name      string
-		admin     bool
-		vaultErr  error
-		errResult bool
+		name       string
+		admin      bool
+		targetType string
+		vaultErr   error
+		errResult  bool
 	}{
 		{
-			name:  "create target success",
-			admin: true,
+			name:       "create target success",
+			admin:      true,
+			targetType: "aws_account",
+		},
+		{
+			name:       "create target unsupported type error",
+			admin:      true,
+			targetType: "gcp_project",
+			errResult:  true,
 		},
 		{
 			name:      "create target admin error",
@@ -117,7 +127,7 @@ func TestVaultCreateTarget(t *testing.T) {
 				vaultLogicalSvc: &mockVaultLogical{err: tt.vaultErr},
 			}
 
-			err := v.CreateTarget("test", types.Target{})
+			err := v.CreateTarget("test", types.Target{Type: tt.targetType})
 			if err != nil {
 				if !tt.errResult {
 					t.Errorf("\ndid not expect error, got: %v", err)
@@ -709,3 +719,104 @@ func (m mockVaultSys) PutPolicy(name, rules string) error {
 func (m mockVaultSys) DeletePolicy(name string) error {
 	return m.err
 }
+
+func TestRequireTargets(t *testing.T) {
+	tests := []struct {
+		name     string
+		targets  []string
+		vaultErr error
+		want     error
+	}{
+		{
+			name:    "project with targets",
+			targets: []string{"target1"},
+		},
+		{
+			name: "project without targets",
+			want: ErrNoTargets,
+		},
+		{
+			name:     "list targets error",
+			vaultErr: errTest,
+			want:     errTest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var testTargets []interface{}
+			for _, i := range tt.targets {
+				testTargets = append(testTargets, fmt.Sprintf("argo-cloudops-projects-test-target-%s", i))
+			}
+			// Targets belonging to other projects must not count.
+			testTargets = append(testTargets, "argo-cloudops-projects-other-target-target1")
+
+			v := VaultProvider{
+				roleID: authorizationKeyAdmin,
+				vaultLogicalSvc: &mockVaultLogical{err: tt.vaultErr, data: map[string]interface{}{
+					"keys": testTargets,
+				}},
+			}
+
+			err := RequireTargets(v, "test")
+			if !errors.Is(err, tt.want) {
+				t.Errorf("\nwant: %v\n got: %v", tt.want, err)
+			}
+		})
+	}
+}
+
+type recordingVaultLogical struct {
+	mockVaultLogical
+	writes map[string]map[string]interface{}
+}
+
+func (m *recordingVaultLogical) Write(path string, data map[string]interface{}) (*vault.Secret, error) {
+	m.writes[path] = data
+	return m.mockVaultLogical.Write(path, data)
+}
+
+func TestVaultCreateTokenTTL(t *testing.T) {
+	tests := []struct {
+		name    string
+		ttl     time.Duration
+		wantTTL interface{}
+	}{
+		{
+			name: "role default ttl",
+		},
+		{
+			name:    "project ttl",
+			ttl:     time.Hour,
+			wantTTL: "3600s",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logical := &recordingVaultLogical{
+				mockVaultLogical: mockVaultLogical{data: map[string]interface{}{
+					"creation_time":      "2022-06-21T14:56:10.341066-07:00",
+					"expiration_time":    "2022-06-21T15:56:10.341066-07:00",
+					"role_id":            "role-id",
+					"secret_id":          "secret",
+					"secret_id_accessor": "secret-id-accessor",
+				}},
+				writes: map[string]map[string]interface{}{},
+			}
+			v := VaultProvider{
+				roleID:          authorizationKeyAdmin,
+				vaultLogicalSvc: logical,
+			}
+
+			if _, err := v.CreateToken("test", tt.ttl); err != nil {
+				t.Fatalf("\ndid not expect error, got: %v", err)
+			}
+
+			got := logical.writes["auth/approle/role/argo-cloudops-projects-test/secret-id"]["ttl"]
+			if got != tt.wantTTL {
+				t.Errorf("\nwant: %v\n got: %v", tt.wantTTL, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package db
+
+import (
+	"encoding/base64"
+	"errors"
+	"time"
+)
+
+const defaultTombstoneRetention = 7 * 24 * time.Hour
+
+var (
+	// ErrInvalidSyncToken conveys that a sync token could not be decoded.
+	ErrInvalidSyncToken = errors.New("invalid sync token")
+	// ErrSyncTokenExpired conveys that the sync token is older than the
+	// tombstone retention window and the client must do a full resync.
+	ErrSyncTokenExpired = errors.New("sync token expired")
+)
+
+// TokenChangeSet describes the tokens created and deleted since a sync token.
+type TokenChangeSet struct {
+	Created       []TokenEntry
+	Deleted       []string
+	NextSyncToken string
+}
+
+// TokenTombstone records the deletion of a token.
+type TokenTombstone struct {
+	DeletedAt time.Time `db:"deleted_at"`
+	ProjectID string    `db:"project"`
+	TokenID   string    `db:"token_id"`
+}
+
+func encodeSyncToken(t time.Time) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(t.UTC().Format(time.RFC3339Nano)))
+}
+
+// decodeSyncToken decodes the sync token into the time it was issued. An
+// empty sync token returns the zero time, meaning all changes. Tokens older
+// than the retention window are rejected since tombstones for deletions
+// before then may have been purged.
+func decodeSyncToken(syncToken string, now time.Time, retention time.Duration) (time.Time, error) {
+	if syncToken == "" {
+		return time.Time{}, nil
+	}
+
+	b, err := base64.RawURLEncoding.DecodeString(syncToken)
+	if err != nil {
+		return time.Time{}, ErrInvalidSyncToken
+	}
+
+	since, err := time.Parse(time.RFC3339Nano, string(b))
+	if err != nil {
+		return time.Time{}, ErrInvalidSyncToken
+	}
+
+	if since.Before(now.Add(-retention)) {
+		return time.Time{}, ErrSyncTokenExpired
+	}
+
+	return since, nil
+}
@@ -0,0 +1,52 @@
+package db
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecodeSyncToken(t *testing.T) {
+	now := time.Date(2022, 1, 8, 12, 0, 0, 0, time.UTC)
+	issued := time.Date(2022, 1, 7, 12, 0, 0, 123, time.UTC)
+
+	tests := []struct {
+		name      string
+		syncToken string
+		want      time.Time
+		wantErr   error
+	}{
+		{
+			name: "empty sync token returns everything",
+		},
+		{
+			name:      "round trip",
+			syncToken: encodeSyncToken(issued),
+			want:      issued,
+		},
+		{
+			name:      "not base64",
+			syncToken: "!!!",
+			wantErr:   ErrInvalidSyncToken,
+		},
+		{
+			name:      "not a timestamp",
+			syncToken: "Zm9v",
+			wantErr:   ErrInvalidSyncToken,
+		},
+		{
+			name:      "older than retention",
+			syncToken: encodeSyncToken(now.Add(-defaultTombstoneRetention - time.Second)),
+			wantErr:   ErrSyncTokenExpired,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := decodeSyncToken(tt.syncToken, now, defaultTombstoneRetention)
+			assert.Equal(t, tt.wantErr, err)
+			assert.True(t, tt.want.Equal(got))
+		})
+	}
+}
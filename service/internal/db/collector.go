@@ -0,0 +1,113 @@
+package db
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	defaultCollectorCacheTTL = 30 * time.Second
+	collectorTimeout         = 10 * time.Second
+)
+
+// CollectorOption is a function for configuring the Collector.
+type CollectorOption func(*Collector)
+
+// WithCollectorCacheTTL sets how long stats are reused between scrapes.
+// Defaults to 30 seconds.
+func WithCollectorCacheTTL(d time.Duration) CollectorOption {
+	return func(c *Collector) {
+		c.cacheTTL = d
+	}
+}
+
+// Collector is a prometheus.Collector reporting system-wide project and token
+// gauges from SystemStats. Stats are cached for a short time to bound the
+// cost of frequent scrapes.
+type Collector struct {
+	client   Client
+	cacheTTL time.Duration
+	now      func() time.Time
+
+	mu       sync.Mutex
+	stats    SystemStats
+	cachedAt time.Time
+
+	totalProjects *prometheus.Desc
+	totalTokens   *prometheus.Desc
+	expiredTokens *prometheus.Desc
+	nextExpiry    *prometheus.Desc
+}
+
+// NewCollector returns a Collector backed by the client.
+func NewCollector(c Client, opts ...CollectorOption) *Collector {
+	col := &Collector{
+		client:   c,
+		cacheTTL: defaultCollectorCacheTTL,
+		now:      time.Now,
+
+		totalProjects: prometheus.NewDesc("cello_db_projects", "Total number of projects.", nil, nil),
+		totalTokens:   prometheus.NewDesc("cello_db_tokens", "Total number of tokens.", nil, nil),
+		expiredTokens: prometheus.NewDesc("cello_db_expired_tokens", "Number of tokens past their expiry.", nil, nil),
+		nextExpiry:    prometheus.NewDesc("cello_db_next_token_expiry_timestamp_seconds", "Unix time of the soonest upcoming token expiry, 0 if none.", nil, nil),
+	}
+
+	for _, opt := range opts {
+		opt(col)
+	}
+
+	return col
+}
+
+// Describe implements prometheus.Collector.
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.totalProjects
+	ch <- c.totalTokens
+	ch <- c.expiredTokens
+	ch <- c.nextExpiry
+}
+
+// Collect implements prometheus.Collector.
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	stats, err := c.systemStats()
+	if err != nil {
+		ch <- prometheus.NewInvalidMetric(c.totalProjects, err)
+		return
+	}
+
+	var nextExpiry float64
+	if !stats.NextExpiry.IsZero() {
+		nextExpiry = float64(stats.NextExpiry.Unix())
+	}
+
+	ch <- prometheus.MustNewConstMetric(c.totalProjects, prometheus.GaugeValue, float64(stats.TotalProjects))
+	ch <- prometheus.MustNewConstMetric(c.totalTokens, prometheus.GaugeValue, float64(stats.TotalTokens))
+	ch <- prometheus.MustNewConstMetric(c.expiredTokens, prometheus.GaugeValue, float64(stats.ExpiredTokens))
+	ch <- prometheus.MustNewConstMetric(c.nextExpiry, prometheus.GaugeValue, nextExpiry)
+}
+
+// systemStats returns the cached stats, refreshing them if they are stale.
+func (c *Collector) systemStats() (SystemStats, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	if !c.cachedAt.IsZero() && now.Sub(c.cachedAt) < c.cacheTTL {
+		return c.stats, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), collectorTimeout)
+	defer cancel()
+
+	stats, err := c.client.SystemStats(ctx, now)
+	if err != nil {
+		return SystemStats{}, err
+	}
+
+	c.stats = stats
+	c.cachedAt = now
+	return stats, nil
+}
@@ -0,0 +1,80 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+// statsClient is a fake Client serving fixed stats.
+type statsClient struct {
+	Client
+
+	stats SystemStats
+	err   error
+	calls int
+}
+
+func (c *statsClient) SystemStats(ctx context.Context, now time.Time) (SystemStats, error) {
+	c.calls++
+	return c.stats, c.err
+}
+
+func TestCollector(t *testing.T) {
+	client := &statsClient{
+		stats: SystemStats{
+			TotalProjects: 3,
+			TotalTokens:   7,
+			ExpiredTokens: 2,
+			NextExpiry:    time.Unix(1700000000, 0),
+		},
+	}
+
+	c := NewCollector(client)
+
+	want := `
+# HELP cello_db_expired_tokens Number of tokens past their expiry.
+# TYPE cello_db_expired_tokens gauge
+cello_db_expired_tokens 2
+# HELP cello_db_next_token_expiry_timestamp_seconds Unix time of the soonest upcoming token expiry, 0 if none.
+# TYPE cello_db_next_token_expiry_timestamp_seconds gauge
+cello_db_next_token_expiry_timestamp_seconds 1.7e+09
+# HELP cello_db_projects Total number of projects.
+# TYPE cello_db_projects gauge
+cello_db_projects 3
+# HELP cello_db_tokens Total number of tokens.
+# TYPE cello_db_tokens gauge
+cello_db_tokens 7
+`
+	assert.Nil(t, testutil.CollectAndCompare(c, strings.NewReader(want)))
+}
+
+func TestCollectorCache(t *testing.T) {
+	client := &statsClient{}
+
+	now := time.Now()
+	c := NewCollector(client, WithCollectorCacheTTL(time.Minute))
+	c.now = func() time.Time { return now }
+
+	testutil.CollectAndCount(c)
+	testutil.CollectAndCount(c)
+	assert.Equal(t, 1, client.calls)
+
+	now = now.Add(time.Minute)
+	testutil.CollectAndCount(c)
+	assert.Equal(t, 2, client.calls)
+}
+
+func TestCollectorError(t *testing.T) {
+	client := &statsClient{err: errors.New("connection refused")}
+
+	c := NewCollector(client)
+
+	assert.NotNil(t, testutil.CollectAndCompare(c, strings.NewReader("")))
+	assert.Equal(t, 1, client.calls)
+}
@@ -4,23 +4,66 @@ package db
 
 import (
 	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
 
 	"github.com/cello-proj/cello/internal/types"
+	"github.com/cello-proj/cello/internal/validations"
+	"github.com/go-kit/log"
 
 	"github.com/upper/db/v4"
 	"github.com/upper/db/v4/adapter/postgresql"
 )
 
 type ProjectEntry struct {
-	ProjectID  string `db:"project"`
-	Repository string `db:"repository"`
+	ProjectID  string       `db:"project" valid:"required~project_id is required,alphanum~project_id must be alphanumeric,stringlength(4|32)~project_id must be between 4 and 32 characters"`
+	Repository string       `db:"repository" valid:"required~repository is required"`
+	Quota      ProjectQuota `db:"quota"`
 }
 
+// Validate validates ProjectEntry.
+func (pe ProjectEntry) Validate() error {
+	v := []func() error{
+		func() error { return validations.ValidateStruct(pe) },
+		func() error {
+			if !validations.IsValidGitURI(pe.Repository) {
+				return errors.New("repository must be a git uri")
+			}
+			return nil
+		},
+	}
+
+	return validations.Validate(v...)
+}
+
+var (
+	// ErrProjectNotFound conveys that the project does not exist.
+	ErrProjectNotFound = errors.New("project not found")
+	// ErrProjectExists conveys that a project with the same id already exists.
+	ErrProjectExists = errors.New("project already exists")
+	// ErrTokenNotFound conveys that the token does not exist.
+	ErrTokenNotFound = errors.New("token not found")
+	// ErrInvalidConfig conveys that the client configuration is invalid.
+	ErrInvalidConfig = errors.New("invalid db configuration")
+)
+
+// TokenEntry is a stored token. It never carries the token secret; the secret
+// is only returned once, by IssueToken.
 type TokenEntry struct {
 	CreatedAt string `db:"created_at"`
 	ExpiresAt string `db:"expires_at"`
 	ProjectID string `db:"project"`
 	TokenID   string `db:"token_id"`
+	RoleID    string `db:"role_id"`
+
+	// SecretCorrupt is set by ReadTokenEntry when the stored secret hash is
+	// missing or malformed, so the token can still be inspected and deleted.
+	// Tokens created by CreateTokenEntry have no stored hash. Such tokens
+	// fail verification with ErrTokenSecretCorrupt.
+	SecretCorrupt bool `db:"-"`
 }
 
 // IsEmpty returns whether a struct is empty.
@@ -31,12 +74,45 @@ func (t TokenEntry) IsEmpty() bool {
 // Client allows for db crud operations
 type Client interface {
 	CreateProjectEntry(ctx context.Context, pe ProjectEntry) error
+	CreateProjectWithToken(ctx context.Context, pe ProjectEntry, token types.Token) error
+	ImportProject(ctx context.Context, data []byte) error
 	DeleteProjectEntry(ctx context.Context, project string) error
+	RestoreProjectEntry(ctx context.Context, project string) error
+	PurgeDeletedProjects(ctx context.Context, now time.Time) (int, error)
 	ReadProjectEntry(ctx context.Context, project string) (ProjectEntry, error)
+	ReadProjectEntryWithETag(ctx context.Context, project string) (ProjectEntry, string, error)
+	UpdateProjectEntry(ctx context.Context, pe ProjectEntry) error
+	UpdateProjectEntryIfMatch(ctx context.Context, pe ProjectEntry, etag string) error
+	FindDuplicateRepositories(ctx context.Context) (map[string][]string, error)
+	ListProjectEntries(ctx context.Context, opts ListOptions) ([]ProjectEntry, string, error)
+	ListProjectsByRepository(ctx context.Context, repository string) ([]ProjectEntry, error)
+	StreamProjectEntries(ctx context.Context) (<-chan ProjectEntry, <-chan error)
+	ReadProjectActivity(ctx context.Context, project string) (ProjectEntry, time.Time, error)
+	ReadProjectsWithTokenCounts(ctx context.Context, ids []string) ([]ProjectSummary, error)
 	CreateTokenEntry(ctx context.Context, token types.Token) error
+	CreateTokenEntries(ctx context.Context, tokens []types.Token) error
+	IssueToken(ctx context.Context, project, roleID string, ttl time.Duration) (types.Token, error)
+	VerifyTokenSecret(ctx context.Context, token, secret string) (TokenEntry, error)
+	VerifyAndTouch(ctx context.Context, project, token, secret string) (TokenEntry, error)
+	AddPendingSecret(ctx context.Context, token string, ttl time.Duration) (string, error)
+	PromotePendingSecret(ctx context.Context, token string) error
 	DeleteTokenEntry(ctx context.Context, token string) error
+	DeleteExpiredTokens(ctx context.Context, project string, now time.Time) (int, error)
+	NextTokenSequence(ctx context.Context, project string) (int64, error)
 	ReadTokenEntry(ctx context.Context, token string) (TokenEntry, error)
+	ReadTokenEntryScoped(ctx context.Context, project, token string) (TokenEntry, error)
+	CountTokenEntries(ctx context.Context, project string) (int, error)
+	PreviewAffectedTokenCount(ctx context.Context, project string, predicate Predicate) (int, error)
 	ListTokenEntries(ctx context.Context, project string) ([]TokenEntry, error)
+	ListTokenEntriesCreatedBetween(ctx context.Context, project string, start, end time.Time) ([]TokenEntry, error)
+	ListTokenEntriesWithTTL(ctx context.Context, project string, now time.Time) ([]TokenWithTTL, error)
+	ListTokenEntriesByUrgency(ctx context.Context, project string, now time.Time) ([]TokenWithTTL, error)
+	ListTokenEntriesPaged(ctx context.Context, project string, limit int, cursor string) (ListTokenEntriesResult, error)
+	ListExpiredTokenEntriesGlobal(ctx context.Context, now time.Time, limit int) ([]TokenEntry, error)
+	ListTokenChanges(ctx context.Context, project, syncToken string) (TokenChangeSet, error)
+	ListTokenHistory(ctx context.Context, project string) ([]TokenHistoryEntry, error)
+	PurgeTokenTombstones(ctx context.Context, before time.Time) (int, error)
+	SystemStats(ctx context.Context, now time.Time) (SystemStats, error)
 	Health(ctx context.Context) error
 }
 
@@ -47,142 +123,946 @@ type SQLClient struct {
 	user     string
 	password string
 	options  map[string]string
+
+	lazyExpiry          bool
+	lazyExpiryDelete    bool
+	now                 func() time.Time
+	tombstoneRetention  time.Duration
+	projectRetention    time.Duration
+	lowercaseProjectIDs bool
+
+	replicaHost   string
+	maxReplicaLag time.Duration
+	replicaLag    func(context.Context, db.Session) (time.Duration, error)
+	open          func(db.ConnectionURL) (db.Session, error)
+
+	unknownOptionsLogger log.Logger
+	scopedProjectCheck   bool
+
+	primary         *sessionPool
+	replica         *sessionPool
+	maxOpenConns    int
+	maxIdleConns    int
+	connMaxLifetime time.Duration
+
+	defaultTimeout time.Duration
+}
+
+// Option is a function for configuring the SQLClient
+type Option func(*SQLClient)
+
+// WithLowercaseProjectIDs canonicalizes project ids to lowercase on write and
+// lookup so ids differing only by case refer to the same project. Project
+// names are alphanumeric, so the lowercased id always satisfies the naming
+// policy. Existing mixed-case rows must be migrated before enabling.
+func WithLowercaseProjectIDs() Option {
+	return func(c *SQLClient) {
+		c.lowercaseProjectIDs = true
+	}
+}
+
+// WithTombstoneRetention sets how long token deletions are tracked for
+// ListTokenChanges. Defaults to 7 days.
+func WithTombstoneRetention(d time.Duration) Option {
+	return func(c *SQLClient) {
+		c.tombstoneRetention = d
+	}
+}
+
+// WithLazyExpiry makes ReadTokenEntry return ErrTokenExpired for tokens past
+// their expiry. When deleteExpired is true, the expired token is also deleted
+// on a best-effort basis.
+func WithLazyExpiry(deleteExpired bool) Option {
+	return func(c *SQLClient) {
+		c.lazyExpiry = true
+		c.lazyExpiryDelete = deleteExpired
+	}
 }
 
 const (
-	ProjectEntryDB = "projects"
-	TokenEntryDB   = "tokens"
+	ProjectEntryDB   = "projects"
+	TokenEntryDB     = "tokens"
+	TokenTombstoneDB = "token_tombstones"
+	TokenSequenceDB  = "token_sequences"
+	TokenHistoryDB   = "token_history"
 )
 
-func NewSQLClient(host, database, user, password string, options map[string]string) (SQLClient, error) {
-	return SQLClient{
+// tokenEntryColumns is the allowlist of token columns fetched by list
+// queries. It must never include secret material.
+var tokenEntryColumns = []interface{}{"created_at", "expires_at", "project", "token_id", "role_id"}
+
+func NewSQLClient(host, database, user, password string, options map[string]string, opts ...Option) (SQLClient, error) {
+	c := SQLClient{
 		host:     host,
 		database: database,
 		user:     user,
 		password: password,
 		options:  options,
-	}, nil
+		now:      time.Now,
+
+		tombstoneRetention: defaultTombstoneRetention,
+		projectRetention:   defaultProjectRetention,
+		replicaLag:         replicaLag,
+		open:               postgresql.Open,
+		primary:            &sessionPool{},
+		replica:            &sessionPool{},
+	}
+
+	for _, opt := range opts {
+		opt(&c)
+	}
+
+	if err := c.validate(); err != nil {
+		return SQLClient{}, err
+	}
+
+	if err := c.checkOptions(); err != nil {
+		return SQLClient{}, err
+	}
+
+	return c, nil
+}
+
+// validate returns ErrInvalidConfig if a required connection setting is
+// missing.
+func (d SQLClient) validate() error {
+	required := []struct {
+		name  string
+		value string
+	}{
+		{"host", d.host},
+		{"database", d.database},
+		{"user", d.user},
+	}
+
+	for _, r := range required {
+		if strings.TrimSpace(r.value) == "" {
+			return fmt.Errorf("%w: %s is required", ErrInvalidConfig, r.name)
+		}
+	}
+	return nil
+}
+
+// projectID returns the canonical form of the project id.
+func (d SQLClient) projectID(project string) string {
+	if d.lowercaseProjectIDs {
+		return strings.ToLower(project)
+	}
+	return project
 }
 
+// createSession returns the shared session for the primary database. It must
+// not be closed by callers.
 func (d SQLClient) createSession() (db.Session, error) {
+	return d.session(d.primary, d.host)
+}
+
+func (d SQLClient) openSession(host string) (db.Session, error) {
 	settings := postgresql.ConnectionURL{
-		Host:     d.host,
+		Host:     host,
 		Database: d.database,
 		User:     d.user,
 		Password: d.password,
 		Options:  d.options,
 	}
 
-	return postgresql.Open(settings)
+	return d.open(settings)
 }
 
 func (d SQLClient) Health(ctx context.Context) error {
+	ctx, cancel := d.withTimeout(ctx)
+	defer cancel()
+
 	sess, err := d.createSession()
 	if err != nil {
 		return err
 	}
-	defer sess.Close()
 
 	return sess.WithContext(ctx).Ping()
 }
 
+// CreateProjectEntry creates the project. The entry is validated before
+// anything is written. ErrProjectExists is returned if a project with the
+// same id already exists.
 func (d SQLClient) CreateProjectEntry(ctx context.Context, pe ProjectEntry) error {
+	ctx, cancel := d.withTimeout(ctx)
+	defer cancel()
+
+	pe.ProjectID = d.projectID(pe.ProjectID)
+
+	if err := pe.Validate(); err != nil {
+		return err
+	}
+
+	sess, err := d.createSession()
+	if err != nil {
+		return err
+	}
+
+	return sess.WithContext(ctx).Tx(func(sess db.Session) error {
+		return createProjectEntry(sess, pe, d.now())
+	})
+}
+
+// createProjectEntry inserts the project entry, returning ErrProjectExists
+// if a project with the same id is already present. A deleted project with the
+// same id is purged first, giving up its recovery.
+func createProjectEntry(sess db.Session, pe ProjectEntry, now time.Time) error {
+	if _, err := purgeDeletedProjects(sess, now, "project = ?", pe.ProjectID); err != nil {
+		return err
+	}
+
+	res, err := sess.SQL().
+		InsertInto(ProjectEntryDB).
+		Values(pe).
+		Amend(func(query string) string { return query + " ON CONFLICT (project) DO NOTHING" }).
+		Exec()
+	if err != nil {
+		return err
+	}
+
+	if err := requireRowsAffected(res); err != nil {
+		if errors.Is(err, db.ErrNoMoreRows) {
+			return fmt.Errorf("%w: '%s'", ErrProjectExists, pe.ProjectID)
+		}
+		return err
+	}
+	return nil
+}
+
+// CreateProjectWithToken creates the project and its first token in a single
+// transaction. Neither is written if either insert fails.
+func (d SQLClient) CreateProjectWithToken(ctx context.Context, pe ProjectEntry, token types.Token) error {
+	ctx, cancel := d.withTimeout(ctx)
+	defer cancel()
+
+	pe.ProjectID = d.projectID(pe.ProjectID)
+	token.ProjectID = d.projectID(token.ProjectID)
+
+	if token.ProjectID != pe.ProjectID {
+		return fmt.Errorf("token project '%s' does not match project '%s'", token.ProjectID, pe.ProjectID)
+	}
+
+	if err := pe.Validate(); err != nil {
+		return err
+	}
+
+	if err := validateTokenTimes(token); err != nil {
+		return err
+	}
+
 	sess, err := d.createSession()
 	if err != nil {
 		return err
 	}
-	defer sess.Close()
 
 	return sess.WithContext(ctx).Tx(func(sess db.Session) error {
-		if err := sess.Collection(ProjectEntryDB).Find("project", pe.ProjectID).Delete(); err != nil {
+		if err := createProjectEntry(sess, pe, d.now()); err != nil {
 			return err
 		}
 
-		if _, err = sess.Collection(ProjectEntryDB).Insert(pe); err != nil {
+		return createTokenEntry(sess, token)
+	})
+}
+
+// ImportProject restores a project and its token metadata from a document
+// created by ExportProject in a single transaction. It returns
+// ErrProjectExists rather than replace an existing project, and writes
+// nothing if any token cannot be imported.
+func (d SQLClient) ImportProject(ctx context.Context, data []byte) error {
+	ctx, cancel := d.withTimeout(ctx)
+	defer cancel()
+
+	pe, tokens, err := decodeProjectExport(data)
+	if err != nil {
+		return err
+	}
+
+	pe.ProjectID = d.projectID(pe.ProjectID)
+
+	if err := pe.Validate(); err != nil {
+		return err
+	}
+
+	for i := range tokens {
+		tokens[i].ProjectID = pe.ProjectID
+		if err := validateTokenTimes(tokens[i]); err != nil {
+			return fmt.Errorf("token %d: %w", i, err)
+		}
+	}
+
+	sess, err := d.createSession()
+	if err != nil {
+		return err
+	}
+
+	return sess.WithContext(ctx).Tx(func(sess db.Session) error {
+		if err := createProjectEntry(sess, pe, d.now()); err != nil {
 			return err
 		}
 
-		return nil
+		return createTokenEntries(sess, tokens)
 	})
 }
 
 func (d SQLClient) ReadProjectEntry(ctx context.Context, project string) (ProjectEntry, error) {
+	ctx, cancel := d.withTimeout(ctx)
+	defer cancel()
+
+	project = d.projectID(project)
+
 	res := ProjectEntry{}
 
 	sess, err := d.createSession()
 	if err != nil {
 		return res, err
 	}
-	defer sess.Close()
 
-	err = sess.WithContext(ctx).Collection(ProjectEntryDB).Find("project", project).One(&res)
-	return res, err
+	err = sess.WithContext(ctx).Collection(ProjectEntryDB).Find(liveProject(db.Cond{"project": project})).One(&res)
+	return res, notFound(err, ErrProjectNotFound)
+}
+
+// notFound translates a no rows error into the not found sentinel.
+func notFound(err, sentinel error) error {
+	if errors.Is(err, db.ErrNoMoreRows) {
+		return sentinel
+	}
+	return err
+}
+
+// ReadProjectEntryWithETag returns the project along with its ETag for use
+// with UpdateProjectEntryIfMatch.
+func (d SQLClient) ReadProjectEntryWithETag(ctx context.Context, project string) (ProjectEntry, string, error) {
+	ctx, cancel := d.withTimeout(ctx)
+	defer cancel()
+
+	pe, err := d.ReadProjectEntry(ctx, project)
+	if err != nil {
+		return pe, "", err
+	}
+
+	return pe, pe.ETag(), nil
+}
+
+// UpdateProjectEntryIfMatch updates the project only if it is unchanged since
+// the ETag was read, returning ErrVersionConflict otherwise. The entry is
+// validated before anything is read or written.
+func (d SQLClient) UpdateProjectEntryIfMatch(ctx context.Context, pe ProjectEntry, etag string) error {
+	ctx, cancel := d.withTimeout(ctx)
+	defer cancel()
+
+	pe.ProjectID = d.projectID(pe.ProjectID)
+
+	if err := pe.Validate(); err != nil {
+		return err
+	}
+
+	sess, err := d.createSession()
+	if err != nil {
+		return err
+	}
+
+	return sess.WithContext(ctx).Tx(func(sess db.Session) error {
+		stored := ProjectEntry{}
+		err := sess.SQL().
+			SelectFrom(ProjectEntryDB).
+			Where(liveProject(db.Cond{"project": pe.ProjectID})).
+			Amend(func(query string) string { return query + " FOR UPDATE" }).
+			One(&stored)
+		if err != nil {
+			return notFound(err, ErrProjectNotFound)
+		}
+
+		if err := checkETag(stored, etag); err != nil {
+			return err
+		}
+
+		return sess.Collection(ProjectEntryDB).Find("project", pe.ProjectID).Update(pe)
+	})
+}
+
+// UpdateProjectEntry updates an existing project. The entry is validated
+// before anything is written. ErrProjectNotFound is returned if the project
+// does not exist.
+func (d SQLClient) UpdateProjectEntry(ctx context.Context, pe ProjectEntry) error {
+	ctx, cancel := d.withTimeout(ctx)
+	defer cancel()
+
+	pe.ProjectID = d.projectID(pe.ProjectID)
+
+	if err := pe.Validate(); err != nil {
+		return err
+	}
+
+	sess, err := d.createSession()
+	if err != nil {
+		return err
+	}
+
+	return sess.WithContext(ctx).Tx(func(sess db.Session) error {
+		res, err := sess.SQL().
+			Update(ProjectEntryDB).
+			Set(map[string]interface{}{
+				"repository": pe.Repository,
+				"quota":      pe.Quota,
+			}).
+			Where(liveProject(db.Cond{"project": pe.ProjectID})).
+			Exec()
+		if err != nil {
+			return err
+		}
+
+		return notFound(requireRowsAffected(res), ErrProjectNotFound)
+	})
+}
+
+// requireRowsAffected returns db.ErrNoMoreRows if the statement matched no
+// rows.
+func requireRowsAffected(res sql.Result) error {
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		return db.ErrNoMoreRows
+	}
+	return nil
+}
+
+// ListProjectEntries returns a page of projects ordered by project id, along
+// with the cursor for the next page. The cursor is empty on the last page.
+func (d SQLClient) ListProjectEntries(ctx context.Context, opts ListOptions) ([]ProjectEntry, string, error) {
+	ctx, cancel := d.withTimeout(ctx)
+	defer cancel()
+
+	after, err := decodeKeyCursor(opts.Cursor)
+	if err != nil {
+		return nil, "", err
+	}
+
+	sess, err := d.createSession()
+	if err != nil {
+		return nil, "", err
+	}
+
+	cond := db.Cond{}
+	if after != "" {
+		cond["project >"] = after
+	}
+
+	res := []ProjectEntry{}
+	err = sess.WithContext(ctx).Collection(ProjectEntryDB).
+		Find(liveProject(cond)).
+		OrderBy("project").
+		Limit(opts.pageSize() + 1).
+		All(&res)
+	if err != nil {
+		return nil, "", err
+	}
+
+	entries, next := projectPage(res, opts.pageSize())
+	return entries, next, nil
+}
+
+// ReadProjectActivity returns the project along with the creation time of its
+// most recent token. The time is zero if the project has no tokens.
+func (d SQLClient) ReadProjectActivity(ctx context.Context, project string) (ProjectEntry, time.Time, error) {
+	ctx, cancel := d.withTimeout(ctx)
+	defer cancel()
+
+	project = d.projectID(project)
+
+	res := ProjectEntry{}
+
+	sess, err := d.createSession()
+	if err != nil {
+		return res, time.Time{}, err
+	}
+
+	row, err := sess.WithContext(ctx).SQL().QueryRow(
+		"SELECT p.project, COALESCE(p.repository, ''), p.quota, (SELECT MAX(t.created_at) FROM "+TokenEntryDB+" t WHERE t.project = p.project) FROM "+ProjectEntryDB+" p WHERE p.project = ? AND p.deleted_at IS NULL",
+		project,
+	)
+	if err != nil {
+		return res, time.Time{}, err
+	}
+
+	var lastTokenCreatedAt sql.NullTime
+	if err := row.Scan(&res.ProjectID, &res.Repository, &res.Quota, &lastTokenCreatedAt); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return res, time.Time{}, ErrProjectNotFound
+		}
+		return res, time.Time{}, err
+	}
+
+	return res, lastTokenCreatedAt.Time, nil
 }
 
+// DeleteProjectEntry deletes the project. The project is hidden immediately
+// but can be restored with RestoreProjectEntry until it is purged along with
+// its tokens by PurgeDeletedProjects.
 func (d SQLClient) DeleteProjectEntry(ctx context.Context, project string) error {
+	ctx, cancel := d.withTimeout(ctx)
+	defer cancel()
+
+	project = d.projectID(project)
+
 	sess, err := d.createSession()
 	if err != nil {
 		return err
 	}
-	defer sess.Close()
 
-	return sess.WithContext(ctx).Collection(ProjectEntryDB).Find("project", project).Delete()
+	_, err = sess.WithContext(ctx).SQL().
+		Update(ProjectEntryDB).
+		Set("deleted_at", d.now()).
+		Where(liveProject(db.Cond{"project": project})).
+		Exec()
+	return err
 }
 
+// CreateTokenEntry creates the token. ErrInvalidTimestamp is returned, and
+// nothing is written, if its timestamps are malformed or out of order.
 func (d SQLClient) CreateTokenEntry(ctx context.Context, token types.Token) error {
+	ctx, cancel := d.withTimeout(ctx)
+	defer cancel()
+
+	if err := validateTokenTimes(token); err != nil {
+		return err
+	}
+
 	sess, err := d.createSession()
 	if err != nil {
 		return err
 	}
-	defer sess.Close()
 
-	err = sess.WithContext(ctx).Tx(func(sess db.Session) error {
-		res := TokenEntry{
-			CreatedAt: token.CreatedAt,
-			ExpiresAt: token.ExpiresAt,
-			ProjectID: token.ProjectID,
-			TokenID:   token.ProjectToken.ID,
-		}
+	token.ProjectID = d.projectID(token.ProjectID)
 
-		if _, err = sess.Collection(TokenEntryDB).Insert(res); err != nil {
-			return err
+	return sess.WithContext(ctx).Tx(func(sess db.Session) error {
+		return createTokenEntry(sess, token)
+	})
+}
+
+// CreateTokenEntries creates the tokens atomically. If any token is invalid
+// the whole batch is rejected, wrapping ErrInvalidTimestamp, before anything
+// is written.
+func (d SQLClient) CreateTokenEntries(ctx context.Context, tokens []types.Token) error {
+	ctx, cancel := d.withTimeout(ctx)
+	defer cancel()
+
+	for i, token := range tokens {
+		if err := validateTokenTimes(token); err != nil {
+			return fmt.Errorf("token %d: %w", i, err)
 		}
+	}
+
+	if len(tokens) == 0 {
 		return nil
+	}
+
+	sess, err := d.createSession()
+	if err != nil {
+		return err
+	}
+
+	entries := make([]types.Token, 0, len(tokens))
+	for _, token := range tokens {
+		token.ProjectID = d.projectID(token.ProjectID)
+		entries = append(entries, token)
+	}
+
+	return sess.WithContext(ctx).Tx(func(sess db.Session) error {
+		return createTokenEntries(sess, entries)
 	})
+}
+
+// createTokenEntries inserts the tokens and their history in one statement
+// each. Nothing is inserted if tokens is empty.
+func createTokenEntries(sess db.Session, tokens []types.Token) error {
+	if len(tokens) == 0 {
+		return nil
+	}
+
+	entries := sess.SQL().InsertInto(TokenEntryDB)
+	history := sess.SQL().InsertInto(TokenHistoryDB)
+	for _, token := range tokens {
+		entries = entries.Values(newTokenEntry(token))
+		history = history.Values(newTokenHistoryEntry(token))
+	}
+
+	if _, err := entries.Exec(); err != nil {
+		return err
+	}
+
+	_, err := history.Exec()
+	return err
+}
+
+// newTokenEntry returns the entry stored for the token. The secret is not
+// stored.
+func newTokenEntry(token types.Token) TokenEntry {
+	return TokenEntry{
+		CreatedAt: token.CreatedAt,
+		ExpiresAt: token.ExpiresAt,
+		ProjectID: token.ProjectID,
+		TokenID:   token.ProjectToken.ID,
+		RoleID:    token.RoleID,
+	}
+}
+
+// createTokenEntry inserts the token and its history.
+func createTokenEntry(sess db.Session, token types.Token) error {
+	if _, err := sess.Collection(TokenEntryDB).Insert(newTokenEntry(token)); err != nil {
+		return err
+	}
+
+	_, err := sess.Collection(TokenHistoryDB).Insert(newTokenHistoryEntry(token))
 	return err
 }
 
 func (d SQLClient) DeleteTokenEntry(ctx context.Context, token string) error {
+	ctx, cancel := d.withTimeout(ctx)
+	defer cancel()
+
+	sess, err := d.createSession()
+	if err != nil {
+		return err
+	}
+
+	return d.deleteTokenEntry(sess.WithContext(ctx), token)
+}
+
+// deleteTokenEntry deletes the token and records a tombstone for it.
+func (d SQLClient) deleteTokenEntry(sess db.Session, token string) error {
+	return sess.Tx(func(sess db.Session) error {
+		if err := recordTokenDeletions(sess, d.now(), "token_id = ?", token); err != nil {
+			return err
+		}
+
+		return sess.Collection(TokenEntryDB).Find("token_id", token).Delete()
+	})
+}
+
+// NextTokenSequence atomically increments and returns the project's token
+// sequence number. The first call for a project returns 1.
+func (d SQLClient) NextTokenSequence(ctx context.Context, project string) (int64, error) {
+	ctx, cancel := d.withTimeout(ctx)
+	defer cancel()
+
+	project = d.projectID(project)
+
+	sess, err := d.createSession()
+	if err != nil {
+		return 0, err
+	}
+
+	row, err := sess.WithContext(ctx).SQL().QueryRow(
+		"INSERT INTO "+TokenSequenceDB+" (project, value) VALUES (?, 1) ON CONFLICT (project) DO UPDATE SET value = "+TokenSequenceDB+".value + 1 RETURNING value",
+		project,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	var seq int64
+	if err := row.Scan(&seq); err != nil {
+		return 0, err
+	}
+	return seq, nil
+}
+
+// DeleteExpiredTokens deletes the project's tokens that expired before now,
+// returning the number deleted.
+func (d SQLClient) DeleteExpiredTokens(ctx context.Context, project string, now time.Time) (int, error) {
+	ctx, cancel := d.withTimeout(ctx)
+	defer cancel()
+
+	project = d.projectID(project)
+
 	sess, err := d.createSession()
 	if err != nil {
+		return 0, err
+	}
+
+	deleted := 0
+	err = sess.WithContext(ctx).Tx(func(sess db.Session) error {
+		cond := db.Cond{"project": project, "expires_at <": now}
+
+		if err := recordTokenDeletions(sess, d.now(), "project = ? AND expires_at < ?", project, now); err != nil {
+			return err
+		}
+
+		res, err := sess.SQL().DeleteFrom(TokenEntryDB).Where(cond).Exec()
+		if err != nil {
+			return err
+		}
+
+		n, err := res.RowsAffected()
+		deleted = int(n)
 		return err
+	})
+	if err != nil {
+		return 0, err
 	}
-	defer sess.Close()
 
-	return sess.WithContext(ctx).Collection(TokenEntryDB).Find("token_id", token).Delete()
+	return deleted, nil
 }
 
+// ReadTokenEntry returns the token with the id. Token ids are unique, so no
+// project is needed; the owning project is returned in ProjectID.
+// ErrTokenNotFound is returned if the token does not exist.
 func (d SQLClient) ReadTokenEntry(ctx context.Context, token string) (TokenEntry, error) {
-	res := TokenEntry{}
+	ctx, cancel := d.withTimeout(ctx)
+	defer cancel()
+
+	sess, err := d.createSession()
+	if err != nil {
+		return TokenEntry{}, err
+	}
+
+	issued := issuedTokenEntry{}
+	err = sess.WithContext(ctx).Collection(TokenEntryDB).Find("token_id", token).One(&issued)
+	if err != nil {
+		return TokenEntry{}, notFound(err, ErrTokenNotFound)
+	}
+
+	res := issued.TokenEntry
+	res.SecretCorrupt = issued.secretCorrupt()
+	if !d.lazyExpiry {
+		return res, nil
+	}
+
+	deleteFn := func(ctx context.Context, token string) error {
+		return d.deleteTokenEntry(sess.WithContext(ctx), token)
+	}
+	if !d.lazyExpiryDelete {
+		deleteFn = nil
+	}
+
+	return checkTokenExpiry(ctx, res, d.now(), deleteFn)
+}
+
+// CountTokenEntries returns the number of tokens for the project.
+func (d SQLClient) CountTokenEntries(ctx context.Context, project string) (int, error) {
+	ctx, cancel := d.withTimeout(ctx)
+	defer cancel()
+
+	project = d.projectID(project)
+
 	sess, err := d.createSession()
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := sess.WithContext(ctx).Collection(TokenEntryDB).Find("project", project).Count()
+	return int(n), err
+}
+
+func (d SQLClient) ListTokenEntries(ctx context.Context, project string) ([]TokenEntry, error) {
+	ctx, cancel := d.withTimeout(ctx)
+	defer cancel()
+
+	project = d.projectID(project)
+
+	res := []TokenEntry{}
+
+	sess, err := d.readSession(ctx)
 	if err != nil {
 		return res, err
 	}
-	defer sess.Close()
 
-	err = sess.WithContext(ctx).Collection(TokenEntryDB).Find("token_id", token).One(&res)
+	err = sess.WithContext(ctx).Collection(TokenEntryDB).Find("project", project).Select(tokenEntryColumns...).OrderBy("-created_at").All(&res)
 	return res, err
 }
 
-func (d SQLClient) ListTokenEntries(ctx context.Context, project string) ([]TokenEntry, error) {
+// ListExpiredTokenEntriesGlobal returns up to limit tokens, across all
+// projects, that expired before now. Oldest expiries are returned first. A
+// limit of zero or less returns every expired token.
+func (d SQLClient) ListExpiredTokenEntriesGlobal(ctx context.Context, now time.Time, limit int) ([]TokenEntry, error) {
+	ctx, cancel := d.withTimeout(ctx)
+	defer cancel()
+
+	res := []TokenEntry{}
+
+	sess, err := d.createSession()
+	if err != nil {
+		return res, err
+	}
+
+	q := sess.WithContext(ctx).Collection(TokenEntryDB).Find(db.Cond{"expires_at <": now}).Select(tokenEntryColumns...).OrderBy("expires_at")
+	if limit > 0 {
+		q = q.Limit(limit)
+	}
+	err = q.All(&res)
+	return res, err
+}
+
+// ListTokenEntriesPaged returns a page of at most limit tokens for the project,
+// newest first, along with the total count and the cursor for the next page.
+// Pages are keyed on (created_at, token_id) so later pages cost the same as
+// the first. A limit of zero or less uses the default page size.
+func (d SQLClient) ListTokenEntriesPaged(ctx context.Context, project string, limit int, cursor string) (ListTokenEntriesResult, error) {
+	ctx, cancel := d.withTimeout(ctx)
+	defer cancel()
+
+	project = d.projectID(project)
+	limit = ListOptions{PageSize: limit}.pageSize()
+
+	after, ok, err := decodeTokenCursor(cursor)
+	if err != nil {
+		return ListTokenEntriesResult{}, err
+	}
+
+	sess, err := d.createSession()
+	if err != nil {
+		return ListTokenEntriesResult{}, err
+	}
+
+	total, err := sess.WithContext(ctx).Collection(TokenEntryDB).Find("project", project).Count()
+	if err != nil {
+		return ListTokenEntriesResult{}, err
+	}
+
+	q := sess.WithContext(ctx).SQL().
+		Select(tokenEntryColumns...).
+		From(TokenEntryDB).
+		Where("project = ?", project)
+	if ok {
+		q = q.And("(created_at, token_id) < (?, ?)", after.CreatedAt, after.TokenID)
+	}
+
+	entries := []TokenEntry{}
+	err = q.OrderBy("-created_at", "-token_id").Limit(limit + 1).All(&entries)
+	if err != nil {
+		return ListTokenEntriesResult{}, err
+	}
+
+	return tokenPage(entries, limit, int(total)), nil
+}
+
+// ListTokenEntriesWithTTL returns the project's tokens along with how long each
+// has left before it expires.
+func (d SQLClient) ListTokenEntriesWithTTL(ctx context.Context, project string, now time.Time) ([]TokenWithTTL, error) {
+	ctx, cancel := d.withTimeout(ctx)
+	defer cancel()
+
+	entries, err := d.ListTokenEntries(ctx, project)
+	if err != nil {
+		return nil, err
+	}
+
+	return withTTL(entries, now)
+}
+
+// ListTokenEntriesByUrgency returns the project's active tokens, soonest to
+// expire first.
+func (d SQLClient) ListTokenEntriesByUrgency(ctx context.Context, project string, now time.Time) ([]TokenWithTTL, error) {
+	ctx, cancel := d.withTimeout(ctx)
+	defer cancel()
+
+	tokens, err := d.ListTokenEntriesWithTTL(ctx, project, now)
+	if err != nil {
+		return nil, err
+	}
+
+	return byUrgency(tokens), nil
+}
+
+// ListTokenChanges returns the project's tokens created and deleted since the
+// sync token was issued. An empty sync token returns all current tokens.
+// ErrSyncTokenExpired is returned if the sync token is older than the
+// tombstone retention window.
+func (d SQLClient) ListTokenChanges(ctx context.Context, project, syncToken string) (TokenChangeSet, error) {
+	ctx, cancel := d.withTimeout(ctx)
+	defer cancel()
+
+	project = d.projectID(project)
+
+	now := d.now()
+
+	since, err := decodeSyncToken(syncToken, now, d.tombstoneRetention)
+	if err != nil {
+		return TokenChangeSet{}, err
+	}
+
+	sess, err := d.createSession()
+	if err != nil {
+		return TokenChangeSet{}, err
+	}
+
+	res := TokenChangeSet{
+		Created:       []TokenEntry{},
+		Deleted:       []string{},
+		NextSyncToken: encodeSyncToken(now),
+	}
+
+	err = sess.WithContext(ctx).Tx(func(sess db.Session) error {
+		err := sess.Collection(TokenEntryDB).Find(db.Cond{
+			"project":       project,
+			"created_at >":  since,
+			"created_at <=": now,
+		}).Select(tokenEntryColumns...).OrderBy("-created_at").All(&res.Created)
+		if err != nil {
+			return err
+		}
+
+		tombstones := []TokenTombstone{}
+		err = sess.Collection(TokenTombstoneDB).Find(db.Cond{
+			"project":       project,
+			"deleted_at >":  since,
+			"deleted_at <=": now,
+		}).OrderBy("deleted_at").All(&tombstones)
+		if err != nil {
+			return err
+		}
+
+		for _, t := range tombstones {
+			res.Deleted = append(res.Deleted, t.TokenID)
+		}
+		return nil
+	})
+
+	return res, err
+}
+
+// PurgeTokenTombstones removes tombstones for tokens deleted before the
+// provided time, returning the number removed.
+func (d SQLClient) PurgeTokenTombstones(ctx context.Context, before time.Time) (int, error) {
+	ctx, cancel := d.withTimeout(ctx)
+	defer cancel()
+
+	sess, err := d.createSession()
+	if err != nil {
+		return 0, err
+	}
+
+	res, err := sess.WithContext(ctx).SQL().DeleteFrom(TokenTombstoneDB).Where(db.Cond{"deleted_at <": before}).Exec()
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := res.RowsAffected()
+	return int(n), err
+}
+
+// ListTokenEntriesCreatedBetween returns the project's tokens created within
+// the range, newest first. Both start and end are inclusive.
+func (d SQLClient) ListTokenEntriesCreatedBetween(ctx context.Context, project string, start, end time.Time) ([]TokenEntry, error) {
+	ctx, cancel := d.withTimeout(ctx)
+	defer cancel()
+
+	project = d.projectID(project)
+
 	res := []TokenEntry{}
 
 	sess, err := d.createSession()
 	if err != nil {
 		return res, err
 	}
-	defer sess.Close()
 
-	err = sess.WithContext(ctx).Collection(TokenEntryDB).Find("project", project).OrderBy("-created_at").All(&res)
+	err = sess.WithContext(ctx).Collection(TokenEntryDB).Find(db.Cond{
+		"project":    project,
+		"created_at": db.Between(start, end),
+	}).Select(tokenEntryColumns...).OrderBy("-created_at").All(&res)
 	return res, err
 }
@@ -0,0 +1,546 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/cello-proj/cello/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/upper/db/v4"
+)
+
+func TestProjectID(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    []Option
+		project string
+		want    string
+	}{
+		{
+			name:    "unchanged by default",
+			project: "MyProject",
+			want:    "MyProject",
+		},
+		{
+			name:    "lowercased when enabled",
+			opts:    []Option{WithLowercaseProjectIDs()},
+			project: "MyProject",
+			want:    "myproject",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := NewSQLClient("host", "db", "user", "pass", nil, tt.opts...)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, c.projectID(tt.project))
+		})
+	}
+}
+
+func TestProjectIDCaseInsensitiveLookup(t *testing.T) {
+	c, err := NewSQLClient("host", "db", "user", "pass", nil, WithLowercaseProjectIDs())
+	assert.NoError(t, err)
+	assert.Equal(t, c.projectID("myproject"), c.projectID("MyProject"))
+	assert.Equal(t, c.projectID("MYPROJECT"), c.projectID("MyProject"))
+}
+
+// secretFields returns the fields of typ, including nested structs, whose
+// name or tags suggest secret material. Flags such as
+// TokenEntry.SecretCorrupt can't hold secret material and are skipped.
+func secretFields(typ reflect.Type) []string {
+	switch typ.Kind() {
+	case reflect.Ptr, reflect.Slice, reflect.Array:
+		return secretFields(typ.Elem())
+	case reflect.Struct:
+	default:
+		return nil
+	}
+
+	res := []string{}
+	for i := 0; i < typ.NumField(); i++ {
+		f := typ.Field(i)
+		if f.Type.Kind() == reflect.Bool {
+			continue
+		}
+		names := strings.ToLower(f.Name + " " + f.Tag.Get("db") + " " + f.Tag.Get("json"))
+		if strings.Contains(names, "secret") || strings.Contains(names, "hash") {
+			res = append(res, typ.Name()+"."+f.Name)
+		}
+		res = append(res, secretFields(f.Type)...)
+	}
+	return res
+}
+
+func TestReadAndListResultsHaveNoSecret(t *testing.T) {
+	results := []interface{}{
+		TokenEntry{},
+		[]TokenWithTTL{},
+		ListTokenEntriesResult{},
+		TokenChangeSet{},
+		[]TokenHistoryEntry{},
+	}
+
+	for _, r := range results {
+		typ := reflect.TypeOf(r)
+		t.Run(typ.String(), func(t *testing.T) {
+			assert.Empty(t, secretFields(typ))
+		})
+	}
+
+	// The token issued at create time is the only place the secret appears.
+	assert.Equal(t, []string{"Token.Secret"}, secretFields(reflect.TypeOf(types.Token{})))
+}
+
+func TestTokenEntryColumns(t *testing.T) {
+	typ := reflect.TypeOf(TokenEntry{})
+
+	tags := []interface{}{}
+	for i := 0; i < typ.NumField(); i++ {
+		if tag := typ.Field(i).Tag.Get("db"); tag != "-" {
+			tags = append(tags, tag)
+		}
+	}
+
+	assert.ElementsMatch(t, tags, tokenEntryColumns)
+	for _, c := range tokenEntryColumns {
+		assert.NotContains(t, c, "secret")
+		assert.NotContains(t, c, "hash")
+	}
+}
+
+type rowsAffectedResult struct {
+	sql.Result
+
+	n   int64
+	err error
+}
+
+func (r rowsAffectedResult) RowsAffected() (int64, error) {
+	return r.n, r.err
+}
+
+func TestRequireRowsAffected(t *testing.T) {
+	errDriver := errors.New("rows affected not supported")
+
+	tests := []struct {
+		name    string
+		res     rowsAffectedResult
+		wantErr error
+	}{
+		{
+			name: "row matched",
+			res:  rowsAffectedResult{n: 1},
+		},
+		{
+			name:    "no rows matched is not found",
+			res:     rowsAffectedResult{n: 0},
+			wantErr: db.ErrNoMoreRows,
+		},
+		{
+			name:    "driver error",
+			res:     rowsAffectedResult{err: errDriver},
+			wantErr: errDriver,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.wantErr, requireRowsAffected(tt.res))
+		})
+	}
+}
+
+func TestNotFound(t *testing.T) {
+	errConn := errors.New("connection refused")
+
+	tests := []struct {
+		name     string
+		err      error
+		sentinel error
+		wantErr  error
+	}{
+		{
+			name:     "no error",
+			sentinel: ErrProjectNotFound,
+		},
+		{
+			name:     "no rows is project not found",
+			err:      db.ErrNoMoreRows,
+			sentinel: ErrProjectNotFound,
+			wantErr:  ErrProjectNotFound,
+		},
+		{
+			name:     "no rows is token not found",
+			err:      db.ErrNoMoreRows,
+			sentinel: ErrTokenNotFound,
+			wantErr:  ErrTokenNotFound,
+		},
+		{
+			name:     "other errors are unchanged",
+			err:      errConn,
+			sentinel: ErrTokenNotFound,
+			wantErr:  errConn,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := notFound(tt.err, tt.sentinel)
+			assert.Equal(t, tt.wantErr, err)
+			if tt.wantErr == tt.sentinel {
+				assert.True(t, errors.Is(err, tt.sentinel))
+			}
+		})
+	}
+}
+
+func TestNewSQLClientValidation(t *testing.T) {
+	tests := []struct {
+		name     string
+		host     string
+		database string
+		user     string
+		wantErr  string
+	}{
+		{
+			name:     "valid",
+			host:     "localhost",
+			database: "cello",
+			user:     "cello",
+		},
+		{
+			name:     "missing host",
+			database: "cello",
+			user:     "cello",
+			wantErr:  "invalid db configuration: host is required",
+		},
+		{
+			name:    "missing database",
+			host:    "localhost",
+			user:    "cello",
+			wantErr: "invalid db configuration: database is required",
+		},
+		{
+			name:     "missing user",
+			host:     "localhost",
+			database: "cello",
+			wantErr:  "invalid db configuration: user is required",
+		},
+		{
+			name:     "blank host",
+			host:     " ",
+			database: "cello",
+			user:     "cello",
+			wantErr:  "invalid db configuration: host is required",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewSQLClient(tt.host, tt.database, tt.user, "pass", nil)
+			if tt.wantErr != "" {
+				assert.True(t, errors.Is(err, ErrInvalidConfig))
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+
+			assert.Nil(t, err)
+		})
+	}
+}
+
+func TestTokenEntryIsEmpty(t *testing.T) {
+	assert.True(t, TokenEntry{}.IsEmpty())
+	assert.False(t, TokenEntry{TokenID: "token1"}.IsEmpty())
+	assert.False(t, TokenEntry{RoleID: "role1"}.IsEmpty())
+}
+
+func TestProjectEntryValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		pe      ProjectEntry
+		wantErr error
+	}{
+		{
+			name: "valid",
+			pe:   ProjectEntry{ProjectID: "project1", Repository: "git@github.com:myorg/myrepo.git"},
+		},
+		{
+			name:    "empty project id",
+			pe:      ProjectEntry{Repository: "git@github.com:myorg/myrepo.git"},
+			wantErr: errors.New("project_id is required"),
+		},
+		{
+			name:    "project id too long",
+			pe:      ProjectEntry{ProjectID: strings.Repeat("a", 33), Repository: "git@github.com:myorg/myrepo.git"},
+			wantErr: errors.New("project_id must be between 4 and 32 characters"),
+		},
+		{
+			name:    "project id not alphanumeric",
+			pe:      ProjectEntry{ProjectID: "project-1", Repository: "git@github.com:myorg/myrepo.git"},
+			wantErr: errors.New("project_id must be alphanumeric"),
+		},
+		{
+			name:    "empty repository",
+			pe:      ProjectEntry{ProjectID: "project1"},
+			wantErr: errors.New("repository is required"),
+		},
+		{
+			name:    "repository not a git uri",
+			pe:      ProjectEntry{ProjectID: "project1", Repository: "not a url"},
+			wantErr: errors.New("repository must be a git uri"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.pe.Validate()
+			if tt.wantErr == nil {
+				assert.Nil(t, err)
+				return
+			}
+			assert.EqualError(t, err, tt.wantErr.Error())
+		})
+	}
+}
+
+func TestCreateProjectEntryInvalid(t *testing.T) {
+	c, err := NewSQLClient("localhost", "cello", "cello", "", nil)
+	assert.Nil(t, err)
+
+	opened := false
+	c.open = func(db.ConnectionURL) (db.Session, error) {
+		opened = true
+		return &fakeSession{}, nil
+	}
+
+	err = c.CreateProjectEntry(context.Background(), ProjectEntry{ProjectID: "project1", Repository: "not a url"})
+	assert.EqualError(t, err, "repository must be a git uri")
+	assert.False(t, opened, "store must not be touched")
+}
+
+func TestReadProjectActivity(t *testing.T) {
+	query := `SELECT p.project, COALESCE\(p.repository, ''\), p.quota, \(SELECT MAX\(t.created_at\) FROM tokens t WHERE t.project = p.project\) FROM projects p WHERE p.project = \$1 AND p.deleted_at IS NULL`
+	columns := []string{"project", "repository", "quota", "max"}
+	lastCreated := time.Date(2022, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		rows     *sqlmock.Rows
+		want     ProjectEntry
+		wantLast time.Time
+		wantErr  error
+	}{
+		{
+			name:     "with tokens",
+			rows:     sqlmock.NewRows(columns).AddRow("project1", testRepository, `{"max_tokens":3}`, lastCreated),
+			want:     ProjectEntry{ProjectID: "project1", Repository: testRepository, Quota: ProjectQuota{MaxTokens: 3}},
+			wantLast: lastCreated,
+		},
+		{
+			name: "without tokens",
+			rows: sqlmock.NewRows(columns).AddRow("project1", testRepository, nil, nil),
+			want: ProjectEntry{ProjectID: "project1", Repository: testRepository},
+		},
+		{
+			name:    "not found",
+			rows:    sqlmock.NewRows(columns),
+			wantErr: ErrProjectNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, mock := newMockSQLClient(t)
+			mock.ExpectQuery(query).WithArgs("project1").WillReturnRows(tt.rows)
+
+			got, last, err := c.ReadProjectActivity(context.Background(), "project1")
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			assert.Nil(t, err)
+			assert.Equal(t, tt.want, got)
+			assert.True(t, tt.wantLast.Equal(last), "got last token time %v, want %v", last, tt.wantLast)
+		})
+	}
+}
+
+func TestListTokenEntriesCreatedBetween(t *testing.T) {
+	c, mock := newMockSQLClient(t)
+	start := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2022, 1, 31, 0, 0, 0, 0, time.UTC)
+
+	expectPrimaryKey(mock, TokenEntryDB, "token_id")
+	mock.ExpectQuery(`SELECT "created_at", "expires_at", "project", "token_id", "role_id" FROM "tokens" `+
+		`WHERE \("created_at" BETWEEN \$1 AND \$2 AND "project" = \$3\) ORDER BY "created_at" DESC`).
+		WithArgs(start, end, "project1").
+		WillReturnRows(sqlmock.NewRows([]string{"created_at", "expires_at", "project", "token_id", "role_id"}).
+			AddRow("2022-01-31T00:00:00Z", "2022-02-01T00:00:00Z", "project1", "token2", "").
+			AddRow("2022-01-01T00:00:00Z", "2022-01-02T00:00:00Z", "project1", "token1", ""))
+
+	got, err := c.ListTokenEntriesCreatedBetween(context.Background(), "project1", start, end)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"token2", "token1"}, tokenIDs(got))
+}
+
+func TestDeleteExpiredTokens(t *testing.T) {
+	now := time.Date(2022, 1, 1, 12, 0, 0, 0, time.UTC)
+	deletedAt := time.Date(2022, 1, 1, 12, 30, 0, 0, time.UTC)
+
+	c, mock := newMockSQLClient(t)
+	c.now = func() time.Time { return deletedAt }
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`INSERT INTO token_tombstones \(token_id, project, deleted_at\) SELECT token_id, project, \$1 FROM tokens WHERE project = \$2 AND expires_at < \$3 ON CONFLICT \(token_id\) DO NOTHING`).
+		WithArgs(deletedAt, "project1", now).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectExec(`UPDATE token_history SET deleted_at = \$1 WHERE deleted_at IS NULL AND token_id IN \(SELECT token_id FROM tokens WHERE project = \$2 AND expires_at < \$3\)`).
+		WithArgs(deletedAt, "project1", now).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectExec(`DELETE FROM "tokens" WHERE \("expires_at" < \$1 AND "project" = \$2\)`).
+		WithArgs(now, "project1").
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectCommit()
+
+	n, err := c.DeleteExpiredTokens(context.Background(), "project1", now)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, n)
+}
+
+func TestDeleteExpiredTokensRollsBack(t *testing.T) {
+	now := time.Date(2022, 1, 1, 12, 0, 0, 0, time.UTC)
+	c, mock := newMockSQLClient(t)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`INSERT INTO token_tombstones`).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`UPDATE token_history`).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`DELETE FROM "tokens"`).WillReturnError(errors.New("boom"))
+	mock.ExpectRollback()
+
+	n, err := c.DeleteExpiredTokens(context.Background(), "project1", now)
+	assert.EqualError(t, err, "boom")
+	assert.Equal(t, 0, n)
+}
+
+func TestNextTokenSequence(t *testing.T) {
+	c, mock := newMockSQLClient(t)
+
+	mock.ExpectQuery(`INSERT INTO token_sequences \(project, value\) VALUES \(\$1, 1\) ON CONFLICT \(project\) DO UPDATE SET value = token_sequences.value \+ 1 RETURNING value`).
+		WithArgs("project1").
+		WillReturnRows(sqlmock.NewRows([]string{"value"}).AddRow(7))
+
+	seq, err := c.NextTokenSequence(context.Background(), "project1")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(7), seq)
+}
+
+func TestNextTokenSequenceError(t *testing.T) {
+	c, mock := newMockSQLClient(t)
+
+	mock.ExpectQuery(`INSERT INTO token_sequences`).WillReturnError(errors.New("boom"))
+
+	_, err := c.NextTokenSequence(context.Background(), "project1")
+	assert.EqualError(t, err, "boom")
+}
+
+// expectCreateProjectEntry expects the statements createProjectEntry runs
+// for project1, with the insert affecting inserted rows.
+func expectCreateProjectEntry(mock sqlmock.Sqlmock, inserted int64) {
+	mock.ExpectExec(`INSERT INTO token_tombstones .* WHERE project IN \(SELECT project FROM projects WHERE deleted_at IS NOT NULL AND project = \$2\)`).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`UPDATE token_history SET deleted_at = \$1 .* WHERE project IN \(SELECT project FROM projects WHERE deleted_at IS NOT NULL AND project = \$2\)\)`).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`DELETE FROM projects WHERE deleted_at IS NOT NULL AND project = \$1`).
+		WithArgs("project1").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`INSERT INTO "projects" .* ON CONFLICT \(project\) DO NOTHING`).
+		WillReturnResult(sqlmock.NewResult(0, inserted))
+}
+
+func TestCreateProjectWithToken(t *testing.T) {
+	now := time.Date(2022, 1, 1, 12, 0, 0, 0, time.UTC)
+	pe := ProjectEntry{ProjectID: "project1", Repository: testRepository}
+
+	t.Run("commits both", func(t *testing.T) {
+		c, mock := newMockSQLClient(t)
+
+		mock.ExpectBegin()
+		expectCreateProjectEntry(mock, 1)
+		expectPrimaryKey(mock, TokenEntryDB, "token_id")
+		mock.ExpectQuery(`INSERT INTO "tokens"`).
+			WillReturnRows(sqlmock.NewRows([]string{"token_id"}).AddRow("token1"))
+		expectPrimaryKey(mock, TokenHistoryDB, "id")
+		mock.ExpectQuery(`INSERT INTO "token_history"`).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+		mock.ExpectCommit()
+
+		assert.Nil(t, c.CreateProjectWithToken(context.Background(), pe, testToken("project1", "token1", now)))
+	})
+
+	t.Run("rolls back the project when the token fails", func(t *testing.T) {
+		c, mock := newMockSQLClient(t)
+
+		mock.ExpectBegin()
+		expectCreateProjectEntry(mock, 1)
+		expectPrimaryKey(mock, TokenEntryDB, "token_id")
+		mock.ExpectQuery(`INSERT INTO "tokens"`).WillReturnError(errors.New("boom"))
+		mock.ExpectRollback()
+
+		assert.EqualError(t, c.CreateProjectWithToken(context.Background(), pe, testToken("project1", "token1", now)), "boom")
+	})
+
+	t.Run("project exists", func(t *testing.T) {
+		c, mock := newMockSQLClient(t)
+
+		mock.ExpectBegin()
+		expectCreateProjectEntry(mock, 0)
+		mock.ExpectRollback()
+
+		err := c.CreateProjectWithToken(context.Background(), pe, testToken("project1", "token1", now))
+		assert.ErrorIs(t, err, ErrProjectExists)
+	})
+
+	t.Run("mismatched project", func(t *testing.T) {
+		c, err := NewSQLClient("localhost", "cello", "cello", "", nil)
+		assert.Nil(t, err)
+
+		opened := false
+		c.open = func(db.ConnectionURL) (db.Session, error) {
+			opened = true
+			return &fakeSession{}, nil
+		}
+
+		err = c.CreateProjectWithToken(context.Background(), pe, testToken("project2", "token1", now))
+		assert.EqualError(t, err, "token project 'project2' does not match project 'project1'")
+		assert.False(t, opened, "store must not be touched")
+	})
+}
+
+func TestUpdateProjectEntryInvalid(t *testing.T) {
+	c, err := NewSQLClient("localhost", "cello", "cello", "", nil)
+	assert.Nil(t, err)
+
+	opened := false
+	c.open = func(db.ConnectionURL) (db.Session, error) {
+		opened = true
+		return &fakeSession{}, nil
+	}
+
+	err = c.UpdateProjectEntry(context.Background(), ProjectEntry{ProjectID: "project1", Repository: "not a url"})
+	assert.EqualError(t, err, "repository must be a git uri")
+	assert.False(t, opened, "store must not be touched")
+
+	m := NewInMemoryClient()
+	assert.Nil(t, m.CreateProjectEntry(context.Background(), ProjectEntry{ProjectID: "project1", Repository: testRepository}))
+	err = m.UpdateProjectEntry(context.Background(), ProjectEntry{ProjectID: "project1", Repository: "not a url"})
+	assert.EqualError(t, err, "repository must be a git uri")
+}
@@ -0,0 +1,21 @@
+package db
+
+import (
+	"context"
+	"fmt"
+)
+
+// HealthCheckName is the name the database is reported under in dependency
+// reports.
+const HealthCheckName = "db"
+
+// HealthCheck returns a dependency check reporting whether the database
+// behind the client is reachable.
+func HealthCheck(c Client) func(context.Context) error {
+	return func(ctx context.Context) error {
+		if err := c.Health(ctx); err != nil {
+			return fmt.Errorf("unable to reach database: %w", err)
+		}
+		return nil
+	}
+}
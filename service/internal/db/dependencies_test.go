@@ -0,0 +1,23 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// unhealthyClient is a fake Client whose health check always fails.
+type unhealthyClient struct {
+	Client
+}
+
+func (unhealthyClient) Health(ctx context.Context) error {
+	return errors.New("connection refused")
+}
+
+func TestHealthCheck(t *testing.T) {
+	assert.NoError(t, HealthCheck(NewInMemoryClient())(context.Background()))
+	assert.EqualError(t, HealthCheck(unhealthyClient{})(context.Background()), "unable to reach database: connection refused")
+}
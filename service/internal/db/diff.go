@@ -0,0 +1,41 @@
+package db
+
+import "reflect"
+
+// FieldChange describes a field that differs between two project entries.
+type FieldChange struct {
+	Field string      `json:"field"`
+	Old   interface{} `json:"old"`
+	New   interface{} `json:"new"`
+}
+
+// Diff returns the fields that changed from pe to other, in a stable order.
+// Fields are named as they are in the API.
+func (pe ProjectEntry) Diff(other ProjectEntry) []FieldChange {
+	fields := []FieldChange{
+		{"project_id", pe.ProjectID, other.ProjectID},
+		{"repository", pe.Repository, other.Repository},
+		{"quota.max_tokens", pe.Quota.MaxTokens, other.Quota.MaxTokens},
+		{"quota.max_token_ttl_seconds", pe.Quota.MaxTokenTTLSeconds, other.Quota.MaxTokenTTLSeconds},
+		{"quota.allowed_target_types", pe.Quota.AllowedTargetTypes, other.Quota.AllowedTargetTypes},
+	}
+
+	var changes []FieldChange
+	for _, f := range fields {
+		if !equalField(f.Old, f.New) {
+			changes = append(changes, f)
+		}
+	}
+	return changes
+}
+
+// equalField reports whether a and b are equal, treating nil and empty
+// slices as equal.
+func equalField(a, b interface{}) bool {
+	as, aok := a.([]string)
+	bs, bok := b.([]string)
+	if aok && bok && len(as) == 0 && len(bs) == 0 {
+		return true
+	}
+	return reflect.DeepEqual(a, b)
+}
@@ -0,0 +1,61 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProjectEntryDiff(t *testing.T) {
+	base := ProjectEntry{
+		ProjectID:  "project1",
+		Repository: "git@github.com:myorg/myrepo.git",
+		Quota:      ProjectQuota{MaxTokens: 10},
+	}
+
+	tests := []struct {
+		name  string
+		other func(pe ProjectEntry) ProjectEntry
+		want  []FieldChange
+	}{
+		{
+			name:  "no changes",
+			other: func(pe ProjectEntry) ProjectEntry { return pe },
+		},
+		{
+			name: "single field",
+			other: func(pe ProjectEntry) ProjectEntry {
+				pe.Repository = "git@github.com:myorg/other.git"
+				return pe
+			},
+			want: []FieldChange{
+				{Field: "repository", Old: "git@github.com:myorg/myrepo.git", New: "git@github.com:myorg/other.git"},
+			},
+		},
+		{
+			name: "multiple fields",
+			other: func(pe ProjectEntry) ProjectEntry {
+				pe.Quota = ProjectQuota{MaxTokens: 20, MaxTokenTTLSeconds: 3600, AllowedTargetTypes: []string{"aws_account"}}
+				return pe
+			},
+			want: []FieldChange{
+				{Field: "quota.max_tokens", Old: 10, New: 20},
+				{Field: "quota.max_token_ttl_seconds", Old: int64(0), New: int64(3600)},
+				{Field: "quota.allowed_target_types", Old: []string(nil), New: []string{"aws_account"}},
+			},
+		},
+		{
+			name: "nil and empty target types are equal",
+			other: func(pe ProjectEntry) ProjectEntry {
+				pe.Quota.AllowedTargetTypes = []string{}
+				return pe
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, base.Diff(tt.other(base)))
+		})
+	}
+}
@@ -0,0 +1,497 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/cello-proj/cello/internal/types"
+)
+
+// ErrShuttingDown conveys that the client is draining and no longer accepts
+// new calls.
+var ErrShuttingDown = errors.New("db client is shutting down")
+
+var _ Client = (*DrainingClient)(nil)
+
+// DrainingClient wraps a Client so that in-flight calls can complete during
+// shutdown while new calls are rejected.
+type DrainingClient struct {
+	client Client
+
+	mu       sync.RWMutex
+	draining bool
+	inflight sync.WaitGroup
+}
+
+// NewDrainingClient returns a DrainingClient wrapping c.
+func NewDrainingClient(c Client) *DrainingClient {
+	return &DrainingClient{client: c}
+}
+
+// Drain rejects new calls with ErrShuttingDown and blocks until in-flight
+// calls finish or the context is done.
+func (d *DrainingClient) Drain(ctx context.Context) error {
+	d.mu.Lock()
+	d.draining = true
+	d.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		d.inflight.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// begin registers a call, returning ErrShuttingDown if draining.
+func (d *DrainingClient) begin() error {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if d.draining {
+		return ErrShuttingDown
+	}
+
+	d.inflight.Add(1)
+	return nil
+}
+
+// end marks a call registered with begin as finished.
+func (d *DrainingClient) end() {
+	d.inflight.Done()
+}
+
+// StreamProjectEntries implements Client. The call remains in flight until
+// the stream finishes.
+func (d *DrainingClient) StreamProjectEntries(ctx context.Context) (<-chan ProjectEntry, <-chan error) {
+	if err := d.begin(); err != nil {
+		out := make(chan ProjectEntry)
+		errc := make(chan error, 1)
+		errc <- err
+		close(out)
+		close(errc)
+		return out, errc
+	}
+
+	out, errc := d.client.StreamProjectEntries(ctx)
+
+	res := make(chan error, 1)
+	go func() {
+		defer d.end()
+		defer close(res)
+
+		for err := range errc {
+			res <- err
+		}
+	}()
+
+	return out, res
+}
+
+// CreateProjectEntry implements Client.
+func (d *DrainingClient) CreateProjectEntry(ctx context.Context, pe ProjectEntry) error {
+	if err := d.begin(); err != nil {
+		return err
+	}
+	defer d.end()
+
+	return d.client.CreateProjectEntry(ctx, pe)
+}
+
+// CreateProjectWithToken implements Client.
+func (d *DrainingClient) CreateProjectWithToken(ctx context.Context, pe ProjectEntry, token types.Token) error {
+	if err := d.begin(); err != nil {
+		return err
+	}
+	defer d.end()
+
+	return d.client.CreateProjectWithToken(ctx, pe, token)
+}
+
+// ImportProject implements Client.
+func (d *DrainingClient) ImportProject(ctx context.Context, data []byte) error {
+	if err := d.begin(); err != nil {
+		return err
+	}
+	defer d.end()
+
+	return d.client.ImportProject(ctx, data)
+}
+
+// DeleteProjectEntry implements Client.
+func (d *DrainingClient) DeleteProjectEntry(ctx context.Context, project string) error {
+	if err := d.begin(); err != nil {
+		return err
+	}
+	defer d.end()
+
+	return d.client.DeleteProjectEntry(ctx, project)
+}
+
+// RestoreProjectEntry implements Client.
+func (d *DrainingClient) RestoreProjectEntry(ctx context.Context, project string) error {
+	if err := d.begin(); err != nil {
+		return err
+	}
+	defer d.end()
+
+	return d.client.RestoreProjectEntry(ctx, project)
+}
+
+// PurgeDeletedProjects implements Client.
+func (d *DrainingClient) PurgeDeletedProjects(ctx context.Context, now time.Time) (int, error) {
+	if err := d.begin(); err != nil {
+		return 0, err
+	}
+	defer d.end()
+
+	return d.client.PurgeDeletedProjects(ctx, now)
+}
+
+// ReadProjectEntry implements Client.
+func (d *DrainingClient) ReadProjectEntry(ctx context.Context, project string) (ProjectEntry, error) {
+	if err := d.begin(); err != nil {
+		return ProjectEntry{}, err
+	}
+	defer d.end()
+
+	return d.client.ReadProjectEntry(ctx, project)
+}
+
+// ReadProjectEntryWithETag implements Client.
+func (d *DrainingClient) ReadProjectEntryWithETag(ctx context.Context, project string) (ProjectEntry, string, error) {
+	if err := d.begin(); err != nil {
+		return ProjectEntry{}, "", err
+	}
+	defer d.end()
+
+	return d.client.ReadProjectEntryWithETag(ctx, project)
+}
+
+// UpdateProjectEntry implements Client.
+func (d *DrainingClient) UpdateProjectEntry(ctx context.Context, pe ProjectEntry) error {
+	if err := d.begin(); err != nil {
+		return err
+	}
+	defer d.end()
+
+	return d.client.UpdateProjectEntry(ctx, pe)
+}
+
+// UpdateProjectEntryIfMatch implements Client.
+func (d *DrainingClient) UpdateProjectEntryIfMatch(ctx context.Context, pe ProjectEntry, etag string) error {
+	if err := d.begin(); err != nil {
+		return err
+	}
+	defer d.end()
+
+	return d.client.UpdateProjectEntryIfMatch(ctx, pe, etag)
+}
+
+// FindDuplicateRepositories implements Client.
+func (d *DrainingClient) FindDuplicateRepositories(ctx context.Context) (map[string][]string, error) {
+	if err := d.begin(); err != nil {
+		return nil, err
+	}
+	defer d.end()
+
+	return d.client.FindDuplicateRepositories(ctx)
+}
+
+// ListProjectEntries implements Client.
+func (d *DrainingClient) ListProjectEntries(ctx context.Context, opts ListOptions) ([]ProjectEntry, string, error) {
+	if err := d.begin(); err != nil {
+		return nil, "", err
+	}
+	defer d.end()
+
+	return d.client.ListProjectEntries(ctx, opts)
+}
+
+// ListProjectsByRepository implements Client.
+func (d *DrainingClient) ListProjectsByRepository(ctx context.Context, repository string) ([]ProjectEntry, error) {
+	if err := d.begin(); err != nil {
+		return nil, err
+	}
+	defer d.end()
+
+	return d.client.ListProjectsByRepository(ctx, repository)
+}
+
+// ReadProjectActivity implements Client.
+func (d *DrainingClient) ReadProjectActivity(ctx context.Context, project string) (ProjectEntry, time.Time, error) {
+	if err := d.begin(); err != nil {
+		return ProjectEntry{}, time.Time{}, err
+	}
+	defer d.end()
+
+	return d.client.ReadProjectActivity(ctx, project)
+}
+
+// ReadProjectsWithTokenCounts implements Client.
+func (d *DrainingClient) ReadProjectsWithTokenCounts(ctx context.Context, ids []string) ([]ProjectSummary, error) {
+	if err := d.begin(); err != nil {
+		return nil, err
+	}
+	defer d.end()
+
+	return d.client.ReadProjectsWithTokenCounts(ctx, ids)
+}
+
+// CreateTokenEntry implements Client.
+func (d *DrainingClient) CreateTokenEntry(ctx context.Context, token types.Token) error {
+	if err := d.begin(); err != nil {
+		return err
+	}
+	defer d.end()
+
+	return d.client.CreateTokenEntry(ctx, token)
+}
+
+// CreateTokenEntries implements Client.
+func (d *DrainingClient) CreateTokenEntries(ctx context.Context, tokens []types.Token) error {
+	if err := d.begin(); err != nil {
+		return err
+	}
+	defer d.end()
+
+	return d.client.CreateTokenEntries(ctx, tokens)
+}
+
+// IssueToken implements Client.
+func (d *DrainingClient) IssueToken(ctx context.Context, project, roleID string, ttl time.Duration) (types.Token, error) {
+	if err := d.begin(); err != nil {
+		return types.Token{}, err
+	}
+	defer d.end()
+
+	return d.client.IssueToken(ctx, project, roleID, ttl)
+}
+
+// VerifyTokenSecret implements Client.
+func (d *DrainingClient) VerifyTokenSecret(ctx context.Context, token, secret string) (TokenEntry, error) {
+	if err := d.begin(); err != nil {
+		return TokenEntry{}, err
+	}
+	defer d.end()
+
+	return d.client.VerifyTokenSecret(ctx, token, secret)
+}
+
+// VerifyAndTouch implements Client.
+func (d *DrainingClient) VerifyAndTouch(ctx context.Context, project, token, secret string) (TokenEntry, error) {
+	if err := d.begin(); err != nil {
+		return TokenEntry{}, err
+	}
+	defer d.end()
+
+	return d.client.VerifyAndTouch(ctx, project, token, secret)
+}
+
+// AddPendingSecret implements Client.
+func (d *DrainingClient) AddPendingSecret(ctx context.Context, token string, ttl time.Duration) (string, error) {
+	if err := d.begin(); err != nil {
+		return "", err
+	}
+	defer d.end()
+
+	return d.client.AddPendingSecret(ctx, token, ttl)
+}
+
+// PromotePendingSecret implements Client.
+func (d *DrainingClient) PromotePendingSecret(ctx context.Context, token string) error {
+	if err := d.begin(); err != nil {
+		return err
+	}
+	defer d.end()
+
+	return d.client.PromotePendingSecret(ctx, token)
+}
+
+// DeleteTokenEntry implements Client.
+func (d *DrainingClient) DeleteTokenEntry(ctx context.Context, token string) error {
+	if err := d.begin(); err != nil {
+		return err
+	}
+	defer d.end()
+
+	return d.client.DeleteTokenEntry(ctx, token)
+}
+
+// DeleteExpiredTokens implements Client.
+func (d *DrainingClient) DeleteExpiredTokens(ctx context.Context, project string, now time.Time) (int, error) {
+	if err := d.begin(); err != nil {
+		return 0, err
+	}
+	defer d.end()
+
+	return d.client.DeleteExpiredTokens(ctx, project, now)
+}
+
+// NextTokenSequence implements Client.
+func (d *DrainingClient) NextTokenSequence(ctx context.Context, project string) (int64, error) {
+	if err := d.begin(); err != nil {
+		return 0, err
+	}
+	defer d.end()
+
+	return d.client.NextTokenSequence(ctx, project)
+}
+
+// ReadTokenEntry implements Client.
+func (d *DrainingClient) ReadTokenEntry(ctx context.Context, token string) (TokenEntry, error) {
+	if err := d.begin(); err != nil {
+		return TokenEntry{}, err
+	}
+	defer d.end()
+
+	return d.client.ReadTokenEntry(ctx, token)
+}
+
+// ReadTokenEntryScoped implements Client.
+func (d *DrainingClient) ReadTokenEntryScoped(ctx context.Context, project, token string) (TokenEntry, error) {
+	if err := d.begin(); err != nil {
+		return TokenEntry{}, err
+	}
+	defer d.end()
+
+	return d.client.ReadTokenEntryScoped(ctx, project, token)
+}
+
+// CountTokenEntries implements Client.
+func (d *DrainingClient) CountTokenEntries(ctx context.Context, project string) (int, error) {
+	if err := d.begin(); err != nil {
+		return 0, err
+	}
+	defer d.end()
+
+	return d.client.CountTokenEntries(ctx, project)
+}
+
+// PreviewAffectedTokenCount implements Client.
+func (d *DrainingClient) PreviewAffectedTokenCount(ctx context.Context, project string, predicate Predicate) (int, error) {
+	if err := d.begin(); err != nil {
+		return 0, err
+	}
+	defer d.end()
+
+	return d.client.PreviewAffectedTokenCount(ctx, project, predicate)
+}
+
+// ListTokenEntries implements Client.
+func (d *DrainingClient) ListTokenEntries(ctx context.Context, project string) ([]TokenEntry, error) {
+	if err := d.begin(); err != nil {
+		return nil, err
+	}
+	defer d.end()
+
+	return d.client.ListTokenEntries(ctx, project)
+}
+
+// ListTokenEntriesCreatedBetween implements Client.
+func (d *DrainingClient) ListTokenEntriesCreatedBetween(ctx context.Context, project string, start, end time.Time) ([]TokenEntry, error) {
+	if err := d.begin(); err != nil {
+		return nil, err
+	}
+	defer d.end()
+
+	return d.client.ListTokenEntriesCreatedBetween(ctx, project, start, end)
+}
+
+// ListTokenEntriesWithTTL implements Client.
+func (d *DrainingClient) ListTokenEntriesWithTTL(ctx context.Context, project string, now time.Time) ([]TokenWithTTL, error) {
+	if err := d.begin(); err != nil {
+		return nil, err
+	}
+	defer d.end()
+
+	return d.client.ListTokenEntriesWithTTL(ctx, project, now)
+}
+
+// ListTokenEntriesByUrgency implements Client.
+func (d *DrainingClient) ListTokenEntriesByUrgency(ctx context.Context, project string, now time.Time) ([]TokenWithTTL, error) {
+	if err := d.begin(); err != nil {
+		return nil, err
+	}
+	defer d.end()
+
+	return d.client.ListTokenEntriesByUrgency(ctx, project, now)
+}
+
+// ListTokenEntriesPaged implements Client.
+func (d *DrainingClient) ListTokenEntriesPaged(ctx context.Context, project string, limit int, cursor string) (ListTokenEntriesResult, error) {
+	if err := d.begin(); err != nil {
+		return ListTokenEntriesResult{}, err
+	}
+	defer d.end()
+
+	return d.client.ListTokenEntriesPaged(ctx, project, limit, cursor)
+}
+
+// ListExpiredTokenEntriesGlobal implements Client.
+func (d *DrainingClient) ListExpiredTokenEntriesGlobal(ctx context.Context, now time.Time, limit int) ([]TokenEntry, error) {
+	if err := d.begin(); err != nil {
+		return nil, err
+	}
+	defer d.end()
+
+	return d.client.ListExpiredTokenEntriesGlobal(ctx, now, limit)
+}
+
+// ListTokenChanges implements Client.
+func (d *DrainingClient) ListTokenChanges(ctx context.Context, project, syncToken string) (TokenChangeSet, error) {
+	if err := d.begin(); err != nil {
+		return TokenChangeSet{}, err
+	}
+	defer d.end()
+
+	return d.client.ListTokenChanges(ctx, project, syncToken)
+}
+
+// ListTokenHistory implements Client.
+func (d *DrainingClient) ListTokenHistory(ctx context.Context, project string) ([]TokenHistoryEntry, error) {
+	if err := d.begin(); err != nil {
+		return nil, err
+	}
+	defer d.end()
+
+	return d.client.ListTokenHistory(ctx, project)
+}
+
+// PurgeTokenTombstones implements Client.
+func (d *DrainingClient) PurgeTokenTombstones(ctx context.Context, before time.Time) (int, error) {
+	if err := d.begin(); err != nil {
+		return 0, err
+	}
+	defer d.end()
+
+	return d.client.PurgeTokenTombstones(ctx, before)
+}
+
+// SystemStats implements Client.
+func (d *DrainingClient) SystemStats(ctx context.Context, now time.Time) (SystemStats, error) {
+	if err := d.begin(); err != nil {
+		return SystemStats{}, err
+	}
+	defer d.end()
+
+	return d.client.SystemStats(ctx, now)
+}
+
+// Health implements Client.
+func (d *DrainingClient) Health(ctx context.Context) error {
+	if err := d.begin(); err != nil {
+		return err
+	}
+	defer d.end()
+
+	return d.client.Health(ctx)
+}
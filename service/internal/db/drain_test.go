@@ -0,0 +1,102 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// blockingClient is a fake Client whose reads block until released.
+type blockingClient struct {
+	Client
+
+	started chan struct{}
+	release chan struct{}
+}
+
+func (c *blockingClient) ReadProjectEntry(ctx context.Context, project string) (ProjectEntry, error) {
+	c.started <- struct{}{}
+	<-c.release
+	return ProjectEntry{ProjectID: project}, nil
+}
+
+func (c *blockingClient) StreamProjectEntries(ctx context.Context) (<-chan ProjectEntry, <-chan error) {
+	out := make(chan ProjectEntry)
+	errc := make(chan error, 1)
+	go func() {
+		defer close(errc)
+		defer close(out)
+		<-c.release
+	}()
+	return out, errc
+}
+
+func TestDrainingClient(t *testing.T) {
+	fake := &blockingClient{started: make(chan struct{}), release: make(chan struct{})}
+	c := NewDrainingClient(fake)
+
+	pending := make(chan error)
+	go func() {
+		_, err := c.ReadProjectEntry(context.Background(), "project1")
+		pending <- err
+	}()
+	<-fake.started
+
+	drained := make(chan error)
+	go func() {
+		drained <- c.Drain(context.Background())
+	}()
+
+	// Wait for the drain to begin rejecting calls.
+	assert.Eventually(t, func() bool {
+		_, err := c.ReadTokenEntry(context.Background(), "token1")
+		return errors.Is(err, ErrShuttingDown)
+	}, time.Second, time.Millisecond)
+
+	select {
+	case <-drained:
+		t.Fatal("drain returned while a call was in flight")
+	default:
+	}
+
+	close(fake.release)
+	assert.Nil(t, <-pending)
+	assert.Nil(t, <-drained)
+}
+
+func TestDrainingClientTimeout(t *testing.T) {
+	fake := &blockingClient{started: make(chan struct{}), release: make(chan struct{})}
+	defer close(fake.release)
+	c := NewDrainingClient(fake)
+
+	go c.ReadProjectEntry(context.Background(), "project1")
+	<-fake.started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	assert.Equal(t, context.DeadlineExceeded, c.Drain(ctx))
+}
+
+func TestDrainingClientStream(t *testing.T) {
+	fake := &blockingClient{release: make(chan struct{})}
+	c := NewDrainingClient(fake)
+
+	out, errc := c.StreamProjectEntries(context.Background())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.Equal(t, context.DeadlineExceeded, c.Drain(ctx))
+
+	close(fake.release)
+	for range out {
+	}
+	assert.Nil(t, <-errc)
+	assert.Nil(t, c.Drain(context.Background()))
+
+	_, errc = c.StreamProjectEntries(context.Background())
+	assert.Equal(t, ErrShuttingDown, <-errc)
+}
@@ -0,0 +1,34 @@
+package db
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+)
+
+// ErrVersionConflict conveys that the stored entry changed since it was read.
+var ErrVersionConflict = errors.New("version conflict")
+
+// ETag returns a stable hash of the project entry for optimistic concurrency.
+func (pe ProjectEntry) ETag() string {
+	// Marshalling a struct is deterministic, so equal entries always produce
+	// equal tags.
+	b, _ := json.Marshal(struct {
+		ProjectID  string       `json:"project_id"`
+		Repository string       `json:"repository"`
+		Quota      ProjectQuota `json:"quota"`
+	}{pe.ProjectID, pe.Repository, pe.Quota})
+
+	sum := sha256.Sum256(b)
+	return `"` + hex.EncodeToString(sum[:]) + `"`
+}
+
+// checkETag returns ErrVersionConflict if the stored entry no longer matches
+// the provided tag.
+func checkETag(stored ProjectEntry, etag string) error {
+	if stored.ETag() != etag {
+		return ErrVersionConflict
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/upper/db/v4"
+)
+
+func TestProjectEntryETag(t *testing.T) {
+	pe := ProjectEntry{ProjectID: "project1", Repository: "repo"}
+
+	assert.Equal(t, pe.ETag(), ProjectEntry{ProjectID: "project1", Repository: "repo"}.ETag())
+	assert.NotEqual(t, pe.ETag(), ProjectEntry{ProjectID: "project1", Repository: "repo2"}.ETag())
+	assert.NotEqual(t, pe.ETag(), ProjectEntry{ProjectID: "project1", Repository: "repo", Quota: ProjectQuota{MaxTokens: 1}}.ETag())
+}
+
+func TestCheckETag(t *testing.T) {
+	stored := ProjectEntry{ProjectID: "project1", Repository: "repo"}
+
+	tests := []struct {
+		name    string
+		etag    string
+		wantErr error
+	}{
+		{
+			name: "matching etag",
+			etag: stored.ETag(),
+		},
+		{
+			name:    "stale etag",
+			etag:    ProjectEntry{ProjectID: "project1", Repository: "old-repo"}.ETag(),
+			wantErr: ErrVersionConflict,
+		},
+		{
+			name:    "empty etag",
+			wantErr: ErrVersionConflict,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.wantErr, checkETag(stored, tt.etag))
+		})
+	}
+}
+
+func TestUpdateProjectEntryIfMatchInvalid(t *testing.T) {
+	c, err := NewSQLClient("localhost", "cello", "cello", "", nil)
+	assert.Nil(t, err)
+
+	opened := false
+	c.open = func(db.ConnectionURL) (db.Session, error) {
+		opened = true
+		return &fakeSession{}, nil
+	}
+
+	invalid := ProjectEntry{ProjectID: "project1", Repository: "not a url"}
+
+	err = c.UpdateProjectEntryIfMatch(context.Background(), invalid, "etag")
+	assert.EqualError(t, err, "repository must be a git uri")
+	assert.False(t, opened, "store must not be touched")
+
+	m := NewInMemoryClient()
+	stored := ProjectEntry{ProjectID: "project1", Repository: testRepository}
+	assert.Nil(t, m.CreateProjectEntry(context.Background(), stored))
+	err = m.UpdateProjectEntryIfMatch(context.Background(), invalid, stored.ETag())
+	assert.EqualError(t, err, "repository must be a git uri")
+}
@@ -0,0 +1,124 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/cello-proj/cello/internal/types"
+)
+
+var (
+	// ErrTokenExpired conveys that the token exists but is past its expiry.
+	ErrTokenExpired = errors.New("token expired")
+	// ErrInvalidTimestamp conveys that a stored token timestamp could not be
+	// parsed.
+	ErrInvalidTimestamp = errors.New("invalid timestamp")
+)
+
+// TokenWithTTL is a token entry along with its remaining time to live.
+type TokenWithTTL struct {
+	TokenEntry
+	// RemainingTTL is negative if the token has expired.
+	RemainingTTL time.Duration
+}
+
+// parseTokenTime parses a stored token timestamp.
+func parseTokenTime(s string) (time.Time, error) {
+	t, err := time.Parse(types.TokenTimeLayout, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: '%s'", ErrInvalidTimestamp, s)
+	}
+	return t, nil
+}
+
+// validateTokenTimes returns ErrInvalidTimestamp if the token's CreatedAt or
+// ExpiresAt cannot be parsed or it does not expire after it was created.
+func validateTokenTimes(t types.Token) error {
+	createdAt, err := parseTokenTime(t.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("created_at: %w", err)
+	}
+
+	expiresAt, err := parseTokenTime(t.ExpiresAt)
+	if err != nil {
+		return fmt.Errorf("expires_at: %w", err)
+	}
+
+	if !expiresAt.After(createdAt) {
+		return fmt.Errorf("%w: expires_at '%s' must be after created_at '%s'", ErrInvalidTimestamp, t.ExpiresAt, t.CreatedAt)
+	}
+
+	return nil
+}
+
+// remainingTTL returns the time left before the token expires.
+func remainingTTL(t TokenEntry, now time.Time) (time.Duration, error) {
+	expiresAt, err := parseTokenTime(t.ExpiresAt)
+	if err != nil {
+		return 0, err
+	}
+
+	return expiresAt.Sub(now), nil
+}
+
+// isTokenExpired returns whether the token expired at or before now.
+func isTokenExpired(t TokenEntry, now time.Time) (bool, error) {
+	ttl, err := remainingTTL(t, now)
+	if err != nil {
+		return false, err
+	}
+
+	return ttl <= 0, nil
+}
+
+// withTTL computes the remaining TTL for each entry.
+func withTTL(entries []TokenEntry, now time.Time) ([]TokenWithTTL, error) {
+	res := []TokenWithTTL{}
+	for _, e := range entries {
+		ttl, err := remainingTTL(e, now)
+		if err != nil {
+			return nil, fmt.Errorf("token '%s' has invalid expires_at: %w", e.TokenID, err)
+		}
+
+		res = append(res, TokenWithTTL{TokenEntry: e, RemainingTTL: ttl})
+	}
+
+	return res, nil
+}
+
+// byUrgency returns the active tokens sorted by remaining TTL, soonest to
+// expire first. Expired tokens are excluded.
+func byUrgency(tokens []TokenWithTTL) []TokenWithTTL {
+	res := []TokenWithTTL{}
+	for _, t := range tokens {
+		if t.RemainingTTL > 0 {
+			res = append(res, t)
+		}
+	}
+
+	sort.SliceStable(res, func(i, j int) bool { return res[i].RemainingTTL < res[j].RemainingTTL })
+	return res
+}
+
+// checkTokenExpiry returns ErrTokenExpired if the token has expired. If
+// deleteFn is provided, the expired token is deleted and any delete error is
+// ignored since the token is already unusable.
+func checkTokenExpiry(ctx context.Context, t TokenEntry, now time.Time, deleteFn func(context.Context, string) error) (TokenEntry, error) {
+	expired, err := isTokenExpired(t, now)
+	if err != nil {
+		return t, err
+	}
+
+	if !expired {
+		return t, nil
+	}
+
+	if deleteFn != nil {
+		_ = deleteFn(ctx, t.TokenID)
+	}
+
+	return TokenEntry{}, ErrTokenExpired
+}
@@ -0,0 +1,420 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/cello-proj/cello/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/upper/db/v4"
+)
+
+func TestCheckTokenExpiry(t *testing.T) {
+	now := time.Date(2022, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name        string
+		expiresAt   string
+		withDelete  bool
+		deleteErr   error
+		wantErr     error
+		wantDeleted bool
+	}{
+		{
+			name:      "not expired",
+			expiresAt: "2022-01-02T12:00:00Z",
+		},
+		{
+			name:      "expired",
+			expiresAt: "2022-01-01T11:59:59Z",
+			wantErr:   ErrTokenExpired,
+		},
+		{
+			name:        "expired deletes when enabled",
+			expiresAt:   "2022-01-01T11:59:59Z",
+			withDelete:  true,
+			wantErr:     ErrTokenExpired,
+			wantDeleted: true,
+		},
+		{
+			name:        "expired ignores delete error",
+			expiresAt:   "2022-01-01T11:59:59Z",
+			withDelete:  true,
+			deleteErr:   errors.New("boom"),
+			wantErr:     ErrTokenExpired,
+			wantDeleted: true,
+		},
+		{
+			name:       "not expired does not delete",
+			expiresAt:  "2022-01-02T12:00:00Z",
+			withDelete: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry := TokenEntry{ExpiresAt: tt.expiresAt, TokenID: "abc"}
+
+			deleted := ""
+			var deleteFn func(context.Context, string) error
+			if tt.withDelete {
+				deleteFn = func(ctx context.Context, token string) error {
+					deleted = token
+					return tt.deleteErr
+				}
+			}
+
+			got, err := checkTokenExpiry(context.Background(), entry, now, deleteFn)
+			assert.Equal(t, tt.wantErr, err)
+			if tt.wantErr == nil {
+				assert.Equal(t, entry, got)
+			}
+
+			if tt.wantDeleted {
+				assert.Equal(t, "abc", deleted)
+			} else {
+				assert.Empty(t, deleted)
+			}
+		})
+	}
+}
+
+func TestCheckTokenExpiryErrors(t *testing.T) {
+	now := time.Date(2022, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	_, err := checkTokenExpiry(context.Background(), TokenEntry{ExpiresAt: "bad"}, now, nil)
+	assert.ErrorIs(t, err, ErrInvalidTimestamp)
+	assert.NotErrorIs(t, err, ErrTokenExpired)
+
+	_, err = checkTokenExpiry(context.Background(), TokenEntry{ExpiresAt: "2021-01-01T00:00:00Z"}, now, nil)
+	assert.ErrorIs(t, err, ErrTokenExpired)
+	assert.NotErrorIs(t, err, ErrInvalidTimestamp)
+}
+
+func TestWithTTL(t *testing.T) {
+	now := time.Date(2022, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	entries := []TokenEntry{
+		{TokenID: "active", ExpiresAt: "2022-01-01T15:12:00Z"},
+		{TokenID: "just-expired", ExpiresAt: "2022-01-01T11:59:59Z"},
+		{TokenID: "long-expired", ExpiresAt: "2021-01-01T12:00:00Z"},
+	}
+
+	got, err := withTTL(entries, now)
+	assert.NoError(t, err)
+	assert.Equal(t, []TokenWithTTL{
+		{TokenEntry: entries[0], RemainingTTL: 3*time.Hour + 12*time.Minute},
+		{TokenEntry: entries[1], RemainingTTL: -time.Second},
+		{TokenEntry: entries[2], RemainingTTL: -365 * 24 * time.Hour},
+	}, got)
+}
+
+func TestWithTTLInvalidTimestamp(t *testing.T) {
+	_, err := withTTL([]TokenEntry{{TokenID: "abc", ExpiresAt: "bad"}}, time.Now())
+	assert.ErrorContains(t, err, "token 'abc' has invalid expires_at")
+	assert.ErrorIs(t, err, ErrInvalidTimestamp)
+}
+
+func TestByUrgency(t *testing.T) {
+	tokens := []TokenWithTTL{
+		{TokenEntry: TokenEntry{TokenID: "later"}, RemainingTTL: 3 * time.Hour},
+		{TokenEntry: TokenEntry{TokenID: "expired"}, RemainingTTL: -time.Second},
+		{TokenEntry: TokenEntry{TokenID: "soonest"}, RemainingTTL: time.Minute},
+		{TokenEntry: TokenEntry{TokenID: "expiring-now"}, RemainingTTL: 0},
+		{TokenEntry: TokenEntry{TokenID: "soon"}, RemainingTTL: time.Hour},
+	}
+
+	got := byUrgency(tokens)
+
+	ids := []string{}
+	for _, t := range got {
+		ids = append(ids, t.TokenID)
+	}
+	assert.Equal(t, []string{"soonest", "soon", "later"}, ids)
+	assert.Equal(t, []TokenWithTTL{}, byUrgency(nil))
+}
+
+func TestValidateTokenTimes(t *testing.T) {
+	tests := []struct {
+		name      string
+		createdAt string
+		expiresAt string
+		wantErr   bool
+	}{
+		{
+			name:      "valid",
+			createdAt: "2022-01-01T12:00:00Z",
+			expiresAt: "2022-01-01T13:00:00Z",
+		},
+		{
+			name:      "valid with fractional seconds and offset",
+			createdAt: "2022-06-21T14:56:10.341066-07:00",
+			expiresAt: "2023-06-21T14:56:10.341066-07:00",
+		},
+		{
+			name:      "empty created_at",
+			expiresAt: "2022-01-01T13:00:00Z",
+			wantErr:   true,
+		},
+		{
+			name:      "empty expires_at",
+			createdAt: "2022-01-01T12:00:00Z",
+			wantErr:   true,
+		},
+		{
+			name:      "malformed created_at",
+			createdAt: "2022-01-01 12:00:00",
+			expiresAt: "2022-01-01T13:00:00Z",
+			wantErr:   true,
+		},
+		{
+			name:      "malformed expires_at",
+			createdAt: "2022-01-01T12:00:00Z",
+			expiresAt: "tomorrow",
+			wantErr:   true,
+		},
+		{
+			name:      "reversed",
+			createdAt: "2022-01-01T13:00:00Z",
+			expiresAt: "2022-01-01T12:00:00Z",
+			wantErr:   true,
+		},
+		{
+			name:      "equal",
+			createdAt: "2022-01-01T12:00:00Z",
+			expiresAt: "2022-01-01T12:00:00Z",
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateTokenTimes(types.Token{CreatedAt: tt.createdAt, ExpiresAt: tt.expiresAt})
+			if tt.wantErr {
+				assert.True(t, errors.Is(err, ErrInvalidTimestamp))
+				return
+			}
+			assert.Nil(t, err)
+		})
+	}
+}
+
+func TestCreateTokenEntryInvalidTimestamp(t *testing.T) {
+	c, err := NewSQLClient("localhost", "cello", "cello", "", nil)
+	assert.Nil(t, err)
+
+	opened := false
+	c.open = func(db.ConnectionURL) (db.Session, error) {
+		opened = true
+		return &fakeSession{}, nil
+	}
+
+	token := types.Token{
+		CreatedAt:    "2022-01-01T13:00:00Z",
+		ExpiresAt:    "2022-01-01T12:00:00Z",
+		ProjectID:    "project1",
+		ProjectToken: types.ProjectToken{ID: "token1"},
+	}
+
+	err = c.CreateTokenEntry(context.Background(), token)
+	assert.True(t, errors.Is(err, ErrInvalidTimestamp))
+
+	err = c.CreateProjectWithToken(context.Background(), ProjectEntry{ProjectID: "project1", Repository: "git@github.com:myorg/myrepo.git"}, token)
+	assert.True(t, errors.Is(err, ErrInvalidTimestamp))
+
+	assert.False(t, opened, "store must not be touched")
+}
+
+func TestCreateTokenEntriesRejectsBatch(t *testing.T) {
+	c, err := NewSQLClient("localhost", "cello", "cello", "", nil)
+	assert.Nil(t, err)
+
+	opened := false
+	c.open = func(db.ConnectionURL) (db.Session, error) {
+		opened = true
+		return &fakeSession{}, nil
+	}
+
+	tokens := []types.Token{
+		{
+			CreatedAt:    "2022-01-01T12:00:00Z",
+			ExpiresAt:    "2022-01-01T13:00:00Z",
+			ProjectID:    "project1",
+			ProjectToken: types.ProjectToken{ID: "token1"},
+		},
+		{
+			CreatedAt:    "2022-01-01T13:00:00Z",
+			ExpiresAt:    "2022-01-01T12:00:00Z",
+			ProjectID:    "project1",
+			ProjectToken: types.ProjectToken{ID: "token2"},
+		},
+	}
+
+	err = c.CreateTokenEntries(context.Background(), tokens)
+	assert.True(t, errors.Is(err, ErrInvalidTimestamp))
+	assert.Contains(t, err.Error(), "token 1")
+
+	assert.Nil(t, c.CreateTokenEntries(context.Background(), nil))
+
+	assert.False(t, opened, "store must not be touched")
+}
+
+func TestListExpiredTokenEntriesGlobal(t *testing.T) {
+	now := time.Date(2022, 1, 1, 12, 0, 0, 0, time.UTC)
+	columns := []string{"created_at", "expires_at", "project", "token_id", "role_id"}
+
+	tests := []struct {
+		name  string
+		limit int
+		query string
+	}{
+		{
+			name:  "limit",
+			limit: 2,
+			query: `SELECT "created_at", "expires_at", "project", "token_id", "role_id" FROM "tokens" WHERE \("expires_at" < \$1\) ORDER BY "expires_at" ASC LIMIT 2$`,
+		},
+		{
+			name:  "zero limit is unlimited",
+			query: `SELECT "created_at", "expires_at", "project", "token_id", "role_id" FROM "tokens" WHERE \("expires_at" < \$1\) ORDER BY "expires_at" ASC$`,
+		},
+		{
+			name:  "negative limit is unlimited",
+			limit: -1,
+			query: `SELECT "created_at", "expires_at", "project", "token_id", "role_id" FROM "tokens" WHERE \("expires_at" < \$1\) ORDER BY "expires_at" ASC$`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, mock := newMockSQLClient(t)
+			expectPrimaryKey(mock, TokenEntryDB, "token_id")
+			mock.ExpectQuery(tt.query).
+				WithArgs(now).
+				WillReturnRows(sqlmock.NewRows(columns).AddRow("2022-01-01T10:00:00Z", "2022-01-01T11:00:00Z", "project1", "token1", "role-id"))
+
+			got, err := c.ListExpiredTokenEntriesGlobal(context.Background(), now, tt.limit)
+			assert.Nil(t, err)
+			assert.Equal(t, []TokenEntry{{
+				CreatedAt: "2022-01-01T10:00:00Z",
+				ExpiresAt: "2022-01-01T11:00:00Z",
+				ProjectID: "project1",
+				TokenID:   "token1",
+				RoleID:    "role-id",
+			}}, got)
+		})
+	}
+}
+
+func TestInMemoryClientListExpiredTokenEntriesGlobal(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2022, 1, 1, 12, 0, 0, 0, time.UTC)
+	c := NewInMemoryClient()
+
+	for _, project := range []string{"project1", "project2"} {
+		assert.Nil(t, c.CreateProjectEntry(ctx, ProjectEntry{ProjectID: project, Repository: testRepository}))
+	}
+	assert.Nil(t, c.CreateTokenEntry(ctx, testToken("project1", "token1", now.Add(-2*time.Hour))))
+	assert.Nil(t, c.CreateTokenEntry(ctx, testToken("project2", "token2", now.Add(-3*time.Hour))))
+	assert.Nil(t, c.CreateTokenEntry(ctx, testToken("project1", "token3", now.Add(-time.Hour))))
+	assert.Nil(t, c.CreateTokenEntry(ctx, testToken("project2", "token4", now)))
+
+	tests := []struct {
+		name  string
+		limit int
+		want  []string
+	}{
+		{"limit", 1, []string{"token2"}},
+		{"limit above count", 5, []string{"token2", "token1"}},
+		{"zero limit is unlimited", 0, []string{"token2", "token1"}},
+		{"negative limit is unlimited", -1, []string{"token2", "token1"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := c.ListExpiredTokenEntriesGlobal(ctx, now, tt.limit)
+			assert.Nil(t, err)
+			assert.Equal(t, tt.want, tokenIDs(got))
+		})
+	}
+}
+
+func TestListTokenEntriesByUrgency(t *testing.T) {
+	now := time.Date(2022, 1, 1, 12, 0, 0, 0, time.UTC)
+	columns := []string{"created_at", "expires_at", "project", "token_id", "role_id"}
+
+	c, mock := newMockSQLClient(t)
+	expectPrimaryKey(mock, TokenEntryDB, "token_id")
+	mock.ExpectQuery(`SELECT "created_at", "expires_at", "project", "token_id", "role_id" FROM "tokens" WHERE \("project" = \$1\) ORDER BY "created_at" DESC$`).
+		WithArgs("project1").
+		WillReturnRows(sqlmock.NewRows(columns).
+			AddRow("2022-01-01T11:00:00Z", "2022-01-01T15:00:00Z", "project1", "later", "").
+			AddRow("2022-01-01T10:00:00Z", "2022-01-01T12:00:00Z", "project1", "expiring-now", "").
+			AddRow("2022-01-01T09:00:00Z", "2022-01-01T12:30:00Z", "project1", "soonest", "").
+			AddRow("2022-01-01T08:00:00Z", "2022-01-01T11:00:00Z", "project1", "expired", ""))
+
+	got, err := c.ListTokenEntriesByUrgency(context.Background(), "project1", now)
+	assert.NoError(t, err)
+	assert.Equal(t, []TokenWithTTL{
+		{
+			TokenEntry:   TokenEntry{CreatedAt: "2022-01-01T09:00:00Z", ExpiresAt: "2022-01-01T12:30:00Z", ProjectID: "project1", TokenID: "soonest"},
+			RemainingTTL: 30 * time.Minute,
+		},
+		{
+			TokenEntry:   TokenEntry{CreatedAt: "2022-01-01T11:00:00Z", ExpiresAt: "2022-01-01T15:00:00Z", ProjectID: "project1", TokenID: "later"},
+			RemainingTTL: 3 * time.Hour,
+		},
+	}, got)
+}
+
+func TestListTokenEntriesWithTTLInvalidExpiry(t *testing.T) {
+	columns := []string{"created_at", "expires_at", "project", "token_id", "role_id"}
+
+	c, mock := newMockSQLClient(t)
+	expectPrimaryKey(mock, TokenEntryDB, "token_id")
+	mock.ExpectQuery(`SELECT .* FROM "tokens" WHERE \("project" = \$1\)`).
+		WithArgs("project1").
+		WillReturnRows(sqlmock.NewRows(columns).AddRow("2022-01-01T11:00:00Z", "bad", "project1", "token1", ""))
+
+	_, err := c.ListTokenEntriesWithTTL(context.Background(), "project1", time.Now())
+	assert.ErrorIs(t, err, ErrInvalidTimestamp)
+}
+
+func TestInMemoryClientListTokenEntriesByUrgency(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2022, 1, 1, 12, 0, 0, 0, time.UTC)
+	c := NewInMemoryClient()
+
+	for _, project := range []string{"project1", "project2"} {
+		assert.Nil(t, c.CreateProjectEntry(ctx, ProjectEntry{ProjectID: project, Repository: testRepository}))
+	}
+	// testToken tokens expire an hour after they are created.
+	assert.Nil(t, c.CreateTokenEntry(ctx, testToken("project1", "expired", now.Add(-2*time.Hour))))
+	assert.Nil(t, c.CreateTokenEntry(ctx, testToken("project1", "expiring-now", now.Add(-time.Hour))))
+	assert.Nil(t, c.CreateTokenEntry(ctx, testToken("project1", "later", now.Add(-10*time.Minute))))
+	assert.Nil(t, c.CreateTokenEntry(ctx, testToken("project1", "soonest", now.Add(-50*time.Minute))))
+	assert.Nil(t, c.CreateTokenEntry(ctx, testToken("project2", "other", now.Add(-55*time.Minute))))
+
+	tokens, err := c.ListTokenEntriesWithTTL(ctx, "project1", now)
+	assert.Nil(t, err)
+	ttls := map[string]time.Duration{}
+	for _, tok := range tokens {
+		ttls[tok.TokenID] = tok.RemainingTTL
+	}
+	assert.Equal(t, map[string]time.Duration{
+		"expired":      -time.Hour,
+		"expiring-now": 0,
+		"later":        50 * time.Minute,
+		"soonest":      10 * time.Minute,
+	}, ttls)
+
+	urgent, err := c.ListTokenEntriesByUrgency(ctx, "project1", now)
+	assert.Nil(t, err)
+	ids := []string{}
+	for _, tok := range urgent {
+		ids = append(ids, tok.TokenID)
+	}
+	assert.Equal(t, []string{"soonest", "later"}, ids)
+}
@@ -0,0 +1,104 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/cello-proj/cello/internal/types"
+)
+
+const projectExportVersion = 1
+
+// projectExport is the portable representation of a project and its tokens.
+// Token secrets are never included.
+type projectExport struct {
+	Version int                  `json:"version"`
+	Project projectExportProject `json:"project"`
+	Tokens  []projectExportToken `json:"tokens"`
+}
+
+type projectExportProject struct {
+	ProjectID  string       `json:"project_id"`
+	Repository string       `json:"repository"`
+	Quota      ProjectQuota `json:"quota"`
+}
+
+type projectExportToken struct {
+	TokenID   string `json:"token_id"`
+	CreatedAt string `json:"created_at"`
+	ExpiresAt string `json:"expires_at"`
+	RoleID    string `json:"role_id,omitempty"`
+}
+
+// ExportProject returns the project and its token metadata as a JSON
+// document suitable for Client.ImportProject.
+func ExportProject(ctx context.Context, c Client, project string) ([]byte, error) {
+	pe, err := c.ReadProjectEntry(ctx, project)
+	if err != nil {
+		return nil, err
+	}
+
+	tokens, err := c.ListTokenEntries(ctx, project)
+	if err != nil {
+		return nil, err
+	}
+
+	export := projectExport{
+		Version: projectExportVersion,
+		Project: projectExportProject{
+			ProjectID:  pe.ProjectID,
+			Repository: pe.Repository,
+			Quota:      pe.Quota,
+		},
+		Tokens: []projectExportToken{},
+	}
+
+	for _, t := range tokens {
+		export.Tokens = append(export.Tokens, projectExportToken{
+			TokenID:   t.TokenID,
+			CreatedAt: t.CreatedAt,
+			ExpiresAt: t.ExpiresAt,
+			RoleID:    t.RoleID,
+		})
+	}
+
+	return json.MarshalIndent(export, "", "  ")
+}
+
+// decodeProjectExport returns the project and tokens described by a
+// document created by ExportProject.
+func decodeProjectExport(data []byte) (ProjectEntry, []types.Token, error) {
+	var export projectExport
+	if err := json.Unmarshal(data, &export); err != nil {
+		return ProjectEntry{}, nil, fmt.Errorf("unable to decode project export: %w", err)
+	}
+
+	if export.Version != projectExportVersion {
+		return ProjectEntry{}, nil, fmt.Errorf("unsupported project export version %d", export.Version)
+	}
+
+	if export.Project.ProjectID == "" {
+		return ProjectEntry{}, nil, errors.New("project export is missing project_id")
+	}
+
+	pe := ProjectEntry{
+		ProjectID:  export.Project.ProjectID,
+		Repository: export.Project.Repository,
+		Quota:      export.Project.Quota,
+	}
+
+	tokens := make([]types.Token, 0, len(export.Tokens))
+	for _, t := range export.Tokens {
+		tokens = append(tokens, types.Token{
+			CreatedAt:    t.CreatedAt,
+			ExpiresAt:    t.ExpiresAt,
+			ProjectID:    pe.ProjectID,
+			ProjectToken: types.ProjectToken{ID: t.TokenID},
+			RoleID:       t.RoleID,
+		})
+	}
+
+	return pe, tokens, nil
+}
@@ -0,0 +1,157 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+const testExport = `{
+  "version": 1,
+  "project": {
+    "project_id": "project1",
+    "repository": "git@github.com:myorg/myrepo.git",
+    "quota": {}
+  },
+  "tokens": [
+    {
+      "token_id": "token1",
+      "created_at": "2022-05-21T14:56:10Z",
+      "expires_at": "2023-05-21T14:56:10Z",
+      "role_id": "role1"
+    },
+    {
+      "token_id": "token2",
+      "created_at": "2022-06-21T14:56:10Z",
+      "expires_at": "2023-06-21T14:56:10Z"
+    }
+  ]
+}`
+
+func TestExportImportProjectRoundTrip(t *testing.T) {
+	ctx := context.Background()
+
+	src := NewInMemoryClient()
+	assert.NoError(t, src.ImportProject(ctx, []byte(testExport)))
+
+	data, err := ExportProject(ctx, src, "project1")
+	assert.NoError(t, err)
+	assert.NotContains(t, string(data), "secret")
+
+	dst := NewInMemoryClient()
+	assert.NoError(t, dst.ImportProject(ctx, data))
+
+	wantProject, err := src.ReadProjectEntry(ctx, "project1")
+	assert.NoError(t, err)
+	gotProject, err := dst.ReadProjectEntry(ctx, "project1")
+	assert.NoError(t, err)
+	assert.Equal(t, wantProject, gotProject)
+
+	again, err := ExportProject(ctx, dst, "project1")
+	assert.NoError(t, err)
+	assert.Equal(t, string(data), string(again))
+}
+
+func TestInMemoryClientImportProject(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("refuses an existing project", func(t *testing.T) {
+		c := NewInMemoryClient()
+		existing := ProjectEntry{ProjectID: "project1", Repository: "git@github.com:myorg/old.git"}
+		assert.NoError(t, c.CreateProjectEntry(ctx, existing))
+
+		assert.ErrorIs(t, c.ImportProject(ctx, []byte(testExport)), ErrProjectExists)
+
+		got, err := c.ReadProjectEntry(ctx, "project1")
+		assert.NoError(t, err)
+		assert.Equal(t, existing, got)
+
+		tokens, err := c.ListTokenEntries(ctx, "project1")
+		assert.NoError(t, err)
+		assert.Empty(t, tokens)
+	})
+
+	t.Run("writes nothing when a token conflicts", func(t *testing.T) {
+		c := NewInMemoryClient()
+		assert.NoError(t, c.CreateProjectEntry(ctx, ProjectEntry{ProjectID: "project2", Repository: testRepository}))
+		assert.NoError(t, c.CreateTokenEntry(ctx, testToken("project2", "token2", time.Now())))
+
+		assert.EqualError(t, c.ImportProject(ctx, []byte(testExport)), "token 'token2' already exists")
+
+		_, err := c.ReadProjectEntry(ctx, "project1")
+		assert.ErrorIs(t, err, ErrProjectNotFound)
+	})
+}
+
+func TestSQLClientImportProject(t *testing.T) {
+	t.Run("commits the project and tokens", func(t *testing.T) {
+		c, mock := newMockSQLClient(t)
+
+		mock.ExpectBegin()
+		expectCreateProjectEntry(mock, 1)
+		mock.ExpectExec(`INSERT INTO "tokens" .* VALUES \(.*\), \(.*\)`).
+			WithArgs("2022-05-21T14:56:10Z", "2023-05-21T14:56:10Z", "project1", "role1", "token1",
+				"2022-06-21T14:56:10Z", "2023-06-21T14:56:10Z", "project1", "", "token2").
+			WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectExec(`INSERT INTO "token_history" .* VALUES \(.*\), \(.*\)`).
+			WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectCommit()
+
+		assert.NoError(t, c.ImportProject(context.Background(), []byte(testExport)))
+	})
+
+	t.Run("rolls back the project when the tokens fail", func(t *testing.T) {
+		c, mock := newMockSQLClient(t)
+
+		mock.ExpectBegin()
+		expectCreateProjectEntry(mock, 1)
+		mock.ExpectExec(`INSERT INTO "tokens"`).WillReturnError(errors.New("boom"))
+		mock.ExpectRollback()
+
+		assert.EqualError(t, c.ImportProject(context.Background(), []byte(testExport)), "boom")
+	})
+
+	t.Run("project exists", func(t *testing.T) {
+		c, mock := newMockSQLClient(t)
+
+		mock.ExpectBegin()
+		expectCreateProjectEntry(mock, 0)
+		mock.ExpectRollback()
+
+		assert.ErrorIs(t, c.ImportProject(context.Background(), []byte(testExport)), ErrProjectExists)
+	})
+}
+
+func TestImportProjectErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		wantErr string
+	}{
+		{
+			name:    "invalid json",
+			data:    "{",
+			wantErr: "unable to decode project export: unexpected end of JSON input",
+		},
+		{
+			name:    "unsupported version",
+			data:    `{"version": 2, "project": {"project_id": "project1"}}`,
+			wantErr: "unsupported project export version 2",
+		},
+		{
+			name:    "missing project id",
+			data:    `{"version": 1, "project": {}}`,
+			wantErr: "project export is missing project_id",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.EqualError(t, NewInMemoryClient().ImportProject(context.Background(), []byte(tt.data)), tt.wantErr)
+		})
+	}
+}
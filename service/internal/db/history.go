@@ -0,0 +1,67 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	"github.com/cello-proj/cello/internal/types"
+	"github.com/upper/db/v4"
+)
+
+// TokenHistoryEntry is the permanent record that a token existed. History is
+// written when a token is created and marked when it is deleted, but is never
+// removed, so it outlives the token and its project. It never carries the
+// token secret.
+type TokenHistoryEntry struct {
+	TokenID   string `db:"token_id"`
+	ProjectID string `db:"project"`
+	CreatedAt string `db:"created_at"`
+	// DeletedAt is nil while the token exists.
+	DeletedAt *time.Time `db:"deleted_at"`
+}
+
+// newTokenHistoryEntry returns the history entry recorded for a new token.
+func newTokenHistoryEntry(token types.Token) TokenHistoryEntry {
+	return TokenHistoryEntry{
+		TokenID:   token.ProjectToken.ID,
+		ProjectID: token.ProjectID,
+		CreatedAt: token.CreatedAt,
+	}
+}
+
+// recordTokenDeletions records a tombstone and marks the history of each
+// token matching the where clause as deleted. It must be called in the same
+// transaction as, and before, the tokens are deleted.
+func recordTokenDeletions(sess db.Session, deletedAt time.Time, where string, args ...interface{}) error {
+	_, err := sess.SQL().Exec(
+		"INSERT INTO "+TokenTombstoneDB+" (token_id, project, deleted_at) SELECT token_id, project, ? FROM "+TokenEntryDB+" WHERE "+where+" ON CONFLICT (token_id) DO NOTHING",
+		append([]interface{}{deletedAt}, args...)...,
+	)
+	if err != nil {
+		return err
+	}
+
+	_, err = sess.SQL().Exec(
+		"UPDATE "+TokenHistoryDB+" SET deleted_at = ? WHERE deleted_at IS NULL AND token_id IN (SELECT token_id FROM "+TokenEntryDB+" WHERE "+where+")",
+		append([]interface{}{deletedAt}, args...)...,
+	)
+	return err
+}
+
+// ListTokenHistory returns every token the project has had, including deleted
+// ones, newest first.
+func (d SQLClient) ListTokenHistory(ctx context.Context, project string) ([]TokenHistoryEntry, error) {
+	ctx, cancel := d.withTimeout(ctx)
+	defer cancel()
+
+	project = d.projectID(project)
+
+	sess, err := d.createSession()
+	if err != nil {
+		return nil, err
+	}
+
+	res := []TokenHistoryEntry{}
+	err = sess.WithContext(ctx).Collection(TokenHistoryDB).Find("project", project).OrderBy("-created_at").All(&res)
+	return res, err
+}
@@ -0,0 +1,76 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/cello-proj/cello/internal/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateTokenEntryRecordsHistory(t *testing.T) {
+	c, mock := newMockSQLClient(t)
+	token := types.Token{
+		CreatedAt:    "2022-01-01T12:00:00Z",
+		ExpiresAt:    "2022-01-01T13:00:00Z",
+		ProjectID:    "project1",
+		ProjectToken: types.ProjectToken{ID: "token1"},
+		RoleID:       "role1",
+		Secret:       "s3cr3t",
+	}
+
+	mock.ExpectBegin()
+	expectPrimaryKey(mock, TokenEntryDB, "token_id")
+	mock.ExpectQuery(`INSERT INTO "tokens"`).
+		WillReturnRows(sqlmock.NewRows([]string{"token_id"}).AddRow("token1"))
+	expectPrimaryKey(mock, TokenHistoryDB, "id")
+	// The history row carries no secret or expiry, and starts undeleted.
+	mock.ExpectQuery(`INSERT INTO "token_history" \("created_at", "deleted_at", "project", "token_id"\) VALUES \(\$1, NULL, \$2, \$3\) RETURNING "id"`).
+		WithArgs("2022-01-01T12:00:00Z", "project1", "token1").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectCommit()
+
+	assert.Nil(t, c.CreateTokenEntry(context.Background(), token))
+}
+
+func TestDeleteTokenEntryMarksHistory(t *testing.T) {
+	deletedAt := time.Date(2022, 1, 1, 12, 30, 0, 0, time.UTC)
+	c, mock := newMockSQLClient(t)
+	c.now = func() time.Time { return deletedAt }
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`INSERT INTO token_tombstones \(token_id, project, deleted_at\) SELECT token_id, project, \$1 FROM tokens WHERE token_id = \$2 ON CONFLICT \(token_id\) DO NOTHING`).
+		WithArgs(deletedAt, "token1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`UPDATE token_history SET deleted_at = \$1 WHERE deleted_at IS NULL AND token_id IN \(SELECT token_id FROM tokens WHERE token_id = \$2\)`).
+		WithArgs(deletedAt, "token1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	expectPrimaryKey(mock, TokenEntryDB, "token_id")
+	mock.ExpectExec(`DELETE FROM "tokens" WHERE \("token_id" = \$1\)`).
+		WithArgs("token1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	assert.Nil(t, c.DeleteTokenEntry(context.Background(), "token1"))
+}
+
+func TestListTokenHistory(t *testing.T) {
+	c, mock := newMockSQLClient(t)
+	deletedAt := time.Date(2022, 1, 2, 0, 0, 0, 0, time.UTC)
+
+	expectPrimaryKey(mock, TokenHistoryDB, "id")
+	mock.ExpectQuery(`SELECT \* FROM "token_history" WHERE \("project" = \$1\) ORDER BY "created_at" DESC`).
+		WithArgs("project1").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "token_id", "project", "created_at", "deleted_at"}).
+			AddRow(2, "token2", "project1", "2022-01-01T13:00:00Z", nil).
+			AddRow(1, "token1", "project1", "2022-01-01T12:00:00Z", deletedAt))
+
+	got, err := c.ListTokenHistory(context.Background(), "project1")
+	assert.Nil(t, err)
+	assert.Equal(t, []TokenHistoryEntry{
+		{TokenID: "token2", ProjectID: "project1", CreatedAt: "2022-01-01T13:00:00Z"},
+		{TokenID: "token1", ProjectID: "project1", CreatedAt: "2022-01-01T12:00:00Z", DeletedAt: &deletedAt},
+	}, got)
+}
@@ -0,0 +1,410 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	"github.com/cello-proj/cello/internal/types"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// BackendPostgres is the backend label of metrics for SQLClient.
+const BackendPostgres = "postgres"
+
+var _ Client = (*InstrumentedClient)(nil)
+
+// InstrumentedClient wraps a Client and records prometheus metrics for each
+// call: call and error counts and a latency histogram, labeled by operation
+// and backend. Nothing is registered unless an InstrumentedClient is created.
+type InstrumentedClient struct {
+	client  Client
+	backend string
+
+	calls    *prometheus.CounterVec
+	errors   *prometheus.CounterVec
+	duration *prometheus.HistogramVec
+}
+
+// NewInstrumentedClient returns an InstrumentedClient wrapping c, registering
+// its metrics with reg. Backend labels the metrics, e.g. BackendPostgres.
+func NewInstrumentedClient(c Client, backend string, reg prometheus.Registerer) (*InstrumentedClient, error) {
+	labels := []string{"operation", "backend"}
+
+	ic := &InstrumentedClient{
+		client:  c,
+		backend: backend,
+		calls: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "cello_db_operations_total",
+			Help: "Number of db operations.",
+		}, labels),
+		errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "cello_db_operation_errors_total",
+			Help: "Number of db operations that returned an error.",
+		}, labels),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "cello_db_operation_duration_seconds",
+			Help:    "Latency of db operations.",
+			Buckets: prometheus.DefBuckets,
+		}, labels),
+	}
+
+	for _, col := range []prometheus.Collector{ic.calls, ic.errors, ic.duration} {
+		if err := reg.Register(col); err != nil {
+			return nil, err
+		}
+	}
+
+	return ic, nil
+}
+
+// observe records a call to the operation, which began at began.
+func (c *InstrumentedClient) observe(operation string, began time.Time, err error) {
+	c.calls.WithLabelValues(operation, c.backend).Inc()
+	if err != nil {
+		c.errors.WithLabelValues(operation, c.backend).Inc()
+	}
+	c.duration.WithLabelValues(operation, c.backend).Observe(time.Since(began).Seconds())
+}
+
+// StreamProjectEntries implements Client. The call is observed once the
+// stream ends, with the error it ended with, if any.
+func (c *InstrumentedClient) StreamProjectEntries(ctx context.Context) (<-chan ProjectEntry, <-chan error) {
+	began := time.Now()
+	entries, errc := c.client.StreamProjectEntries(ctx)
+
+	out := make(chan error, 1)
+	go func() {
+		defer close(out)
+
+		var err error
+		for e := range errc {
+			if err == nil {
+				err = e
+			}
+			out <- e
+		}
+		c.observe("StreamProjectEntries", began, err)
+	}()
+
+	return entries, out
+}
+
+// CreateProjectEntry implements Client.
+func (c *InstrumentedClient) CreateProjectEntry(ctx context.Context, pe ProjectEntry) error {
+	began := time.Now()
+	err := c.client.CreateProjectEntry(ctx, pe)
+	c.observe("CreateProjectEntry", began, err)
+	return err
+}
+
+// CreateProjectWithToken implements Client.
+func (c *InstrumentedClient) CreateProjectWithToken(ctx context.Context, pe ProjectEntry, token types.Token) error {
+	began := time.Now()
+	err := c.client.CreateProjectWithToken(ctx, pe, token)
+	c.observe("CreateProjectWithToken", began, err)
+	return err
+}
+
+// ImportProject implements Client.
+func (c *InstrumentedClient) ImportProject(ctx context.Context, data []byte) error {
+	began := time.Now()
+	err := c.client.ImportProject(ctx, data)
+	c.observe("ImportProject", began, err)
+	return err
+}
+
+// DeleteProjectEntry implements Client.
+func (c *InstrumentedClient) DeleteProjectEntry(ctx context.Context, project string) error {
+	began := time.Now()
+	err := c.client.DeleteProjectEntry(ctx, project)
+	c.observe("DeleteProjectEntry", began, err)
+	return err
+}
+
+// RestoreProjectEntry implements Client.
+func (c *InstrumentedClient) RestoreProjectEntry(ctx context.Context, project string) error {
+	began := time.Now()
+	err := c.client.RestoreProjectEntry(ctx, project)
+	c.observe("RestoreProjectEntry", began, err)
+	return err
+}
+
+// PurgeDeletedProjects implements Client.
+func (c *InstrumentedClient) PurgeDeletedProjects(ctx context.Context, now time.Time) (int, error) {
+	began := time.Now()
+	res, err := c.client.PurgeDeletedProjects(ctx, now)
+	c.observe("PurgeDeletedProjects", began, err)
+	return res, err
+}
+
+// ReadProjectEntry implements Client.
+func (c *InstrumentedClient) ReadProjectEntry(ctx context.Context, project string) (ProjectEntry, error) {
+	began := time.Now()
+	res, err := c.client.ReadProjectEntry(ctx, project)
+	c.observe("ReadProjectEntry", began, err)
+	return res, err
+}
+
+// ReadProjectEntryWithETag implements Client.
+func (c *InstrumentedClient) ReadProjectEntryWithETag(ctx context.Context, project string) (ProjectEntry, string, error) {
+	began := time.Now()
+	pe, etag, err := c.client.ReadProjectEntryWithETag(ctx, project)
+	c.observe("ReadProjectEntryWithETag", began, err)
+	return pe, etag, err
+}
+
+// UpdateProjectEntry implements Client.
+func (c *InstrumentedClient) UpdateProjectEntry(ctx context.Context, pe ProjectEntry) error {
+	began := time.Now()
+	err := c.client.UpdateProjectEntry(ctx, pe)
+	c.observe("UpdateProjectEntry", began, err)
+	return err
+}
+
+// UpdateProjectEntryIfMatch implements Client.
+func (c *InstrumentedClient) UpdateProjectEntryIfMatch(ctx context.Context, pe ProjectEntry, etag string) error {
+	began := time.Now()
+	err := c.client.UpdateProjectEntryIfMatch(ctx, pe, etag)
+	c.observe("UpdateProjectEntryIfMatch", began, err)
+	return err
+}
+
+// FindDuplicateRepositories implements Client.
+func (c *InstrumentedClient) FindDuplicateRepositories(ctx context.Context) (map[string][]string, error) {
+	began := time.Now()
+	res, err := c.client.FindDuplicateRepositories(ctx)
+	c.observe("FindDuplicateRepositories", began, err)
+	return res, err
+}
+
+// ListProjectEntries implements Client.
+func (c *InstrumentedClient) ListProjectEntries(ctx context.Context, opts ListOptions) ([]ProjectEntry, string, error) {
+	began := time.Now()
+	entries, cursor, err := c.client.ListProjectEntries(ctx, opts)
+	c.observe("ListProjectEntries", began, err)
+	return entries, cursor, err
+}
+
+// ListProjectsByRepository implements Client.
+func (c *InstrumentedClient) ListProjectsByRepository(ctx context.Context, repository string) ([]ProjectEntry, error) {
+	began := time.Now()
+	res, err := c.client.ListProjectsByRepository(ctx, repository)
+	c.observe("ListProjectsByRepository", began, err)
+	return res, err
+}
+
+// ReadProjectActivity implements Client.
+func (c *InstrumentedClient) ReadProjectActivity(ctx context.Context, project string) (ProjectEntry, time.Time, error) {
+	began := time.Now()
+	pe, lastActivity, err := c.client.ReadProjectActivity(ctx, project)
+	c.observe("ReadProjectActivity", began, err)
+	return pe, lastActivity, err
+}
+
+// ReadProjectsWithTokenCounts implements Client.
+func (c *InstrumentedClient) ReadProjectsWithTokenCounts(ctx context.Context, ids []string) ([]ProjectSummary, error) {
+	began := time.Now()
+	res, err := c.client.ReadProjectsWithTokenCounts(ctx, ids)
+	c.observe("ReadProjectsWithTokenCounts", began, err)
+	return res, err
+}
+
+// CreateTokenEntry implements Client.
+func (c *InstrumentedClient) CreateTokenEntry(ctx context.Context, token types.Token) error {
+	began := time.Now()
+	err := c.client.CreateTokenEntry(ctx, token)
+	c.observe("CreateTokenEntry", began, err)
+	return err
+}
+
+// CreateTokenEntries implements Client.
+func (c *InstrumentedClient) CreateTokenEntries(ctx context.Context, tokens []types.Token) error {
+	began := time.Now()
+	err := c.client.CreateTokenEntries(ctx, tokens)
+	c.observe("CreateTokenEntries", began, err)
+	return err
+}
+
+// IssueToken implements Client.
+func (c *InstrumentedClient) IssueToken(ctx context.Context, project, roleID string, ttl time.Duration) (types.Token, error) {
+	began := time.Now()
+	res, err := c.client.IssueToken(ctx, project, roleID, ttl)
+	c.observe("IssueToken", began, err)
+	return res, err
+}
+
+// VerifyTokenSecret implements Client.
+func (c *InstrumentedClient) VerifyTokenSecret(ctx context.Context, token, secret string) (TokenEntry, error) {
+	began := time.Now()
+	res, err := c.client.VerifyTokenSecret(ctx, token, secret)
+	c.observe("VerifyTokenSecret", began, err)
+	return res, err
+}
+
+// VerifyAndTouch implements Client.
+func (c *InstrumentedClient) VerifyAndTouch(ctx context.Context, project, token, secret string) (TokenEntry, error) {
+	began := time.Now()
+	res, err := c.client.VerifyAndTouch(ctx, project, token, secret)
+	c.observe("VerifyAndTouch", began, err)
+	return res, err
+}
+
+// AddPendingSecret implements Client.
+func (c *InstrumentedClient) AddPendingSecret(ctx context.Context, token string, ttl time.Duration) (string, error) {
+	began := time.Now()
+	res, err := c.client.AddPendingSecret(ctx, token, ttl)
+	c.observe("AddPendingSecret", began, err)
+	return res, err
+}
+
+// PromotePendingSecret implements Client.
+func (c *InstrumentedClient) PromotePendingSecret(ctx context.Context, token string) error {
+	began := time.Now()
+	err := c.client.PromotePendingSecret(ctx, token)
+	c.observe("PromotePendingSecret", began, err)
+	return err
+}
+
+// DeleteTokenEntry implements Client.
+func (c *InstrumentedClient) DeleteTokenEntry(ctx context.Context, token string) error {
+	began := time.Now()
+	err := c.client.DeleteTokenEntry(ctx, token)
+	c.observe("DeleteTokenEntry", began, err)
+	return err
+}
+
+// DeleteExpiredTokens implements Client.
+func (c *InstrumentedClient) DeleteExpiredTokens(ctx context.Context, project string, now time.Time) (int, error) {
+	began := time.Now()
+	res, err := c.client.DeleteExpiredTokens(ctx, project, now)
+	c.observe("DeleteExpiredTokens", began, err)
+	return res, err
+}
+
+// NextTokenSequence implements Client.
+func (c *InstrumentedClient) NextTokenSequence(ctx context.Context, project string) (int64, error) {
+	began := time.Now()
+	res, err := c.client.NextTokenSequence(ctx, project)
+	c.observe("NextTokenSequence", began, err)
+	return res, err
+}
+
+// ReadTokenEntry implements Client.
+func (c *InstrumentedClient) ReadTokenEntry(ctx context.Context, token string) (TokenEntry, error) {
+	began := time.Now()
+	res, err := c.client.ReadTokenEntry(ctx, token)
+	c.observe("ReadTokenEntry", began, err)
+	return res, err
+}
+
+// ReadTokenEntryScoped implements Client.
+func (c *InstrumentedClient) ReadTokenEntryScoped(ctx context.Context, project, token string) (TokenEntry, error) {
+	began := time.Now()
+	res, err := c.client.ReadTokenEntryScoped(ctx, project, token)
+	c.observe("ReadTokenEntryScoped", began, err)
+	return res, err
+}
+
+// CountTokenEntries implements Client.
+func (c *InstrumentedClient) CountTokenEntries(ctx context.Context, project string) (int, error) {
+	began := time.Now()
+	res, err := c.client.CountTokenEntries(ctx, project)
+	c.observe("CountTokenEntries", began, err)
+	return res, err
+}
+
+// PreviewAffectedTokenCount implements Client.
+func (c *InstrumentedClient) PreviewAffectedTokenCount(ctx context.Context, project string, predicate Predicate) (int, error) {
+	began := time.Now()
+	res, err := c.client.PreviewAffectedTokenCount(ctx, project, predicate)
+	c.observe("PreviewAffectedTokenCount", began, err)
+	return res, err
+}
+
+// ListTokenEntries implements Client.
+func (c *InstrumentedClient) ListTokenEntries(ctx context.Context, project string) ([]TokenEntry, error) {
+	began := time.Now()
+	res, err := c.client.ListTokenEntries(ctx, project)
+	c.observe("ListTokenEntries", began, err)
+	return res, err
+}
+
+// ListTokenEntriesCreatedBetween implements Client.
+func (c *InstrumentedClient) ListTokenEntriesCreatedBetween(ctx context.Context, project string, start, end time.Time) ([]TokenEntry, error) {
+	began := time.Now()
+	res, err := c.client.ListTokenEntriesCreatedBetween(ctx, project, start, end)
+	c.observe("ListTokenEntriesCreatedBetween", began, err)
+	return res, err
+}
+
+// ListTokenEntriesWithTTL implements Client.
+func (c *InstrumentedClient) ListTokenEntriesWithTTL(ctx context.Context, project string, now time.Time) ([]TokenWithTTL, error) {
+	began := time.Now()
+	res, err := c.client.ListTokenEntriesWithTTL(ctx, project, now)
+	c.observe("ListTokenEntriesWithTTL", began, err)
+	return res, err
+}
+
+// ListTokenEntriesByUrgency implements Client.
+func (c *InstrumentedClient) ListTokenEntriesByUrgency(ctx context.Context, project string, now time.Time) ([]TokenWithTTL, error) {
+	began := time.Now()
+	res, err := c.client.ListTokenEntriesByUrgency(ctx, project, now)
+	c.observe("ListTokenEntriesByUrgency", began, err)
+	return res, err
+}
+
+// ListTokenEntriesPaged implements Client.
+func (c *InstrumentedClient) ListTokenEntriesPaged(ctx context.Context, project string, limit int, cursor string) (ListTokenEntriesResult, error) {
+	began := time.Now()
+	res, err := c.client.ListTokenEntriesPaged(ctx, project, limit, cursor)
+	c.observe("ListTokenEntriesPaged", began, err)
+	return res, err
+}
+
+// ListExpiredTokenEntriesGlobal implements Client.
+func (c *InstrumentedClient) ListExpiredTokenEntriesGlobal(ctx context.Context, now time.Time, limit int) ([]TokenEntry, error) {
+	began := time.Now()
+	res, err := c.client.ListExpiredTokenEntriesGlobal(ctx, now, limit)
+	c.observe("ListExpiredTokenEntriesGlobal", began, err)
+	return res, err
+}
+
+// ListTokenChanges implements Client.
+func (c *InstrumentedClient) ListTokenChanges(ctx context.Context, project, syncToken string) (TokenChangeSet, error) {
+	began := time.Now()
+	res, err := c.client.ListTokenChanges(ctx, project, syncToken)
+	c.observe("ListTokenChanges", began, err)
+	return res, err
+}
+
+// ListTokenHistory implements Client.
+func (c *InstrumentedClient) ListTokenHistory(ctx context.Context, project string) ([]TokenHistoryEntry, error) {
+	began := time.Now()
+	res, err := c.client.ListTokenHistory(ctx, project)
+	c.observe("ListTokenHistory", began, err)
+	return res, err
+}
+
+// PurgeTokenTombstones implements Client.
+func (c *InstrumentedClient) PurgeTokenTombstones(ctx context.Context, before time.Time) (int, error) {
+	began := time.Now()
+	res, err := c.client.PurgeTokenTombstones(ctx, before)
+	c.observe("PurgeTokenTombstones", began, err)
+	return res, err
+}
+
+// SystemStats implements Client.
+func (c *InstrumentedClient) SystemStats(ctx context.Context, now time.Time) (SystemStats, error) {
+	began := time.Now()
+	res, err := c.client.SystemStats(ctx, now)
+	c.observe("SystemStats", began, err)
+	return res, err
+}
+
+// Health implements Client.
+func (c *InstrumentedClient) Health(ctx context.Context) error {
+	began := time.Now()
+	err := c.client.Health(ctx)
+	c.observe("Health", began, err)
+	return err
+}
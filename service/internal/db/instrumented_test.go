@@ -0,0 +1,90 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+// instrumentedFakeClient is a fake Client returning a fixed error.
+type instrumentedFakeClient struct {
+	Client
+
+	err error
+}
+
+func (c *instrumentedFakeClient) ReadProjectEntry(ctx context.Context, project string) (ProjectEntry, error) {
+	return ProjectEntry{ProjectID: project}, c.err
+}
+
+func (c *instrumentedFakeClient) StreamProjectEntries(ctx context.Context) (<-chan ProjectEntry, <-chan error) {
+	out := make(chan ProjectEntry)
+	errc := make(chan error, 1)
+	errc <- c.err
+	close(out)
+	close(errc)
+	return out, errc
+}
+
+func TestInstrumentedClient(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantErrors float64
+	}{
+		{
+			name: "success",
+		},
+		{
+			name:       "error",
+			err:        errors.New("boom"),
+			wantErrors: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reg := prometheus.NewRegistry()
+			c, err := NewInstrumentedClient(&instrumentedFakeClient{err: tt.err}, BackendPostgres, reg)
+			assert.Nil(t, err)
+
+			pe, err := c.ReadProjectEntry(context.Background(), "project1")
+			assert.Equal(t, tt.err, err)
+			assert.Equal(t, "project1", pe.ProjectID)
+
+			assert.Equal(t, float64(1), testutil.ToFloat64(c.calls.WithLabelValues("ReadProjectEntry", BackendPostgres)))
+			assert.Equal(t, tt.wantErrors, testutil.ToFloat64(c.errors.WithLabelValues("ReadProjectEntry", BackendPostgres)))
+			assert.Equal(t, 1, testutil.CollectAndCount(c.duration))
+		})
+	}
+}
+
+func TestInstrumentedClientStream(t *testing.T) {
+	boom := errors.New("boom")
+	c, err := NewInstrumentedClient(&instrumentedFakeClient{err: boom}, BackendPostgres, prometheus.NewRegistry())
+	assert.Nil(t, err)
+
+	entries, errc := c.StreamProjectEntries(context.Background())
+	for range entries {
+	}
+	assert.Equal(t, boom, <-errc)
+
+	// The stream is observed once the error channel is closed.
+	for range errc {
+	}
+	assert.Equal(t, float64(1), testutil.ToFloat64(c.errors.WithLabelValues("StreamProjectEntries", BackendPostgres)))
+}
+
+func TestNewInstrumentedClientRegisterError(t *testing.T) {
+	reg := prometheus.NewRegistry()
+
+	_, err := NewInstrumentedClient(&instrumentedFakeClient{}, BackendPostgres, reg)
+	assert.Nil(t, err)
+
+	_, err = NewInstrumentedClient(&instrumentedFakeClient{}, BackendPostgres, reg)
+	assert.NotNil(t, err)
+}
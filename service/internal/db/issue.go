@@ -0,0 +1,340 @@
+package db
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/cello-proj/cello/internal/types"
+	"github.com/google/uuid"
+	"github.com/upper/db/v4"
+)
+
+const tokenSecretBytes = 32
+
+var (
+	// ErrInvalidTokenRequest conveys that a token could not be issued with the
+	// provided arguments.
+	ErrInvalidTokenRequest = errors.New("invalid token request")
+	// ErrInvalidTokenSecret conveys that the secret does not match the token.
+	ErrInvalidTokenSecret = errors.New("invalid token secret")
+	// ErrTokenSecretCorrupt conveys that the stored secret hash of the token
+	// is missing or malformed, so no secret can verify. It wraps
+	// ErrInvalidTokenSecret.
+	ErrTokenSecretCorrupt = fmt.Errorf("%w: stored secret hash is missing or malformed", ErrInvalidTokenSecret)
+	// ErrNoPendingSecret conveys that the token has no unexpired pending
+	// secret to promote.
+	ErrNoPendingSecret = errors.New("no pending token secret")
+)
+
+// issuedTokenEntry is a token along with the hashes of its secrets. The
+// primary hash is only set for tokens issued by IssueToken. A pending secret,
+// added by AddPendingSecret, is accepted alongside the primary one until it
+// expires or is promoted.
+type issuedTokenEntry struct {
+	TokenEntry             `db:",inline"`
+	SecretHash             *string    `db:"secret_hash"`
+	PendingSecretHash      *string    `db:"pending_secret_hash,omitempty"`
+	PendingSecretExpiresAt *time.Time `db:"pending_secret_expires_at,omitempty"`
+}
+
+// issuedTokenEntryColumns are the columns of issuedTokenEntry.
+var issuedTokenEntryColumns = append([]interface{}{"secret_hash", "pending_secret_hash", "pending_secret_expires_at"}, tokenEntryColumns...)
+
+// secretCorrupt returns whether the primary secret hash is missing or
+// malformed, or a pending one is malformed.
+func (e issuedTokenEntry) secretCorrupt() bool {
+	return !validSecretHash(e.SecretHash) || (e.PendingSecretHash != nil && !validSecretHash(e.PendingSecretHash))
+}
+
+// validSecretHash returns whether hash is a hash written by hashTokenSecret.
+func validSecretHash(hash *string) bool {
+	if hash == nil || len(*hash) != sha256.Size*2 {
+		return false
+	}
+	_, err := hex.DecodeString(*hash)
+	return err == nil
+}
+
+// hasPendingSecret returns whether the token has a pending secret that has
+// not expired at now.
+func (e issuedTokenEntry) hasPendingSecret(now time.Time) bool {
+	return e.PendingSecretHash != nil && e.PendingSecretExpiresAt != nil && now.Before(*e.PendingSecretExpiresAt)
+}
+
+// newToken builds a token for the project with a random id and secret,
+// expiring ttl after now.
+func newToken(project, roleID string, ttl time.Duration, now time.Time, r io.Reader) (types.Token, error) {
+	if project == "" {
+		return types.Token{}, fmt.Errorf("%w: project is required", ErrInvalidTokenRequest)
+	}
+	if ttl <= 0 {
+		return types.Token{}, fmt.Errorf("%w: ttl must be positive", ErrInvalidTokenRequest)
+	}
+
+	id, err := uuid.NewRandomFromReader(r)
+	if err != nil {
+		return types.Token{}, fmt.Errorf("unable to generate token id: %w", err)
+	}
+
+	secret, err := newTokenSecret(r)
+	if err != nil {
+		return types.Token{}, err
+	}
+
+	now = now.UTC()
+	return types.Token{
+		CreatedAt:    now.Format(types.TokenTimeLayout),
+		ExpiresAt:    now.Add(ttl).Format(types.TokenTimeLayout),
+		ProjectID:    project,
+		ProjectToken: types.ProjectToken{ID: id.String()},
+		RoleID:       roleID,
+		Secret:       secret,
+	}, nil
+}
+
+// newTokenSecret returns a random token secret.
+func newTokenSecret(r io.Reader) (string, error) {
+	secret := make([]byte, tokenSecretBytes)
+	if _, err := io.ReadFull(r, secret); err != nil {
+		return "", fmt.Errorf("unable to generate token secret: %w", err)
+	}
+	return hex.EncodeToString(secret), nil
+}
+
+// hashTokenSecret returns the hash stored for an issued token secret. Secrets
+// are random, so a fast hash is sufficient.
+func hashTokenSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// matchesHash returns whether hash is the stored hash of secret.
+func matchesHash(hash *string, secret string) bool {
+	return hash != nil && subtle.ConstantTimeCompare([]byte(*hash), []byte(hashTokenSecret(secret))) == 1
+}
+
+// checkTokenSecret returns ErrInvalidTokenSecret unless secret is the
+// token's primary secret or its unexpired pending one, and ErrTokenExpired if
+// the token expired at or before now. ErrTokenSecretCorrupt is returned
+// instead of a mismatch when the stored hashes are corrupt.
+func checkTokenSecret(entry issuedTokenEntry, secret string, now time.Time) error {
+	if entry.secretCorrupt() {
+		return ErrTokenSecretCorrupt
+	}
+
+	primary := matchesHash(entry.SecretHash, secret)
+	pending := entry.hasPendingSecret(now) && matchesHash(entry.PendingSecretHash, secret)
+	if !primary && !pending {
+		return ErrInvalidTokenSecret
+	}
+
+	expired, err := isTokenExpired(entry.TokenEntry, now)
+	if err != nil {
+		return err
+	}
+	if expired {
+		return ErrTokenExpired
+	}
+	return nil
+}
+
+// IssueToken generates a new token for the project and persists it. The
+// returned token includes the secret. Only a hash of the secret is stored, so
+// it cannot be retrieved again but can be checked with VerifyTokenSecret.
+func (d SQLClient) IssueToken(ctx context.Context, project, roleID string, ttl time.Duration) (types.Token, error) {
+	ctx, cancel := d.withTimeout(ctx)
+	defer cancel()
+
+	token, err := newToken(d.projectID(project), roleID, ttl, d.now(), rand.Reader)
+	if err != nil {
+		return types.Token{}, err
+	}
+
+	sess, err := d.createSession()
+	if err != nil {
+		return types.Token{}, err
+	}
+
+	hash := hashTokenSecret(token.Secret)
+	entry := issuedTokenEntry{TokenEntry: newTokenEntry(token), SecretHash: &hash}
+
+	err = sess.WithContext(ctx).Tx(func(sess db.Session) error {
+		if _, err := sess.Collection(TokenEntryDB).Insert(entry); err != nil {
+			return err
+		}
+
+		_, err := sess.Collection(TokenHistoryDB).Insert(newTokenHistoryEntry(token))
+		return err
+	})
+	if err != nil {
+		return types.Token{}, err
+	}
+
+	return token, nil
+}
+
+// VerifyTokenSecret returns the token if secret is the one returned when it
+// was issued by IssueToken, or its unexpired pending secret. ErrTokenNotFound,
+// ErrInvalidTokenSecret or ErrTokenExpired is returned otherwise. Tokens whose
+// stored hash is missing, including those not issued by IssueToken, or
+// malformed fail with ErrTokenSecretCorrupt.
+func (d SQLClient) VerifyTokenSecret(ctx context.Context, token, secret string) (TokenEntry, error) {
+	ctx, cancel := d.withTimeout(ctx)
+	defer cancel()
+
+	sess, err := d.createSession()
+	if err != nil {
+		return TokenEntry{}, err
+	}
+
+	res, err := readIssuedTokenEntry(sess.WithContext(ctx), db.Cond{"token_id": token})
+	if err != nil {
+		return TokenEntry{}, err
+	}
+
+	if err := checkTokenSecret(res, secret, d.now()); err != nil {
+		return TokenEntry{}, err
+	}
+	return res.TokenEntry, nil
+}
+
+// readIssuedTokenEntry returns the token matching cond along with its secret
+// hashes, or ErrTokenNotFound.
+func readIssuedTokenEntry(sess db.Session, cond db.Cond) (issuedTokenEntry, error) {
+	res := issuedTokenEntry{}
+	err := sess.SQL().
+		Select(issuedTokenEntryColumns...).
+		From(TokenEntryDB).
+		Where(cond).
+		One(&res)
+	return res, notFound(err, ErrTokenNotFound)
+}
+
+// VerifyAndTouch verifies the secret as VerifyTokenSecret does and records
+// the time the token was used, in one transaction. A token that does not
+// belong to the project is reported as ErrTokenNotFound.
+func (d SQLClient) VerifyAndTouch(ctx context.Context, project, token, secret string) (TokenEntry, error) {
+	ctx, cancel := d.withTimeout(ctx)
+	defer cancel()
+
+	project = d.projectID(project)
+
+	sess, err := d.createSession()
+	if err != nil {
+		return TokenEntry{}, err
+	}
+
+	var res issuedTokenEntry
+	err = sess.WithContext(ctx).Tx(func(sess db.Session) error {
+		res, err = readIssuedTokenEntry(sess, db.Cond{"project": project, "token_id": token})
+		if err != nil {
+			return err
+		}
+
+		now := d.now()
+		if err := checkTokenSecret(res, secret, now); err != nil {
+			return err
+		}
+
+		_, err = sess.SQL().
+			Update(TokenEntryDB).
+			Set("last_used_at", now.UTC()).
+			Where(db.Cond{"token_id": token}).
+			Exec()
+		return err
+	})
+	if err != nil {
+		return TokenEntry{}, err
+	}
+
+	return res.TokenEntry, nil
+}
+
+// AddPendingSecret generates a new secret for a token issued by IssueToken
+// and returns it. Until ttl has passed, or the secret is promoted with
+// PromotePendingSecret, both it and the token's primary secret verify. Any
+// earlier pending secret is replaced.
+func (d SQLClient) AddPendingSecret(ctx context.Context, token string, ttl time.Duration) (string, error) {
+	ctx, cancel := d.withTimeout(ctx)
+	defer cancel()
+
+	if ttl <= 0 {
+		return "", fmt.Errorf("%w: ttl must be positive", ErrInvalidTokenRequest)
+	}
+
+	secret, err := newTokenSecret(rand.Reader)
+	if err != nil {
+		return "", err
+	}
+
+	sess, err := d.createSession()
+	if err != nil {
+		return "", err
+	}
+
+	err = sess.WithContext(ctx).Tx(func(sess db.Session) error {
+		res, err := readIssuedTokenEntry(sess, db.Cond{"token_id": token})
+		if err != nil {
+			return err
+		}
+		if res.SecretHash == nil {
+			return fmt.Errorf("%w: token '%s' was not issued with a secret", ErrInvalidTokenRequest, token)
+		}
+
+		_, err = sess.SQL().
+			Update(TokenEntryDB).
+			Set(
+				"pending_secret_hash", hashTokenSecret(secret),
+				"pending_secret_expires_at", d.now().Add(ttl).UTC(),
+			).
+			Where(db.Cond{"token_id": token}).
+			Exec()
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return secret, nil
+}
+
+// PromotePendingSecret makes the token's pending secret its primary secret,
+// retiring the previous one. ErrNoPendingSecret is returned if the token has
+// no pending secret or it has expired.
+func (d SQLClient) PromotePendingSecret(ctx context.Context, token string) error {
+	ctx, cancel := d.withTimeout(ctx)
+	defer cancel()
+
+	sess, err := d.createSession()
+	if err != nil {
+		return err
+	}
+
+	return sess.WithContext(ctx).Tx(func(sess db.Session) error {
+		res, err := readIssuedTokenEntry(sess, db.Cond{"token_id": token})
+		if err != nil {
+			return err
+		}
+		if !res.hasPendingSecret(d.now()) {
+			return fmt.Errorf("%w: '%s'", ErrNoPendingSecret, token)
+		}
+
+		_, err = sess.SQL().
+			Update(TokenEntryDB).
+			Set(
+				"secret_hash", *res.PendingSecretHash,
+				"pending_secret_hash", nil,
+				"pending_secret_expires_at", nil,
+			).
+			Where(db.Cond{"token_id": token}).
+			Exec()
+		return err
+	})
+}
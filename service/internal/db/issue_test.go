@@ -0,0 +1,420 @@
+package db
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql/driver"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/upper/db/v4"
+)
+
+func TestNewToken(t *testing.T) {
+	now := time.Date(2022, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		project string
+		ttl     time.Duration
+		wantErr error
+	}{
+		{
+			name:    "issues token",
+			project: "project1",
+			ttl:     time.Hour,
+		},
+		{
+			name:    "project is required",
+			ttl:     time.Hour,
+			wantErr: ErrInvalidTokenRequest,
+		},
+		{
+			name:    "ttl must be positive",
+			project: "project1",
+			wantErr: ErrInvalidTokenRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token, err := newToken(tt.project, "role-id", tt.ttl, now, rand.Reader)
+			if tt.wantErr != nil {
+				assert.True(t, errors.Is(err, tt.wantErr))
+				return
+			}
+
+			assert.Nil(t, err)
+			assert.Equal(t, tt.project, token.ProjectID)
+			assert.Equal(t, "role-id", token.RoleID)
+			assert.NotEmpty(t, token.ProjectToken.ID)
+			assert.Len(t, token.Secret, tokenSecretBytes*2)
+			assert.Equal(t, "2022-01-01T12:00:00Z", token.CreatedAt)
+			assert.Equal(t, "2022-01-01T13:00:00Z", token.ExpiresAt)
+
+			expired, err := isTokenExpired(TokenEntry{ExpiresAt: token.ExpiresAt}, now)
+			assert.Nil(t, err)
+			assert.False(t, expired)
+		})
+	}
+}
+
+func TestNewTokenUnique(t *testing.T) {
+	now := time.Now()
+
+	a, err := newToken("project1", "role-id", time.Hour, now, rand.Reader)
+	assert.Nil(t, err)
+	b, err := newToken("project1", "role-id", time.Hour, now, rand.Reader)
+	assert.Nil(t, err)
+
+	assert.NotEqual(t, a.ProjectToken.ID, b.ProjectToken.ID)
+	assert.NotEqual(t, a.Secret, b.Secret)
+}
+
+func TestNewTokenReaderError(t *testing.T) {
+	_, err := newToken("project1", "role-id", time.Hour, time.Now(), strings.NewReader(""))
+	assert.NotNil(t, err)
+}
+
+// captureArg is a sqlmock argument that matches any value and keeps it.
+type captureArg struct {
+	value *driver.Value
+}
+
+func (a captureArg) Match(v driver.Value) bool {
+	*a.value = v
+	return true
+}
+
+func TestIssueTokenStoresSecretHash(t *testing.T) {
+	c, mock := newMockSQLClient(t)
+
+	var hash driver.Value
+	mock.ExpectBegin()
+	expectPrimaryKey(mock, TokenEntryDB, "token_id")
+	mock.ExpectQuery(`INSERT INTO "tokens" \("created_at", "expires_at", "project", "role_id", "secret_hash", "token_id"\)`).
+		WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), "project1", "role-id", captureArg{&hash}, sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"token_id"}).AddRow("token1"))
+	expectPrimaryKey(mock, TokenHistoryDB, "id")
+	mock.ExpectQuery(`INSERT INTO "token_history"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectCommit()
+
+	token, err := c.IssueToken(context.Background(), "project1", "role-id", time.Hour)
+	assert.Nil(t, err)
+	assert.NotEmpty(t, token.Secret)
+	assert.Equal(t, hashTokenSecret(token.Secret), hash)
+}
+
+var issuedTokenColumns = []string{"secret_hash", "pending_secret_hash", "pending_secret_expires_at", "created_at", "expires_at", "project", "token_id", "role_id"}
+
+func expectReadIssuedTokenEntry(mock sqlmock.Sqlmock, rows *sqlmock.Rows) {
+	expectReadIssuedTokenEntryWhere(mock, `\("token_id" = \$1\)`, rows, "token1")
+}
+
+func expectReadIssuedTokenEntryWhere(mock sqlmock.Sqlmock, where string, rows *sqlmock.Rows, args ...driver.Value) {
+	mock.ExpectQuery(`SELECT "secret_hash", "pending_secret_hash", "pending_secret_expires_at", "created_at", "expires_at", "project", "token_id", "role_id" FROM "tokens" WHERE ` + where).
+		WithArgs(args...).
+		WillReturnRows(rows)
+}
+
+func TestVerifyTokenSecret(t *testing.T) {
+	now := time.Date(2022, 1, 1, 12, 0, 0, 0, time.UTC)
+	hash := hashTokenSecret("secret")
+
+	tests := []struct {
+		name    string
+		rows    *sqlmock.Rows
+		secret  string
+		want    TokenEntry
+		wantErr error
+	}{
+		{
+			name:   "valid secret",
+			secret: "secret",
+			rows: sqlmock.NewRows(issuedTokenColumns).
+				AddRow(hash, nil, nil, "2022-01-01T11:00:00Z", "2022-01-01T13:00:00Z", "project1", "token1", "role-id"),
+			want: TokenEntry{
+				CreatedAt: "2022-01-01T11:00:00Z",
+				ExpiresAt: "2022-01-01T13:00:00Z",
+				ProjectID: "project1",
+				TokenID:   "token1",
+				RoleID:    "role-id",
+			},
+		},
+		{
+			name:   "wrong secret",
+			secret: "other",
+			rows: sqlmock.NewRows(issuedTokenColumns).
+				AddRow(hash, nil, nil, "2022-01-01T11:00:00Z", "2022-01-01T13:00:00Z", "project1", "token1", "role-id"),
+			wantErr: ErrInvalidTokenSecret,
+		},
+		{
+			name:   "token not issued by IssueToken",
+			secret: "secret",
+			rows: sqlmock.NewRows(issuedTokenColumns).
+				AddRow(nil, nil, nil, "2022-01-01T11:00:00Z", "2022-01-01T13:00:00Z", "project1", "token1", "role-id"),
+			wantErr: ErrTokenSecretCorrupt,
+		},
+		{
+			name:   "malformed hash",
+			secret: "secret",
+			rows: sqlmock.NewRows(issuedTokenColumns).
+				AddRow("not-a-hash", nil, nil, "2022-01-01T11:00:00Z", "2022-01-01T13:00:00Z", "project1", "token1", "role-id"),
+			wantErr: ErrTokenSecretCorrupt,
+		},
+		{
+			name:   "pending secret",
+			secret: "pending",
+			rows: sqlmock.NewRows(issuedTokenColumns).
+				AddRow(hash, hashTokenSecret("pending"), now.Add(time.Minute), "2022-01-01T11:00:00Z", "2022-01-01T13:00:00Z", "project1", "token1", "role-id"),
+			want: TokenEntry{
+				CreatedAt: "2022-01-01T11:00:00Z",
+				ExpiresAt: "2022-01-01T13:00:00Z",
+				ProjectID: "project1",
+				TokenID:   "token1",
+				RoleID:    "role-id",
+			},
+		},
+		{
+			name:   "expired pending secret",
+			secret: "pending",
+			rows: sqlmock.NewRows(issuedTokenColumns).
+				AddRow(hash, hashTokenSecret("pending"), now, "2022-01-01T11:00:00Z", "2022-01-01T13:00:00Z", "project1", "token1", "role-id"),
+			wantErr: ErrInvalidTokenSecret,
+		},
+		{
+			name:   "expired token",
+			secret: "secret",
+			rows: sqlmock.NewRows(issuedTokenColumns).
+				AddRow(hash, nil, nil, "2022-01-01T10:00:00Z", "2022-01-01T12:00:00Z", "project1", "token1", "role-id"),
+			wantErr: ErrTokenExpired,
+		},
+		{
+			name:    "token not found",
+			secret:  "secret",
+			rows:    sqlmock.NewRows(issuedTokenColumns),
+			wantErr: ErrTokenNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, mock := newMockSQLClient(t)
+			c.now = func() time.Time { return now }
+
+			expectReadIssuedTokenEntry(mock, tt.rows)
+
+			got, err := c.VerifyTokenSecret(context.Background(), "token1", tt.secret)
+			if tt.wantErr != nil {
+				assert.True(t, errors.Is(err, tt.wantErr), err)
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestAddPendingSecret(t *testing.T) {
+	now := time.Date(2022, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("stores the pending secret hash", func(t *testing.T) {
+		c, mock := newMockSQLClient(t)
+		c.now = func() time.Time { return now }
+
+		var hash driver.Value
+		mock.ExpectBegin()
+		expectReadIssuedTokenEntry(mock, sqlmock.NewRows(issuedTokenColumns).
+			AddRow(hashTokenSecret("secret"), nil, nil, "2022-01-01T11:00:00Z", "2022-01-01T13:00:00Z", "project1", "token1", "role-id"))
+		mock.ExpectExec(`UPDATE "tokens" SET "pending_secret_hash" = \$1, "pending_secret_expires_at" = \$2 WHERE \("token_id" = \$3\)`).
+			WithArgs(captureArg{&hash}, now.Add(time.Hour), "token1").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		secret, err := c.AddPendingSecret(context.Background(), "token1", time.Hour)
+		assert.Nil(t, err)
+		assert.Len(t, secret, tokenSecretBytes*2)
+		assert.Equal(t, hashTokenSecret(secret), hash)
+	})
+
+	t.Run("token not issued with a secret", func(t *testing.T) {
+		c, mock := newMockSQLClient(t)
+
+		mock.ExpectBegin()
+		expectReadIssuedTokenEntry(mock, sqlmock.NewRows(issuedTokenColumns).
+			AddRow(nil, nil, nil, "2022-01-01T11:00:00Z", "2022-01-01T13:00:00Z", "project1", "token1", "role-id"))
+		mock.ExpectRollback()
+
+		_, err := c.AddPendingSecret(context.Background(), "token1", time.Hour)
+		assert.True(t, errors.Is(err, ErrInvalidTokenRequest), err)
+	})
+
+	t.Run("ttl must be positive", func(t *testing.T) {
+		c, err := NewSQLClient("localhost", "cello", "cello", "", nil)
+		assert.Nil(t, err)
+
+		opened := false
+		c.open = func(db.ConnectionURL) (db.Session, error) {
+			opened = true
+			return &fakeSession{}, nil
+		}
+
+		_, err = c.AddPendingSecret(context.Background(), "token1", 0)
+		assert.True(t, errors.Is(err, ErrInvalidTokenRequest), err)
+		assert.False(t, opened, "store must not be touched")
+	})
+}
+
+func TestPromotePendingSecret(t *testing.T) {
+	now := time.Date(2022, 1, 1, 12, 0, 0, 0, time.UTC)
+	pending := hashTokenSecret("pending")
+
+	t.Run("replaces the primary secret", func(t *testing.T) {
+		c, mock := newMockSQLClient(t)
+		c.now = func() time.Time { return now }
+
+		mock.ExpectBegin()
+		expectReadIssuedTokenEntry(mock, sqlmock.NewRows(issuedTokenColumns).
+			AddRow(hashTokenSecret("secret"), pending, now.Add(time.Minute), "2022-01-01T11:00:00Z", "2022-01-01T13:00:00Z", "project1", "token1", "role-id"))
+		mock.ExpectExec(`UPDATE "tokens" SET "secret_hash" = \$1, "pending_secret_hash" = NULL, "pending_secret_expires_at" = NULL WHERE \("token_id" = \$2\)`).
+			WithArgs(pending, "token1").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		assert.Nil(t, c.PromotePendingSecret(context.Background(), "token1"))
+	})
+
+	t.Run("pending secret expired", func(t *testing.T) {
+		c, mock := newMockSQLClient(t)
+		c.now = func() time.Time { return now }
+
+		mock.ExpectBegin()
+		expectReadIssuedTokenEntry(mock, sqlmock.NewRows(issuedTokenColumns).
+			AddRow(hashTokenSecret("secret"), pending, now, "2022-01-01T11:00:00Z", "2022-01-01T13:00:00Z", "project1", "token1", "role-id"))
+		mock.ExpectRollback()
+
+		assert.True(t, errors.Is(c.PromotePendingSecret(context.Background(), "token1"), ErrNoPendingSecret))
+	})
+
+	t.Run("token not found", func(t *testing.T) {
+		c, mock := newMockSQLClient(t)
+
+		mock.ExpectBegin()
+		expectReadIssuedTokenEntry(mock, sqlmock.NewRows(issuedTokenColumns))
+		mock.ExpectRollback()
+
+		assert.True(t, errors.Is(c.PromotePendingSecret(context.Background(), "token1"), ErrTokenNotFound))
+	})
+}
+
+func TestVerifyAndTouch(t *testing.T) {
+	now := time.Date(2022, 1, 1, 12, 0, 0, 0, time.UTC)
+	hash := hashTokenSecret("secret")
+	where := `\("project" = \$1 AND "token_id" = \$2\)`
+
+	t.Run("records the last use", func(t *testing.T) {
+		c, mock := newMockSQLClient(t)
+		c.now = func() time.Time { return now }
+
+		mock.ExpectBegin()
+		expectReadIssuedTokenEntryWhere(mock, where, sqlmock.NewRows(issuedTokenColumns).
+			AddRow(hash, nil, nil, "2022-01-01T11:00:00Z", "2022-01-01T13:00:00Z", "project1", "token1", "role-id"),
+			"project1", "token1")
+		mock.ExpectExec(`UPDATE "tokens" SET "last_used_at" = \$1 WHERE \("token_id" = \$2\)`).
+			WithArgs(now, "token1").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		got, err := c.VerifyAndTouch(context.Background(), "project1", "token1", "secret")
+		assert.Nil(t, err)
+		assert.Equal(t, TokenEntry{
+			CreatedAt: "2022-01-01T11:00:00Z",
+			ExpiresAt: "2022-01-01T13:00:00Z",
+			ProjectID: "project1",
+			TokenID:   "token1",
+			RoleID:    "role-id",
+		}, got)
+	})
+
+	tests := []struct {
+		name    string
+		rows    *sqlmock.Rows
+		secret  string
+		wantErr error
+	}{
+		{
+			name:   "wrong secret",
+			secret: "other",
+			rows: sqlmock.NewRows(issuedTokenColumns).
+				AddRow(hash, nil, nil, "2022-01-01T11:00:00Z", "2022-01-01T13:00:00Z", "project1", "token1", "role-id"),
+			wantErr: ErrInvalidTokenSecret,
+		},
+		{
+			name:   "expired token",
+			secret: "secret",
+			rows: sqlmock.NewRows(issuedTokenColumns).
+				AddRow(hash, nil, nil, "2022-01-01T10:00:00Z", "2022-01-01T12:00:00Z", "project1", "token1", "role-id"),
+			wantErr: ErrTokenExpired,
+		},
+		{
+			name:    "token not found in project",
+			secret:  "secret",
+			rows:    sqlmock.NewRows(issuedTokenColumns),
+			wantErr: ErrTokenNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, mock := newMockSQLClient(t)
+			c.now = func() time.Time { return now }
+
+			mock.ExpectBegin()
+			expectReadIssuedTokenEntryWhere(mock, where, tt.rows, "project1", "token1")
+			mock.ExpectRollback()
+
+			_, err := c.VerifyAndTouch(context.Background(), "project1", "token1", tt.secret)
+			assert.True(t, errors.Is(err, tt.wantErr), err)
+		})
+	}
+}
+
+func TestReadTokenEntrySecretCorrupt(t *testing.T) {
+	tests := []struct {
+		name string
+		hash interface{}
+		want bool
+	}{
+		{name: "valid hash", hash: hashTokenSecret("secret")},
+		{name: "missing hash", hash: nil, want: true},
+		{name: "malformed hash", hash: "not-a-hash", want: true},
+		{name: "truncated hash", hash: hashTokenSecret("secret")[:32], want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, mock := newMockSQLClient(t)
+			expectPrimaryKey(mock, TokenEntryDB, "token_id")
+			mock.ExpectQuery(`SELECT \* FROM "tokens" WHERE \("token_id" = \$1\)`).
+				WithArgs("token1").
+				WillReturnRows(sqlmock.NewRows(issuedTokenColumns).
+					AddRow(tt.hash, nil, nil, "2022-01-01T11:00:00Z", "2022-01-01T13:00:00Z", "project1", "token1", "role-id"))
+
+			got, err := c.ReadTokenEntry(context.Background(), "token1")
+			assert.Nil(t, err)
+			assert.Equal(t, TokenEntry{
+				CreatedAt:     "2022-01-01T11:00:00Z",
+				ExpiresAt:     "2022-01-01T13:00:00Z",
+				ProjectID:     "project1",
+				TokenID:       "token1",
+				RoleID:        "role-id",
+				SecretCorrupt: tt.want,
+			}, got)
+		})
+	}
+}
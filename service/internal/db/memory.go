@@ -0,0 +1,928 @@
+package db
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cello-proj/cello/internal/types"
+)
+
+var _ Client = (*InMemoryClient)(nil)
+
+// InMemoryOption is a function for configuring the InMemoryClient.
+type InMemoryOption func(*InMemoryClient)
+
+// WithInMemoryClock sets the clock used for deletion times and retention
+// windows. Defaults to time.Now.
+func WithInMemoryClock(now func() time.Time) InMemoryOption {
+	return func(c *InMemoryClient) {
+		c.now = now
+	}
+}
+
+// InMemoryClient is a Client storing everything in memory, for tests of code
+// that depends on a Client. It follows the semantics of SQLClient: deleted
+// projects are hidden until they are purged along with their tokens, token
+// lists are newest first, and tokens require their project to exist. It is
+// safe for concurrent use.
+type InMemoryClient struct {
+	now                func() time.Time
+	projectRetention   time.Duration
+	tombstoneRetention time.Duration
+
+	mu         sync.Mutex
+	projects   map[string]memoryProject
+	tokens     map[string]TokenEntry
+	hashes     map[string]string
+	pending    map[string]memoryPendingSecret
+	tombstones map[string]TokenTombstone
+	history    []TokenHistoryEntry
+	sequences  map[string]int64
+}
+
+// memoryProject is a stored project. DeletedAt is nil while the project is
+// live.
+type memoryProject struct {
+	entry     ProjectEntry
+	deletedAt *time.Time
+}
+
+// memoryPendingSecret is the hash of a token's pending secret and when it
+// expires.
+type memoryPendingSecret struct {
+	hash      string
+	expiresAt time.Time
+}
+
+// NewInMemoryClient returns an empty InMemoryClient.
+func NewInMemoryClient(opts ...InMemoryOption) *InMemoryClient {
+	c := &InMemoryClient{
+		now:                time.Now,
+		projectRetention:   defaultProjectRetention,
+		tombstoneRetention: defaultTombstoneRetention,
+		projects:           map[string]memoryProject{},
+		tokens:             map[string]TokenEntry{},
+		hashes:             map[string]string{},
+		pending:            map[string]memoryPendingSecret{},
+		tombstones:         map[string]TokenTombstone{},
+		sequences:          map[string]int64{},
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
+}
+
+// tokenTime parses a stored token timestamp. Stored timestamps are validated
+// on write so they always parse.
+func tokenTime(s string) time.Time {
+	t, _ := parseTokenTime(s)
+	return t
+}
+
+// newestFirst orders tokens by creation time, newest first, with the token id
+// breaking ties as the (created_at, token_id) pagination key does.
+func newestFirst(entries []TokenEntry) {
+	sort.Slice(entries, func(i, j int) bool {
+		return tokenBefore(entries[j], tokenKey{CreatedAt: entries[i].CreatedAt, TokenID: entries[i].TokenID})
+	})
+}
+
+// tokenBefore returns whether the token sorts before the key in ascending
+// (created_at, token_id) order.
+func tokenBefore(t TokenEntry, k tokenKey) bool {
+	created, keyCreated := tokenTime(t.CreatedAt), tokenTime(k.CreatedAt)
+	if !created.Equal(keyCreated) {
+		return created.Before(keyCreated)
+	}
+	return t.TokenID < k.TokenID
+}
+
+// liveProject returns the project if it exists and has not been deleted.
+// The lock must be held.
+func (c *InMemoryClient) liveProject(project string) (ProjectEntry, bool) {
+	p, ok := c.projects[project]
+	if !ok || p.deletedAt != nil {
+		return ProjectEntry{}, false
+	}
+	return p.entry, true
+}
+
+// liveProjects returns the live projects ordered by project id. The lock must
+// be held.
+func (c *InMemoryClient) liveProjects() []ProjectEntry {
+	res := []ProjectEntry{}
+	for _, p := range c.projects {
+		if p.deletedAt == nil {
+			res = append(res, p.entry)
+		}
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].ProjectID < res[j].ProjectID })
+	return res
+}
+
+// filterTokens returns the tokens matching keep, newest first. The lock must
+// be held.
+func (c *InMemoryClient) filterTokens(keep func(TokenEntry) bool) []TokenEntry {
+	res := []TokenEntry{}
+	for _, t := range c.tokens {
+		if keep(t) {
+			res = append(res, t)
+		}
+	}
+	newestFirst(res)
+	return res
+}
+
+// projectTokens returns the project's tokens, newest first. The lock must be
+// held.
+func (c *InMemoryClient) projectTokens(project string) []TokenEntry {
+	return c.filterTokens(func(t TokenEntry) bool { return t.ProjectID == project })
+}
+
+// deleteTokens deletes the tokens, recording a tombstone and marking the
+// history of each. The lock must be held.
+func (c *InMemoryClient) deleteTokens(tokens []TokenEntry) {
+	deletedAt := c.now()
+	for _, t := range tokens {
+		if _, ok := c.tombstones[t.TokenID]; !ok {
+			c.tombstones[t.TokenID] = TokenTombstone{DeletedAt: deletedAt, ProjectID: t.ProjectID, TokenID: t.TokenID}
+		}
+		for i, h := range c.history {
+			if h.TokenID == t.TokenID && h.DeletedAt == nil {
+				c.history[i].DeletedAt = &deletedAt
+			}
+		}
+		delete(c.tokens, t.TokenID)
+		delete(c.hashes, t.TokenID)
+		delete(c.pending, t.TokenID)
+	}
+}
+
+// purgeProject permanently deletes the project along with its tokens and
+// token sequence. The lock must be held.
+func (c *InMemoryClient) purgeProject(project string) {
+	c.deleteTokens(c.projectTokens(project))
+	delete(c.sequences, project)
+	delete(c.projects, project)
+}
+
+// createProject inserts the project, purging a deleted project with the same
+// id first. The lock must be held.
+func (c *InMemoryClient) createProject(pe ProjectEntry) error {
+	if p, ok := c.projects[pe.ProjectID]; ok {
+		if p.deletedAt == nil {
+			return fmt.Errorf("%w: '%s'", ErrProjectExists, pe.ProjectID)
+		}
+		c.purgeProject(pe.ProjectID)
+	}
+
+	c.projects[pe.ProjectID] = memoryProject{entry: pe}
+	return nil
+}
+
+// checkTokens returns an error if any token duplicates a stored or earlier
+// token, or belongs to a project that does not exist. Deleted projects exist
+// until they are purged. The lock must be held.
+func (c *InMemoryClient) checkTokens(tokens []types.Token) error {
+	for _, t := range tokens {
+		if _, ok := c.projects[t.ProjectID]; !ok {
+			return fmt.Errorf("%w: '%s'", ErrProjectNotFound, t.ProjectID)
+		}
+	}
+	return c.checkTokenIDs(tokens)
+}
+
+// checkTokenIDs returns an error if any token duplicates a stored or earlier
+// token. The lock must be held.
+func (c *InMemoryClient) checkTokenIDs(tokens []types.Token) error {
+	seen := map[string]bool{}
+	for _, t := range tokens {
+		if _, ok := c.tokens[t.ProjectToken.ID]; ok || seen[t.ProjectToken.ID] {
+			return fmt.Errorf("token '%s' already exists", t.ProjectToken.ID)
+		}
+		seen[t.ProjectToken.ID] = true
+	}
+	return nil
+}
+
+// createTokens inserts the tokens and their history. They must have been
+// checked. The lock must be held.
+func (c *InMemoryClient) createTokens(tokens []types.Token) {
+	for _, t := range tokens {
+		c.tokens[t.ProjectToken.ID] = newTokenEntry(t)
+		c.history = append(c.history, newTokenHistoryEntry(t))
+	}
+}
+
+// CreateProjectEntry implements Client.
+func (c *InMemoryClient) CreateProjectEntry(ctx context.Context, pe ProjectEntry) error {
+	if err := pe.Validate(); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.createProject(pe)
+}
+
+// CreateProjectWithToken implements Client.
+func (c *InMemoryClient) CreateProjectWithToken(ctx context.Context, pe ProjectEntry, token types.Token) error {
+	if token.ProjectID != pe.ProjectID {
+		return fmt.Errorf("token project '%s' does not match project '%s'", token.ProjectID, pe.ProjectID)
+	}
+
+	if err := pe.Validate(); err != nil {
+		return err
+	}
+
+	if err := validateTokenTimes(token); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.tokens[token.ProjectToken.ID]; ok {
+		return fmt.Errorf("token '%s' already exists", token.ProjectToken.ID)
+	}
+
+	if err := c.createProject(pe); err != nil {
+		return err
+	}
+
+	c.createTokens([]types.Token{token})
+	return nil
+}
+
+// ImportProject implements Client.
+func (c *InMemoryClient) ImportProject(ctx context.Context, data []byte) error {
+	pe, tokens, err := decodeProjectExport(data)
+	if err != nil {
+		return err
+	}
+
+	if err := pe.Validate(); err != nil {
+		return err
+	}
+
+	for i, token := range tokens {
+		if err := validateTokenTimes(token); err != nil {
+			return fmt.Errorf("token %d: %w", i, err)
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := c.checkTokenIDs(tokens); err != nil {
+		return err
+	}
+
+	if err := c.createProject(pe); err != nil {
+		return err
+	}
+
+	c.createTokens(tokens)
+	return nil
+}
+
+// DeleteProjectEntry implements Client.
+func (c *InMemoryClient) DeleteProjectEntry(ctx context.Context, project string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if p, ok := c.projects[project]; ok && p.deletedAt == nil {
+		now := c.now()
+		p.deletedAt = &now
+		c.projects[project] = p
+	}
+	return nil
+}
+
+// RestoreProjectEntry implements Client.
+func (c *InMemoryClient) RestoreProjectEntry(ctx context.Context, project string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	p, ok := c.projects[project]
+	if !ok || p.deletedAt == nil || !p.deletedAt.After(c.now().Add(-c.projectRetention)) {
+		return ErrProjectNotFound
+	}
+
+	p.deletedAt = nil
+	c.projects[project] = p
+	return nil
+}
+
+// PurgeDeletedProjects implements Client.
+func (c *InMemoryClient) PurgeDeletedProjects(ctx context.Context, now time.Time) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	cutoff := now.Add(-c.projectRetention)
+
+	purged := 0
+	for id, p := range c.projects {
+		if p.deletedAt != nil && p.deletedAt.Before(cutoff) {
+			c.purgeProject(id)
+			purged++
+		}
+	}
+	return purged, nil
+}
+
+// ReadProjectEntry implements Client.
+func (c *InMemoryClient) ReadProjectEntry(ctx context.Context, project string) (ProjectEntry, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	pe, ok := c.liveProject(project)
+	if !ok {
+		return ProjectEntry{}, ErrProjectNotFound
+	}
+	return pe, nil
+}
+
+// ReadProjectEntryWithETag implements Client.
+func (c *InMemoryClient) ReadProjectEntryWithETag(ctx context.Context, project string) (ProjectEntry, string, error) {
+	pe, err := c.ReadProjectEntry(ctx, project)
+	if err != nil {
+		return pe, "", err
+	}
+	return pe, pe.ETag(), nil
+}
+
+// UpdateProjectEntry implements Client.
+func (c *InMemoryClient) UpdateProjectEntry(ctx context.Context, pe ProjectEntry) error {
+	if err := pe.Validate(); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stored, ok := c.liveProject(pe.ProjectID)
+	if !ok {
+		return ErrProjectNotFound
+	}
+
+	stored.Repository = pe.Repository
+	stored.Quota = pe.Quota
+	c.projects[pe.ProjectID] = memoryProject{entry: stored}
+	return nil
+}
+
+// UpdateProjectEntryIfMatch implements Client.
+func (c *InMemoryClient) UpdateProjectEntryIfMatch(ctx context.Context, pe ProjectEntry, etag string) error {
+	if err := pe.Validate(); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stored, ok := c.liveProject(pe.ProjectID)
+	if !ok {
+		return ErrProjectNotFound
+	}
+
+	if err := checkETag(stored, etag); err != nil {
+		return err
+	}
+
+	c.projects[pe.ProjectID] = memoryProject{entry: pe}
+	return nil
+}
+
+// FindDuplicateRepositories implements Client.
+func (c *InMemoryClient) FindDuplicateRepositories(ctx context.Context) (map[string][]string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return duplicateRepositories(c.liveProjects()), nil
+}
+
+// ListProjectEntries implements Client.
+func (c *InMemoryClient) ListProjectEntries(ctx context.Context, opts ListOptions) ([]ProjectEntry, string, error) {
+	after, err := decodeKeyCursor(opts.Cursor)
+	if err != nil {
+		return nil, "", err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	res := []ProjectEntry{}
+	for _, pe := range c.liveProjects() {
+		if pe.ProjectID > after && len(res) <= opts.pageSize() {
+			res = append(res, pe)
+		}
+	}
+
+	entries, next := projectPage(res, opts.pageSize())
+	return entries, next, nil
+}
+
+// ListProjectsByRepository implements Client.
+func (c *InMemoryClient) ListProjectsByRepository(ctx context.Context, repository string) ([]ProjectEntry, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	variants := map[string]bool{}
+	for _, v := range repositoryVariants(repository) {
+		variants[v] = true
+	}
+
+	res := []ProjectEntry{}
+	for _, pe := range c.liveProjects() {
+		if variants[strings.ToLower(pe.Repository)] {
+			res = append(res, pe)
+		}
+	}
+	return res, nil
+}
+
+// StreamProjectEntries implements Client. The projects are those live when it
+// is called.
+func (c *InMemoryClient) StreamProjectEntries(ctx context.Context) (<-chan ProjectEntry, <-chan error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return streamProjectEntries(ctx, &sliceIterator{entries: c.liveProjects()})
+}
+
+// sliceIterator is a rowIterator over a slice of projects.
+type sliceIterator struct {
+	entries []ProjectEntry
+}
+
+func (it *sliceIterator) Next(ptr interface{}) bool {
+	if len(it.entries) == 0 {
+		return false
+	}
+	*ptr.(*ProjectEntry) = it.entries[0]
+	it.entries = it.entries[1:]
+	return true
+}
+
+func (it *sliceIterator) Err() error {
+	return nil
+}
+
+func (it *sliceIterator) Close() error {
+	return nil
+}
+
+// ReadProjectActivity implements Client.
+func (c *InMemoryClient) ReadProjectActivity(ctx context.Context, project string) (ProjectEntry, time.Time, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	pe, ok := c.liveProject(project)
+	if !ok {
+		return ProjectEntry{}, time.Time{}, ErrProjectNotFound
+	}
+
+	tokens := c.projectTokens(project)
+	if len(tokens) == 0 {
+		return pe, time.Time{}, nil
+	}
+	return pe, tokenTime(tokens[0].CreatedAt), nil
+}
+
+// ReadProjectsWithTokenCounts implements Client.
+func (c *InMemoryClient) ReadProjectsWithTokenCounts(ctx context.Context, ids []string) ([]ProjectSummary, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	wanted := map[string]bool{}
+	for _, id := range ids {
+		wanted[id] = true
+	}
+
+	res := []ProjectSummary{}
+	for _, pe := range c.liveProjects() {
+		if wanted[pe.ProjectID] {
+			res = append(res, ProjectSummary{ProjectEntry: pe, TokenCount: len(c.projectTokens(pe.ProjectID))})
+		}
+	}
+	return res, nil
+}
+
+// CreateTokenEntry implements Client.
+func (c *InMemoryClient) CreateTokenEntry(ctx context.Context, token types.Token) error {
+	if err := validateTokenTimes(token); err != nil {
+		return err
+	}
+
+	return c.createTokenEntries([]types.Token{token})
+}
+
+// CreateTokenEntries implements Client.
+func (c *InMemoryClient) CreateTokenEntries(ctx context.Context, tokens []types.Token) error {
+	for i, token := range tokens {
+		if err := validateTokenTimes(token); err != nil {
+			return fmt.Errorf("token %d: %w", i, err)
+		}
+	}
+
+	return c.createTokenEntries(tokens)
+}
+
+// createTokenEntries creates the validated tokens if none conflict.
+func (c *InMemoryClient) createTokenEntries(tokens []types.Token) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := c.checkTokens(tokens); err != nil {
+		return err
+	}
+
+	c.createTokens(tokens)
+	return nil
+}
+
+// IssueToken implements Client.
+func (c *InMemoryClient) IssueToken(ctx context.Context, project, roleID string, ttl time.Duration) (types.Token, error) {
+	token, err := newToken(project, roleID, ttl, c.now(), rand.Reader)
+	if err != nil {
+		return types.Token{}, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	tokens := []types.Token{token}
+	if err := c.checkTokens(tokens); err != nil {
+		return types.Token{}, err
+	}
+
+	c.createTokens(tokens)
+	c.hashes[token.ProjectToken.ID] = hashTokenSecret(token.Secret)
+	return token, nil
+}
+
+// VerifyTokenSecret implements Client.
+func (c *InMemoryClient) VerifyTokenSecret(ctx context.Context, token, secret string) (TokenEntry, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.issuedEntry(token)
+	if !ok {
+		return TokenEntry{}, ErrTokenNotFound
+	}
+
+	if err := checkTokenSecret(entry, secret, c.now()); err != nil {
+		return TokenEntry{}, err
+	}
+	return entry.TokenEntry, nil
+}
+
+// VerifyAndTouch implements Client. Last use times are not observable
+// through Client, so they are not recorded.
+func (c *InMemoryClient) VerifyAndTouch(ctx context.Context, project, token, secret string) (TokenEntry, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.issuedEntry(token)
+	if !ok || entry.ProjectID != project {
+		return TokenEntry{}, ErrTokenNotFound
+	}
+
+	if err := checkTokenSecret(entry, secret, c.now()); err != nil {
+		return TokenEntry{}, err
+	}
+	return entry.TokenEntry, nil
+}
+
+// issuedEntry returns the token along with its secret hashes. The lock must
+// be held.
+func (c *InMemoryClient) issuedEntry(token string) (issuedTokenEntry, bool) {
+	entry, ok := c.tokens[token]
+	if !ok {
+		return issuedTokenEntry{}, false
+	}
+
+	res := issuedTokenEntry{TokenEntry: entry}
+	if h, ok := c.hashes[token]; ok {
+		res.SecretHash = &h
+	}
+	if p, ok := c.pending[token]; ok {
+		res.PendingSecretHash = &p.hash
+		res.PendingSecretExpiresAt = &p.expiresAt
+	}
+	return res, true
+}
+
+// AddPendingSecret implements Client.
+func (c *InMemoryClient) AddPendingSecret(ctx context.Context, token string, ttl time.Duration) (string, error) {
+	if ttl <= 0 {
+		return "", fmt.Errorf("%w: ttl must be positive", ErrInvalidTokenRequest)
+	}
+
+	secret, err := newTokenSecret(rand.Reader)
+	if err != nil {
+		return "", err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.issuedEntry(token)
+	if !ok {
+		return "", ErrTokenNotFound
+	}
+	if entry.SecretHash == nil {
+		return "", fmt.Errorf("%w: token '%s' was not issued with a secret", ErrInvalidTokenRequest, token)
+	}
+
+	c.pending[token] = memoryPendingSecret{hash: hashTokenSecret(secret), expiresAt: c.now().Add(ttl)}
+	return secret, nil
+}
+
+// PromotePendingSecret implements Client.
+func (c *InMemoryClient) PromotePendingSecret(ctx context.Context, token string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.issuedEntry(token)
+	if !ok {
+		return ErrTokenNotFound
+	}
+	if !entry.hasPendingSecret(c.now()) {
+		return fmt.Errorf("%w: '%s'", ErrNoPendingSecret, token)
+	}
+
+	c.hashes[token] = *entry.PendingSecretHash
+	delete(c.pending, token)
+	return nil
+}
+
+// DeleteTokenEntry implements Client.
+func (c *InMemoryClient) DeleteTokenEntry(ctx context.Context, token string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if t, ok := c.tokens[token]; ok {
+		c.deleteTokens([]TokenEntry{t})
+	}
+	return nil
+}
+
+// DeleteExpiredTokens implements Client.
+func (c *InMemoryClient) DeleteExpiredTokens(ctx context.Context, project string, now time.Time) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	expired := c.filterTokens(func(t TokenEntry) bool {
+		return t.ProjectID == project && tokenTime(t.ExpiresAt).Before(now)
+	})
+	c.deleteTokens(expired)
+	return len(expired), nil
+}
+
+// NextTokenSequence implements Client.
+func (c *InMemoryClient) NextTokenSequence(ctx context.Context, project string) (int64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.projects[project]; !ok {
+		return 0, fmt.Errorf("%w: '%s'", ErrProjectNotFound, project)
+	}
+
+	c.sequences[project]++
+	return c.sequences[project], nil
+}
+
+// ReadTokenEntry implements Client.
+func (c *InMemoryClient) ReadTokenEntry(ctx context.Context, token string) (TokenEntry, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.issuedEntry(token)
+	if !ok {
+		return TokenEntry{}, ErrTokenNotFound
+	}
+
+	t := entry.TokenEntry
+	t.SecretCorrupt = entry.secretCorrupt()
+	return t, nil
+}
+
+// ReadTokenEntryScoped implements Client.
+func (c *InMemoryClient) ReadTokenEntryScoped(ctx context.Context, project, token string) (TokenEntry, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	t, ok := c.tokens[token]
+	if !ok || t.ProjectID != project {
+		return TokenEntry{}, ErrTokenNotFound
+	}
+	return t, nil
+}
+
+// CountTokenEntries implements Client.
+func (c *InMemoryClient) CountTokenEntries(ctx context.Context, project string) (int, error) {
+	return c.PreviewAffectedTokenCount(ctx, project, AllTokens())
+}
+
+// PreviewAffectedTokenCount implements Client.
+func (c *InMemoryClient) PreviewAffectedTokenCount(ctx context.Context, project string, predicate Predicate) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	n := 0
+	for _, t := range c.tokens {
+		if t.ProjectID != project {
+			continue
+		}
+		if !predicate.ExpiredBefore.IsZero() && !tokenTime(t.ExpiresAt).Before(predicate.ExpiredBefore) {
+			continue
+		}
+		n++
+	}
+	return n, nil
+}
+
+// ListTokenEntries implements Client.
+func (c *InMemoryClient) ListTokenEntries(ctx context.Context, project string) ([]TokenEntry, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.projectTokens(project), nil
+}
+
+// ListTokenEntriesCreatedBetween implements Client.
+func (c *InMemoryClient) ListTokenEntriesCreatedBetween(ctx context.Context, project string, start, end time.Time) ([]TokenEntry, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.filterTokens(func(t TokenEntry) bool {
+		created := tokenTime(t.CreatedAt)
+		return t.ProjectID == project && !created.Before(start) && !created.After(end)
+	}), nil
+}
+
+// ListTokenEntriesWithTTL implements Client.
+func (c *InMemoryClient) ListTokenEntriesWithTTL(ctx context.Context, project string, now time.Time) ([]TokenWithTTL, error) {
+	entries, err := c.ListTokenEntries(ctx, project)
+	if err != nil {
+		return nil, err
+	}
+	return withTTL(entries, now)
+}
+
+// ListTokenEntriesByUrgency implements Client.
+func (c *InMemoryClient) ListTokenEntriesByUrgency(ctx context.Context, project string, now time.Time) ([]TokenWithTTL, error) {
+	tokens, err := c.ListTokenEntriesWithTTL(ctx, project, now)
+	if err != nil {
+		return nil, err
+	}
+	return byUrgency(tokens), nil
+}
+
+// ListTokenEntriesPaged implements Client.
+func (c *InMemoryClient) ListTokenEntriesPaged(ctx context.Context, project string, limit int, cursor string) (ListTokenEntriesResult, error) {
+	limit = ListOptions{PageSize: limit}.pageSize()
+
+	after, ok, err := decodeTokenCursor(cursor)
+	if err != nil {
+		return ListTokenEntriesResult{}, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	tokens := c.projectTokens(project)
+
+	entries := []TokenEntry{}
+	for _, t := range tokens {
+		if ok && !tokenBefore(t, after) {
+			continue
+		}
+		if len(entries) > limit {
+			break
+		}
+		entries = append(entries, t)
+	}
+
+	return tokenPage(entries, limit, len(tokens)), nil
+}
+
+// ListExpiredTokenEntriesGlobal implements Client.
+func (c *InMemoryClient) ListExpiredTokenEntriesGlobal(ctx context.Context, now time.Time, limit int) ([]TokenEntry, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	res := c.filterTokens(func(t TokenEntry) bool { return tokenTime(t.ExpiresAt).Before(now) })
+	sort.SliceStable(res, func(i, j int) bool { return tokenTime(res[i].ExpiresAt).Before(tokenTime(res[j].ExpiresAt)) })
+	if limit > 0 && len(res) > limit {
+		res = res[:limit]
+	}
+	return res, nil
+}
+
+// ListTokenChanges implements Client.
+func (c *InMemoryClient) ListTokenChanges(ctx context.Context, project, syncToken string) (TokenChangeSet, error) {
+	now := c.now()
+
+	since, err := decodeSyncToken(syncToken, now, c.tombstoneRetention)
+	if err != nil {
+		return TokenChangeSet{}, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	inRange := func(t time.Time) bool { return t.After(since) && !t.After(now) }
+
+	res := TokenChangeSet{
+		Created: c.filterTokens(func(t TokenEntry) bool {
+			return t.ProjectID == project && inRange(tokenTime(t.CreatedAt))
+		}),
+		Deleted:       []string{},
+		NextSyncToken: encodeSyncToken(now),
+	}
+
+	tombstones := []TokenTombstone{}
+	for _, t := range c.tombstones {
+		if t.ProjectID == project && inRange(t.DeletedAt) {
+			tombstones = append(tombstones, t)
+		}
+	}
+	sort.Slice(tombstones, func(i, j int) bool { return tombstones[i].DeletedAt.Before(tombstones[j].DeletedAt) })
+
+	for _, t := range tombstones {
+		res.Deleted = append(res.Deleted, t.TokenID)
+	}
+	return res, nil
+}
+
+// ListTokenHistory implements Client.
+func (c *InMemoryClient) ListTokenHistory(ctx context.Context, project string) ([]TokenHistoryEntry, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	res := []TokenHistoryEntry{}
+	for _, h := range c.history {
+		if h.ProjectID == project {
+			res = append(res, h)
+		}
+	}
+	sort.SliceStable(res, func(i, j int) bool { return tokenTime(res[i].CreatedAt).After(tokenTime(res[j].CreatedAt)) })
+	return res, nil
+}
+
+// PurgeTokenTombstones implements Client.
+func (c *InMemoryClient) PurgeTokenTombstones(ctx context.Context, before time.Time) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	purged := 0
+	for id, t := range c.tombstones {
+		if t.DeletedAt.Before(before) {
+			delete(c.tombstones, id)
+			purged++
+		}
+	}
+	return purged, nil
+}
+
+// SystemStats implements Client.
+func (c *InMemoryClient) SystemStats(ctx context.Context, now time.Time) (SystemStats, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	res := SystemStats{TotalProjects: int64(len(c.liveProjects()))}
+
+	for _, t := range c.tokens {
+		if _, ok := c.liveProject(t.ProjectID); !ok {
+			continue
+		}
+		res.TotalTokens++
+
+		expiresAt := tokenTime(t.ExpiresAt)
+		if expiresAt.Before(now) {
+			res.ExpiredTokens++
+			continue
+		}
+		if res.NextExpiry.IsZero() || expiresAt.Before(res.NextExpiry) {
+			res.NextExpiry = expiresAt
+		}
+	}
+	return res, nil
+}
+
+// Health implements Client.
+func (c *InMemoryClient) Health(ctx context.Context) error {
+	return nil
+}
@@ -0,0 +1,334 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cello-proj/cello/internal/types"
+	"github.com/stretchr/testify/assert"
+)
+
+const testRepository = "git@github.com:myorg/myrepo.git"
+
+func testToken(project, id string, createdAt time.Time) types.Token {
+	return types.Token{
+		CreatedAt:    createdAt.UTC().Format(types.TokenTimeLayout),
+		ExpiresAt:    createdAt.Add(time.Hour).UTC().Format(types.TokenTimeLayout),
+		ProjectID:    project,
+		ProjectToken: types.ProjectToken{ID: id},
+	}
+}
+
+func tokenIDs(entries []TokenEntry) []string {
+	res := []string{}
+	for _, e := range entries {
+		res = append(res, e.TokenID)
+	}
+	return res
+}
+
+func TestInMemoryClientNotFound(t *testing.T) {
+	ctx := context.Background()
+	c := NewInMemoryClient()
+
+	_, err := c.ReadProjectEntry(ctx, "project1")
+	assert.True(t, errors.Is(err, ErrProjectNotFound))
+
+	err = c.UpdateProjectEntry(ctx, ProjectEntry{ProjectID: "project1", Repository: testRepository})
+	assert.True(t, errors.Is(err, ErrProjectNotFound))
+
+	_, err = c.ReadTokenEntry(ctx, "token1")
+	assert.True(t, errors.Is(err, ErrTokenNotFound))
+
+	err = c.CreateTokenEntry(ctx, testToken("project1", "token1", time.Now()))
+	assert.True(t, errors.Is(err, ErrProjectNotFound))
+}
+
+func TestInMemoryClientProjectExists(t *testing.T) {
+	ctx := context.Background()
+	c := NewInMemoryClient()
+
+	pe := ProjectEntry{ProjectID: "project1", Repository: testRepository}
+	assert.Nil(t, c.CreateProjectEntry(ctx, pe))
+	assert.True(t, errors.Is(c.CreateProjectEntry(ctx, pe), ErrProjectExists))
+}
+
+func TestInMemoryClientTokenOrdering(t *testing.T) {
+	ctx := context.Background()
+	c := NewInMemoryClient()
+	assert.Nil(t, c.CreateProjectEntry(ctx, ProjectEntry{ProjectID: "project1", Repository: testRepository}))
+
+	base := time.Date(2022, 1, 1, 12, 0, 0, 0, time.UTC)
+	assert.Nil(t, c.CreateTokenEntries(ctx, []types.Token{
+		testToken("project1", "b", base),
+		testToken("project1", "c", base.Add(2*time.Minute)),
+		testToken("project1", "a", base),
+		testToken("project1", "d", base.Add(time.Minute)),
+	}))
+
+	want := []string{"c", "d", "b", "a"}
+
+	entries, err := c.ListTokenEntries(ctx, "project1")
+	assert.Nil(t, err)
+	assert.Equal(t, want, tokenIDs(entries))
+
+	got := []string{}
+	cursor := ""
+	for {
+		res, err := c.ListTokenEntriesPaged(ctx, "project1", 3, cursor)
+		assert.Nil(t, err)
+		assert.Equal(t, 4, res.Total)
+		got = append(got, tokenIDs(res.Entries)...)
+		if !res.HasMore {
+			break
+		}
+		cursor = res.NextCursor
+	}
+	assert.Equal(t, want, got)
+
+	history, err := c.ListTokenHistory(ctx, "project1")
+	assert.Nil(t, err)
+	assert.Equal(t, "c", history[0].TokenID)
+}
+
+func TestInMemoryClientDeleteRestore(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2022, 1, 1, 12, 0, 0, 0, time.UTC)
+	c := NewInMemoryClient(WithInMemoryClock(func() time.Time { return now }))
+
+	pe := ProjectEntry{ProjectID: "project1", Repository: testRepository}
+	assert.Nil(t, c.CreateProjectWithToken(ctx, pe, testToken("project1", "token1", now)))
+
+	assert.Nil(t, c.DeleteProjectEntry(ctx, "project1"))
+
+	_, err := c.ReadProjectEntry(ctx, "project1")
+	assert.True(t, errors.Is(err, ErrProjectNotFound))
+	projects, _, err := c.ListProjectEntries(ctx, ListOptions{})
+	assert.Nil(t, err)
+	assert.Empty(t, projects)
+
+	assert.Nil(t, c.RestoreProjectEntry(ctx, "project1"))
+
+	got, err := c.ReadProjectEntry(ctx, "project1")
+	assert.Nil(t, err)
+	assert.Equal(t, pe, got)
+	_, err = c.ReadTokenEntry(ctx, "token1")
+	assert.Nil(t, err)
+
+	// Live projects cannot be restored.
+	assert.True(t, errors.Is(c.RestoreProjectEntry(ctx, "project1"), ErrProjectNotFound))
+}
+
+func TestInMemoryClientPurgeCascadesToTokens(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2022, 1, 1, 12, 0, 0, 0, time.UTC)
+	c := NewInMemoryClient(WithInMemoryClock(func() time.Time { return now }))
+
+	pe := ProjectEntry{ProjectID: "project1", Repository: testRepository}
+	assert.Nil(t, c.CreateProjectWithToken(ctx, pe, testToken("project1", "token1", now)))
+	assert.Nil(t, c.CreateProjectEntry(ctx, ProjectEntry{ProjectID: "project2", Repository: testRepository}))
+	assert.Nil(t, c.CreateTokenEntry(ctx, testToken("project2", "token2", now)))
+	syncToken := encodeSyncToken(now)
+
+	assert.Nil(t, c.DeleteProjectEntry(ctx, "project1"))
+
+	// Nothing is purged within the recovery window.
+	n, err := c.PurgeDeletedProjects(ctx, now)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, n)
+
+	now = now.Add(defaultProjectRetention + time.Second)
+	n, err = c.PurgeDeletedProjects(ctx, now)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, n)
+
+	_, err = c.ReadTokenEntry(ctx, "token1")
+	assert.True(t, errors.Is(err, ErrTokenNotFound))
+	assert.True(t, errors.Is(c.RestoreProjectEntry(ctx, "project1"), ErrProjectNotFound))
+
+	// Other projects keep their tokens.
+	_, err = c.ReadTokenEntry(ctx, "token2")
+	assert.Nil(t, err)
+
+	history, err := c.ListTokenHistory(ctx, "project1")
+	assert.Nil(t, err)
+	assert.NotNil(t, history[0].DeletedAt)
+
+	// The purge is visible to clients syncing the project.
+	c.tombstoneRetention = 2 * defaultProjectRetention
+	changes, err := c.ListTokenChanges(ctx, "project1", syncToken)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"token1"}, changes.Deleted)
+}
+
+func TestInMemoryClientCreateOverDeletedProject(t *testing.T) {
+	ctx := context.Background()
+	c := NewInMemoryClient()
+
+	pe := ProjectEntry{ProjectID: "project1", Repository: testRepository}
+	assert.Nil(t, c.CreateProjectWithToken(ctx, pe, testToken("project1", "token1", time.Now())))
+	assert.Nil(t, c.DeleteProjectEntry(ctx, "project1"))
+
+	assert.Nil(t, c.CreateProjectEntry(ctx, pe))
+
+	n, err := c.CountTokenEntries(ctx, "project1")
+	assert.Nil(t, err)
+	assert.Equal(t, 0, n)
+}
+
+func TestInMemoryClientCreateTokenEntriesAtomic(t *testing.T) {
+	ctx := context.Background()
+	c := NewInMemoryClient()
+	assert.Nil(t, c.CreateProjectEntry(ctx, ProjectEntry{ProjectID: "project1", Repository: testRepository}))
+
+	now := time.Now()
+	err := c.CreateTokenEntries(ctx, []types.Token{
+		testToken("project1", "token1", now),
+		testToken("project1", "token1", now),
+	})
+	assert.EqualError(t, err, "token 'token1' already exists")
+
+	n, err := c.CountTokenEntries(ctx, "project1")
+	assert.Nil(t, err)
+	assert.Equal(t, 0, n)
+}
+
+func TestInMemoryClientVerifyTokenSecret(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2022, 1, 1, 12, 0, 0, 0, time.UTC)
+	c := NewInMemoryClient(WithInMemoryClock(func() time.Time { return now }))
+	assert.Nil(t, c.CreateProjectEntry(ctx, ProjectEntry{ProjectID: "project1", Repository: testRepository}))
+
+	token, err := c.IssueToken(ctx, "project1", "role-id", time.Hour)
+	assert.Nil(t, err)
+
+	entry, err := c.VerifyTokenSecret(ctx, token.ProjectToken.ID, token.Secret)
+	assert.Nil(t, err)
+	assert.Equal(t, token.ProjectToken.ID, entry.TokenID)
+
+	_, err = c.VerifyTokenSecret(ctx, token.ProjectToken.ID, "other")
+	assert.True(t, errors.Is(err, ErrInvalidTokenSecret))
+
+	read, err := c.ReadTokenEntry(ctx, token.ProjectToken.ID)
+	assert.Nil(t, err)
+	assert.False(t, read.SecretCorrupt)
+
+	// Tokens created without IssueToken have no secret to verify.
+	assert.Nil(t, c.CreateTokenEntry(ctx, testToken("project1", "token2", now)))
+	_, err = c.VerifyTokenSecret(ctx, "token2", "")
+	assert.True(t, errors.Is(err, ErrTokenSecretCorrupt))
+	assert.True(t, errors.Is(err, ErrInvalidTokenSecret))
+
+	read, err = c.ReadTokenEntry(ctx, "token2")
+	assert.Nil(t, err)
+	assert.True(t, read.SecretCorrupt)
+
+	now = now.Add(time.Hour)
+	_, err = c.VerifyTokenSecret(ctx, token.ProjectToken.ID, token.Secret)
+	assert.True(t, errors.Is(err, ErrTokenExpired))
+
+	assert.Nil(t, c.DeleteTokenEntry(ctx, token.ProjectToken.ID))
+	_, err = c.VerifyTokenSecret(ctx, token.ProjectToken.ID, token.Secret)
+	assert.True(t, errors.Is(err, ErrTokenNotFound))
+}
+
+func TestInMemoryClientVerifyAndTouch(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2022, 1, 1, 12, 0, 0, 0, time.UTC)
+	c := NewInMemoryClient(WithInMemoryClock(func() time.Time { return now }))
+	assert.Nil(t, c.CreateProjectEntry(ctx, ProjectEntry{ProjectID: "project1", Repository: testRepository}))
+
+	token, err := c.IssueToken(ctx, "project1", "role-id", time.Hour)
+	assert.Nil(t, err)
+
+	entry, err := c.VerifyAndTouch(ctx, "project1", token.ProjectToken.ID, token.Secret)
+	assert.Nil(t, err)
+	assert.Equal(t, token.ProjectToken.ID, entry.TokenID)
+
+	_, err = c.VerifyAndTouch(ctx, "project2", token.ProjectToken.ID, token.Secret)
+	assert.True(t, errors.Is(err, ErrTokenNotFound))
+
+	_, err = c.VerifyAndTouch(ctx, "project1", token.ProjectToken.ID, "other")
+	assert.True(t, errors.Is(err, ErrInvalidTokenSecret))
+
+	now = now.Add(time.Hour)
+	_, err = c.VerifyAndTouch(ctx, "project1", token.ProjectToken.ID, token.Secret)
+	assert.True(t, errors.Is(err, ErrTokenExpired))
+}
+
+func TestInMemoryClientPendingSecret(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2022, 1, 1, 12, 0, 0, 0, time.UTC)
+	c := NewInMemoryClient(WithInMemoryClock(func() time.Time { return now }))
+	assert.Nil(t, c.CreateProjectEntry(ctx, ProjectEntry{ProjectID: "project1", Repository: testRepository}))
+
+	token, err := c.IssueToken(ctx, "project1", "role-id", time.Hour)
+	assert.Nil(t, err)
+
+	assert.True(t, errors.Is(c.PromotePendingSecret(ctx, token.ProjectToken.ID), ErrNoPendingSecret))
+
+	pending, err := c.AddPendingSecret(ctx, token.ProjectToken.ID, time.Minute)
+	assert.Nil(t, err)
+
+	// Both secrets verify during the window.
+	_, err = c.VerifyTokenSecret(ctx, token.ProjectToken.ID, token.Secret)
+	assert.Nil(t, err)
+	_, err = c.VerifyTokenSecret(ctx, token.ProjectToken.ID, pending)
+	assert.Nil(t, err)
+
+	// Only the promoted secret verifies afterwards.
+	assert.Nil(t, c.PromotePendingSecret(ctx, token.ProjectToken.ID))
+	_, err = c.VerifyTokenSecret(ctx, token.ProjectToken.ID, token.Secret)
+	assert.True(t, errors.Is(err, ErrInvalidTokenSecret))
+	_, err = c.VerifyTokenSecret(ctx, token.ProjectToken.ID, pending)
+	assert.Nil(t, err)
+
+	// An unpromoted pending secret stops verifying once it expires.
+	next, err := c.AddPendingSecret(ctx, token.ProjectToken.ID, time.Minute)
+	assert.Nil(t, err)
+	now = now.Add(time.Minute)
+	_, err = c.VerifyTokenSecret(ctx, token.ProjectToken.ID, next)
+	assert.True(t, errors.Is(err, ErrInvalidTokenSecret))
+	assert.True(t, errors.Is(c.PromotePendingSecret(ctx, token.ProjectToken.ID), ErrNoPendingSecret))
+
+	// Tokens created without IssueToken have no secret to rotate.
+	assert.Nil(t, c.CreateTokenEntry(ctx, testToken("project1", "token2", now)))
+	_, err = c.AddPendingSecret(ctx, "token2", time.Minute)
+	assert.True(t, errors.Is(err, ErrInvalidTokenRequest))
+
+	_, err = c.AddPendingSecret(ctx, "token3", time.Minute)
+	assert.True(t, errors.Is(err, ErrTokenNotFound))
+}
+
+func TestInMemoryClientConcurrent(t *testing.T) {
+	ctx := context.Background()
+	c := NewInMemoryClient()
+	assert.Nil(t, c.CreateProjectEntry(ctx, ProjectEntry{ProjectID: "project1", Repository: testRepository}))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, err := c.IssueToken(ctx, "project1", fmt.Sprintf("role%d", i), time.Hour)
+			assert.Nil(t, err)
+			_, err = c.NextTokenSequence(ctx, "project1")
+			assert.Nil(t, err)
+			_, err = c.ListTokenEntries(ctx, "project1")
+			assert.Nil(t, err)
+		}(i)
+	}
+	wg.Wait()
+
+	n, err := c.CountTokenEntries(ctx, "project1")
+	assert.Nil(t, err)
+	assert.Equal(t, 10, n)
+
+	seq, err := c.NextTokenSequence(ctx, "project1")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(11), seq)
+}
@@ -0,0 +1,116 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+)
+
+// ErrUnknownOption conveys that a connection option is not a recognized
+// Postgres connection parameter.
+var ErrUnknownOption = errors.New("unknown connection option")
+
+// knownConnectionOptions are the parameters accepted in the options map: the
+// libpq connection parameters, those added by pgx, which the postgresql
+// adapter connects with, and commonly set runtime parameters.
+var knownConnectionOptions = map[string]bool{
+	// libpq
+	"application_name":          true,
+	"channel_binding":           true,
+	"client_encoding":           true,
+	"connect_timeout":           true,
+	"dbname":                    true,
+	"fallback_application_name": true,
+	"gssdelegation":             true,
+	"gssencmode":                true,
+	"gsslib":                    true,
+	"host":                      true,
+	"hostaddr":                  true,
+	"keepalives":                true,
+	"keepalives_count":          true,
+	"keepalives_idle":           true,
+	"keepalives_interval":       true,
+	"krbsrvname":                true,
+	"load_balance_hosts":        true,
+	"options":                   true,
+	"passfile":                  true,
+	"password":                  true,
+	"port":                      true,
+	"replication":               true,
+	"require_auth":              true,
+	"requirepeer":               true,
+	"requiressl":                true,
+	"service":                   true,
+	"ssl_max_protocol_version":  true,
+	"ssl_min_protocol_version":  true,
+	"sslcert":                   true,
+	"sslcertmode":               true,
+	"sslcompression":            true,
+	"sslcrl":                    true,
+	"sslcrldir":                 true,
+	"sslkey":                    true,
+	"sslmode":                   true,
+	"sslpassword":               true,
+	"sslrootcert":               true,
+	"sslsni":                    true,
+	"target_session_attrs":      true,
+	"tcp_user_timeout":          true,
+	"user":                      true,
+
+	// pgx
+	"krbspn":                   true,
+	"min_read_buffer_size":     true,
+	"prefer_simple_protocol":   true,
+	"servicefile":              true,
+	"statement_cache_capacity": true,
+	"statement_cache_mode":     true,
+
+	// runtime parameters
+	"idle_in_transaction_session_timeout": true,
+	"lock_timeout":                        true,
+	"search_path":                         true,
+	"statement_timeout":                   true,
+	"timezone":                            true,
+}
+
+// WithUnknownOptionsWarning logs unrecognized connection options as warnings
+// instead of failing client creation.
+func WithUnknownOptionsWarning(l log.Logger) Option {
+	return func(c *SQLClient) {
+		c.unknownOptionsLogger = l
+	}
+}
+
+// validateOptions returns ErrUnknownOption naming any options that are not
+// recognized connection parameters.
+func validateOptions(options map[string]string) error {
+	unknown := []string{}
+	for k := range options {
+		if !knownConnectionOptions[strings.ToLower(k)] {
+			unknown = append(unknown, k)
+		}
+	}
+
+	if len(unknown) == 0 {
+		return nil
+	}
+
+	sort.Strings(unknown)
+	return fmt.Errorf("%w: %s", ErrUnknownOption, strings.Join(unknown, ", "))
+}
+
+// checkOptions validates the client's options, logging rather than failing
+// when configured to.
+func (d SQLClient) checkOptions() error {
+	err := validateOptions(d.options)
+	if err == nil || d.unknownOptionsLogger == nil {
+		return err
+	}
+
+	level.Warn(d.unknownOptionsLogger).Log("message", "ignoring unrecognized db connection options", "error", err)
+	return nil
+}
@@ -0,0 +1,70 @@
+package db
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateOptions(t *testing.T) {
+	tests := []struct {
+		name    string
+		options map[string]string
+		wantErr string
+	}{
+		{
+			name: "no options",
+		},
+		{
+			name:    "known options",
+			options: map[string]string{"sslmode": "require", "connect_timeout": "5"},
+		},
+		{
+			name:    "libpq and pgx options",
+			options: map[string]string{"sslcrldir": "/crl", "load_balance_hosts": "random", "statement_cache_mode": "describe"},
+		},
+		{
+			name:    "known options are case insensitive",
+			options: map[string]string{"SSLMode": "require"},
+		},
+		{
+			name:    "typo is flagged",
+			options: map[string]string{"sslmoed": "require"},
+			wantErr: "unknown connection option: sslmoed",
+		},
+		{
+			name:    "all unknown options are named",
+			options: map[string]string{"sslmoed": "require", "conect_timeout": "5", "sslmode": "require"},
+			wantErr: "unknown connection option: conect_timeout, sslmoed",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateOptions(tt.options)
+			if tt.wantErr != "" {
+				assert.True(t, errors.Is(err, ErrUnknownOption))
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+
+			assert.Nil(t, err)
+		})
+	}
+}
+
+func TestNewSQLClientOptions(t *testing.T) {
+	options := map[string]string{"sslmoed": "require"}
+
+	_, err := NewSQLClient("host", "cello", "user", "pass", options)
+	assert.True(t, errors.Is(err, ErrUnknownOption))
+
+	buf := &bytes.Buffer{}
+	_, err = NewSQLClient("host", "cello", "user", "pass", options, WithUnknownOptionsWarning(log.NewLogfmtLogger(buf)))
+	assert.Nil(t, err)
+	assert.Contains(t, buf.String(), "sslmoed")
+	assert.Contains(t, buf.String(), "level=warn")
+}
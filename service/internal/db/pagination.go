@@ -0,0 +1,117 @@
+package db
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+)
+
+const defaultPageSize = 100
+
+// ErrInvalidCursor conveys that a pagination cursor could not be decoded.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// ListOptions controls pagination of list methods.
+type ListOptions struct {
+	// PageSize is the maximum number of entries returned. Defaults to 100.
+	PageSize int
+	// Cursor is the opaque cursor returned by the previous page. Empty
+	// starts at the beginning.
+	Cursor string
+}
+
+// pageSize returns the page size, applying the default.
+func (o ListOptions) pageSize() int {
+	if o.PageSize <= 0 {
+		return defaultPageSize
+	}
+	return o.PageSize
+}
+
+// ListTokenEntriesResult is a page of token entries along with pagination
+// metadata.
+type ListTokenEntriesResult struct {
+	Entries    []TokenEntry
+	Total      int
+	NextCursor string
+	HasMore    bool
+}
+
+// tokenKey is the keyset pagination key of a token entry. Tokens are listed
+// newest first, with the token id breaking ties between tokens created at the
+// same time.
+type tokenKey struct {
+	CreatedAt string
+	TokenID   string
+}
+
+// encodeTokenCursor encodes the key of the last token of a page into an
+// opaque cursor.
+func encodeTokenCursor(k tokenKey) string {
+	return encodeKeyCursor(k.CreatedAt + "|" + k.TokenID)
+}
+
+// decodeTokenCursor decodes an opaque cursor into the key of the last token of
+// the previous page. An empty cursor is the first page.
+func decodeTokenCursor(cursor string) (tokenKey, bool, error) {
+	key, err := decodeKeyCursor(cursor)
+	if err != nil || key == "" {
+		return tokenKey{}, false, err
+	}
+
+	createdAt, tokenID, ok := strings.Cut(key, "|")
+	if !ok || createdAt == "" || tokenID == "" {
+		return tokenKey{}, false, ErrInvalidCursor
+	}
+
+	return tokenKey{CreatedAt: createdAt, TokenID: tokenID}, true, nil
+}
+
+// tokenPage trims entries, fetched with one extra row, to the page size and
+// builds the page metadata. The cursor is empty on the last page.
+func tokenPage(entries []TokenEntry, pageSize, total int) ListTokenEntriesResult {
+	res := ListTokenEntriesResult{
+		Entries: entries,
+		Total:   total,
+		HasMore: len(entries) > pageSize,
+	}
+
+	if res.HasMore {
+		res.Entries = entries[:pageSize]
+		last := res.Entries[len(res.Entries)-1]
+		res.NextCursor = encodeTokenCursor(tokenKey{CreatedAt: last.CreatedAt, TokenID: last.TokenID})
+	}
+
+	return res
+}
+
+// encodeKeyCursor encodes the last key of a page into an opaque cursor.
+func encodeKeyCursor(key string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(key))
+}
+
+// decodeKeyCursor decodes an opaque cursor into the last key of the previous
+// page. An empty cursor is the first page.
+func decodeKeyCursor(cursor string) (string, error) {
+	if cursor == "" {
+		return "", nil
+	}
+
+	b, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil || len(b) == 0 {
+		return "", ErrInvalidCursor
+	}
+
+	return string(b), nil
+}
+
+// projectPage trims entries, fetched with one extra row, to the page size and
+// returns the cursor for the next page. The cursor is empty on the last page.
+func projectPage(entries []ProjectEntry, pageSize int) ([]ProjectEntry, string) {
+	if len(entries) <= pageSize {
+		return entries, ""
+	}
+
+	entries = entries[:pageSize]
+	return entries, encodeKeyCursor(entries[len(entries)-1].ProjectID)
+}
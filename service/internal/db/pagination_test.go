@@ -0,0 +1,233 @@
+package db
+
+import (
+	"context"
+	"database/sql/driver"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/upper/db/v4"
+)
+
+func TestTokenCursor(t *testing.T) {
+	tests := []struct {
+		name    string
+		cursor  string
+		want    tokenKey
+		wantOK  bool
+		wantErr error
+	}{
+		{
+			name: "empty cursor is first page",
+		},
+		{
+			name:   "round trip",
+			cursor: encodeTokenCursor(tokenKey{CreatedAt: "2022-06-21T14:56:10Z", TokenID: "token1"}),
+			want:   tokenKey{CreatedAt: "2022-06-21T14:56:10Z", TokenID: "token1"},
+			wantOK: true,
+		},
+		{
+			name:    "not base64",
+			cursor:  "!!!",
+			wantErr: ErrInvalidCursor,
+		},
+		{
+			name:    "missing token id",
+			cursor:  encodeKeyCursor("2022-06-21T14:56:10Z"),
+			wantErr: ErrInvalidCursor,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok, err := decodeTokenCursor(tt.cursor)
+			assert.Equal(t, tt.wantErr, err)
+			assert.Equal(t, tt.wantOK, ok)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestTokenPage(t *testing.T) {
+	entries := []TokenEntry{
+		{CreatedAt: "2022-01-03T00:00:00Z", TokenID: "c"},
+		{CreatedAt: "2022-01-02T00:00:00Z", TokenID: "b"},
+		{CreatedAt: "2022-01-01T00:00:00Z", TokenID: "a"},
+	}
+
+	tests := []struct {
+		name    string
+		entries []TokenEntry
+		want    ListTokenEntriesResult
+	}{
+		{
+			name:    "last page",
+			entries: entries[:2],
+			want:    ListTokenEntriesResult{Entries: entries[:2], Total: 3},
+		},
+		{
+			name:    "more pages",
+			entries: entries,
+			want: ListTokenEntriesResult{
+				Entries:    entries[:2],
+				Total:      3,
+				HasMore:    true,
+				NextCursor: encodeTokenCursor(tokenKey{CreatedAt: "2022-01-02T00:00:00Z", TokenID: "b"}),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tokenPage(tt.entries, 2, 3))
+		})
+	}
+}
+
+func TestListTokenEntriesPaged(t *testing.T) {
+	columns := []string{"created_at", "expires_at", "project", "token_id", "role_id"}
+	cursor := encodeTokenCursor(tokenKey{CreatedAt: "2022-01-02T00:00:00Z", TokenID: "b"})
+
+	tests := []struct {
+		name     string
+		cursor   string
+		query    string
+		args     []driver.Value
+		rows     *sqlmock.Rows
+		wantIDs  []string
+		wantMore bool
+		wantNext string
+	}{
+		{
+			name:  "first page has more",
+			query: `SELECT "created_at", "expires_at", "project", "token_id", "role_id" FROM "tokens" WHERE \(project = \$1\) ORDER BY "created_at" DESC, "token_id" DESC LIMIT 3$`,
+			args:  []driver.Value{"project1"},
+			rows: sqlmock.NewRows(columns).
+				AddRow("2022-01-03T00:00:00Z", "", "project1", "d", "").
+				AddRow("2022-01-02T00:00:00Z", "", "project1", "c", "").
+				AddRow("2022-01-02T00:00:00Z", "", "project1", "b", ""),
+			wantIDs:  []string{"d", "c"},
+			wantMore: true,
+			wantNext: encodeTokenCursor(tokenKey{CreatedAt: "2022-01-02T00:00:00Z", TokenID: "c"}),
+		},
+		{
+			name:   "later page is keyed on created_at and token_id",
+			cursor: cursor,
+			query:  `SELECT "created_at", "expires_at", "project", "token_id", "role_id" FROM "tokens" WHERE \(project = \$1 AND \(created_at, token_id\) < \(\$2, \$3\)\) ORDER BY "created_at" DESC, "token_id" DESC LIMIT 3$`,
+			args:   []driver.Value{"project1", "2022-01-02T00:00:00Z", "b"},
+			rows: sqlmock.NewRows(columns).
+				AddRow("2022-01-01T00:00:00Z", "", "project1", "a", ""),
+			wantIDs: []string{"a"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, mock := newMockSQLClient(t)
+			expectPrimaryKey(mock, TokenEntryDB, "token_id")
+			mock.ExpectQuery(`SELECT count\(1\) AS _t FROM "tokens" WHERE \("project" = \$1\)`).
+				WithArgs("project1").
+				WillReturnRows(sqlmock.NewRows([]string{"_t"}).AddRow(4))
+			mock.ExpectQuery(tt.query).WithArgs(tt.args...).WillReturnRows(tt.rows)
+
+			got, err := c.ListTokenEntriesPaged(context.Background(), "project1", 2, tt.cursor)
+			assert.Nil(t, err)
+			assert.Equal(t, tt.wantIDs, tokenIDs(got.Entries))
+			assert.Equal(t, 4, got.Total)
+			assert.Equal(t, tt.wantMore, got.HasMore)
+			assert.Equal(t, tt.wantNext, got.NextCursor)
+		})
+	}
+}
+
+func TestListTokenEntriesPagedInvalidCursor(t *testing.T) {
+	c, err := NewSQLClient("localhost", "cello", "cello", "", nil)
+	assert.Nil(t, err)
+
+	opened := false
+	c.open = func(db.ConnectionURL) (db.Session, error) {
+		opened = true
+		return &fakeSession{}, nil
+	}
+
+	_, err = c.ListTokenEntriesPaged(context.Background(), "project1", 2, "!!!")
+	assert.Equal(t, ErrInvalidCursor, err)
+	assert.False(t, opened, "store must not be touched")
+}
+
+func TestKeyCursor(t *testing.T) {
+	tests := []struct {
+		name    string
+		cursor  string
+		want    string
+		wantErr error
+	}{
+		{
+			name: "empty cursor is first page",
+		},
+		{
+			name:   "round trip",
+			cursor: encodeKeyCursor("project1"),
+			want:   "project1",
+		},
+		{
+			name:    "not base64",
+			cursor:  "!!!",
+			wantErr: ErrInvalidCursor,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := decodeKeyCursor(tt.cursor)
+			assert.Equal(t, tt.wantErr, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestProjectPage(t *testing.T) {
+	projects := []ProjectEntry{{ProjectID: "a"}, {ProjectID: "b"}, {ProjectID: "c"}}
+
+	tests := []struct {
+		name        string
+		entries     []ProjectEntry
+		pageSize    int
+		wantEntries []ProjectEntry
+		wantCursor  string
+	}{
+		{
+			name:        "more pages",
+			entries:     projects,
+			pageSize:    2,
+			wantEntries: projects[:2],
+			wantCursor:  encodeKeyCursor("b"),
+		},
+		{
+			name:        "last page",
+			entries:     projects,
+			pageSize:    3,
+			wantEntries: projects,
+		},
+		{
+			name:        "empty",
+			entries:     []ProjectEntry{},
+			pageSize:    3,
+			wantEntries: []ProjectEntry{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries, cursor := projectPage(tt.entries, tt.pageSize)
+			assert.Equal(t, tt.wantEntries, entries)
+			assert.Equal(t, tt.wantCursor, cursor)
+		})
+	}
+}
+
+func TestListOptionsPageSize(t *testing.T) {
+	assert.Equal(t, defaultPageSize, ListOptions{}.pageSize())
+	assert.Equal(t, 10, ListOptions{PageSize: 10}.pageSize())
+}
@@ -0,0 +1,92 @@
+package db
+
+import (
+	"sync"
+	"time"
+
+	"github.com/upper/db/v4"
+)
+
+// sessionPool lazily opens a session that is shared across calls. The
+// session wraps a sql.DB, which pools the underlying connections.
+type sessionPool struct {
+	mu   sync.Mutex
+	sess db.Session
+}
+
+// WithMaxOpenConns sets the maximum number of open connections to each
+// database. Defaults to unlimited.
+func WithMaxOpenConns(n int) Option {
+	return func(c *SQLClient) {
+		c.maxOpenConns = n
+	}
+}
+
+// WithMaxIdleConns sets the maximum number of idle connections kept to each
+// database. Defaults to 10.
+func WithMaxIdleConns(n int) Option {
+	return func(c *SQLClient) {
+		c.maxIdleConns = n
+	}
+}
+
+// WithConnMaxLifetime sets the maximum amount of time a connection may be
+// reused.
+func WithConnMaxLifetime(d time.Duration) Option {
+	return func(c *SQLClient) {
+		c.connMaxLifetime = d
+	}
+}
+
+// session returns the pool's session, opening it on first use. A failed open
+// is not cached so the next call retries.
+func (d SQLClient) session(p *sessionPool, host string) (db.Session, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.sess != nil {
+		return p.sess, nil
+	}
+
+	sess, err := d.openSession(host)
+	if err != nil {
+		return nil, err
+	}
+
+	if d.maxOpenConns > 0 {
+		sess.SetMaxOpenConns(d.maxOpenConns)
+	}
+	if d.maxIdleConns > 0 {
+		sess.SetMaxIdleConns(d.maxIdleConns)
+	}
+	if d.connMaxLifetime > 0 {
+		sess.SetConnMaxLifetime(d.connMaxLifetime)
+	}
+
+	p.sess = sess
+	return sess, nil
+}
+
+// close closes the pool's session if it was opened.
+func (p *sessionPool) close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.sess == nil {
+		return nil
+	}
+
+	err := p.sess.Close()
+	p.sess = nil
+	return err
+}
+
+// Close closes the client's database connections. The client reconnects if
+// used afterwards.
+func (d SQLClient) Close() error {
+	err := d.primary.close()
+	if rerr := d.replica.close(); err == nil {
+		err = rerr
+	}
+	return err
+}
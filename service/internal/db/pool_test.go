@@ -0,0 +1,114 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/upper/db/v4"
+	"github.com/upper/db/v4/adapter/postgresql"
+)
+
+func TestCreateSessionReusesSession(t *testing.T) {
+	c, err := NewSQLClient("primary", "cello", "user", "pass", nil,
+		WithMaxOpenConns(10), WithMaxIdleConns(5), WithConnMaxLifetime(time.Minute))
+	assert.Nil(t, err)
+
+	errConn := errors.New("connection refused")
+	openErr := errConn
+	opened := []*fakeSession{}
+	c.open = func(settings db.ConnectionURL) (db.Session, error) {
+		if openErr != nil {
+			return nil, openErr
+		}
+		s := &fakeSession{host: settings.(postgresql.ConnectionURL).Host}
+		opened = append(opened, s)
+		return s, nil
+	}
+
+	// A failed open is retried on the next call.
+	_, err = c.createSession()
+	assert.Equal(t, errConn, err)
+	openErr = nil
+
+	first, err := c.createSession()
+	assert.Nil(t, err)
+	second, err := c.createSession()
+	assert.Nil(t, err)
+
+	assert.Len(t, opened, 1)
+	assert.Same(t, first, second)
+	assert.Equal(t, 10, opened[0].maxOpenConns)
+	assert.Equal(t, 5, opened[0].maxIdleConns)
+	assert.Equal(t, time.Minute, opened[0].connMaxLifetime)
+
+	// Copies of the client share the session.
+	cp := c
+	third, err := cp.createSession()
+	assert.Nil(t, err)
+	assert.Same(t, first, third)
+
+	assert.Nil(t, c.Close())
+	assert.True(t, opened[0].closed)
+
+	// The client reconnects after being closed.
+	_, err = c.createSession()
+	assert.Nil(t, err)
+	assert.Len(t, opened, 2)
+}
+
+func TestCloseUnopened(t *testing.T) {
+	c, err := NewSQLClient("primary", "cello", "user", "pass", nil)
+	assert.Nil(t, err)
+	assert.Nil(t, c.Close())
+}
+
+func TestPoolSharesSessionAcrossConcurrentCalls(t *testing.T) {
+	sqlDB, mock, err := sqlmock.New()
+	assert.Nil(t, err)
+	mock.MatchExpectationsInOrder(false)
+
+	c, err := NewSQLClient("primary", "cello", "user", "pass", nil, WithMaxOpenConns(3))
+	assert.Nil(t, err)
+
+	var mu sync.Mutex
+	opens := 0
+	c.open = func(db.ConnectionURL) (db.Session, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		opens++
+		return postgresql.New(sqlDB)
+	}
+
+	const calls = 5
+	mock.ExpectQuery(`SELECT\s+CURRENT_DATABASE\(\)`).
+		WillReturnRows(sqlmock.NewRows([]string{"name"}).AddRow("cello"))
+	for i := 0; i < calls; i++ {
+		mock.ExpectQuery(`INSERT INTO token_sequences`).
+			WithArgs("project1").
+			WillReturnRows(sqlmock.NewRows([]string{"value"}).AddRow(i + 1))
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < calls; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := c.NextTokenSequence(context.Background(), "project1")
+			assert.Nil(t, err)
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, 1, opens)
+	assert.Equal(t, 3, sqlDB.Stats().MaxOpenConnections)
+
+	// Close closes the shared sql.DB.
+	mock.ExpectClose()
+	assert.Nil(t, c.Close())
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,52 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	"github.com/upper/db/v4"
+)
+
+// Predicate selects the tokens of a project affected by a bulk operation. The
+// zero value matches all of the project's tokens.
+type Predicate struct {
+	// ExpiredBefore, when set, matches only tokens that expired before it,
+	// the same tokens DeleteExpiredTokens removes.
+	ExpiredBefore time.Time
+}
+
+// AllTokens matches all of a project's tokens.
+func AllTokens() Predicate {
+	return Predicate{}
+}
+
+// ExpiredTokens matches a project's tokens that expired before now.
+func ExpiredTokens(now time.Time) Predicate {
+	return Predicate{ExpiredBefore: now}
+}
+
+// cond returns the query condition for the predicate.
+func (p Predicate) cond(project string) db.Cond {
+	cond := db.Cond{"project": project}
+	if !p.ExpiredBefore.IsZero() {
+		cond["expires_at <"] = p.ExpiredBefore
+	}
+	return cond
+}
+
+// PreviewAffectedTokenCount returns how many of the project's tokens match the
+// predicate, so a destructive operation can be confirmed before it runs.
+func (d SQLClient) PreviewAffectedTokenCount(ctx context.Context, project string, predicate Predicate) (int, error) {
+	ctx, cancel := d.withTimeout(ctx)
+	defer cancel()
+
+	project = d.projectID(project)
+
+	sess, err := d.createSession()
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := sess.WithContext(ctx).Collection(TokenEntryDB).Find(predicate.cond(project)).Count()
+	return int(n), err
+}
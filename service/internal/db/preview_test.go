@@ -0,0 +1,41 @@
+package db
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/upper/db/v4"
+)
+
+func TestPredicateCond(t *testing.T) {
+	now := time.Date(2022, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		predicate Predicate
+		want      db.Cond
+	}{
+		{
+			name:      "all tokens",
+			predicate: AllTokens(),
+			want:      db.Cond{"project": "project1"},
+		},
+		{
+			name:      "zero value is all tokens",
+			predicate: Predicate{},
+			want:      db.Cond{"project": "project1"},
+		},
+		{
+			name:      "expired tokens",
+			predicate: ExpiredTokens(now),
+			want:      db.Cond{"project": "project1", "expires_at <": now},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.predicate.cond("project1"))
+		})
+	}
+}
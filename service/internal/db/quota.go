@@ -0,0 +1,120 @@
+package db
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// DefaultMaxTokens is the number of tokens a project may have when its quota
+// does not set one.
+const DefaultMaxTokens = 2
+
+// ErrQuotaExceeded conveys that an operation would exceed the project quota.
+var ErrQuotaExceeded = errors.New("project quota exceeded")
+
+// ProjectQuota holds per-project limits. Zero values mean the default applies.
+type ProjectQuota struct {
+	// MaxTokens is the maximum number of tokens the project may have.
+	MaxTokens int `json:"max_tokens,omitempty"`
+	// MaxTokenTTLSeconds is the maximum lifetime of a token. Zero is unlimited.
+	MaxTokenTTLSeconds int64 `json:"max_token_ttl_seconds,omitempty"`
+	// AllowedTargetTypes restricts the target types of the project. Empty
+	// allows all types.
+	AllowedTargetTypes []string `json:"allowed_target_types,omitempty"`
+}
+
+// WithDefaults returns the quota with defaults applied to unset fields.
+func (q ProjectQuota) WithDefaults() ProjectQuota {
+	if q.MaxTokens == 0 {
+		q.MaxTokens = DefaultMaxTokens
+	}
+	return q
+}
+
+// ValidateTokenCount returns ErrQuotaExceeded if the project already has the
+// maximum number of tokens.
+func (q ProjectQuota) ValidateTokenCount(count int) error {
+	if count >= q.WithDefaults().MaxTokens {
+		return fmt.Errorf("%w: token limit of %d reached", ErrQuotaExceeded, q.WithDefaults().MaxTokens)
+	}
+	return nil
+}
+
+// MaxTokenTTL returns the maximum lifetime of a token, or zero when it is
+// unlimited.
+func (q ProjectQuota) MaxTokenTTL() time.Duration {
+	return time.Duration(q.MaxTokenTTLSeconds) * time.Second
+}
+
+// ValidateTokenTTL returns ErrQuotaExceeded if the token lifetime is longer
+// than allowed.
+func (q ProjectQuota) ValidateTokenTTL(createdAt, expiresAt string) error {
+	if q.MaxTokenTTLSeconds == 0 {
+		return nil
+	}
+
+	created, err := parseTokenTime(createdAt)
+	if err != nil {
+		return err
+	}
+
+	expires, err := parseTokenTime(expiresAt)
+	if err != nil {
+		return err
+	}
+
+	maxTTL := q.MaxTokenTTL()
+	if expires.Sub(created) > maxTTL {
+		return fmt.Errorf("%w: token ttl cannot be more than %s", ErrQuotaExceeded, maxTTL)
+	}
+	return nil
+}
+
+// ValidateTargetType returns ErrQuotaExceeded if the target type is not
+// allowed.
+func (q ProjectQuota) ValidateTargetType(targetType string) error {
+	if len(q.AllowedTargetTypes) == 0 {
+		return nil
+	}
+
+	for _, t := range q.AllowedTargetTypes {
+		if t == targetType {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("%w: target type must be one of '%s'", ErrQuotaExceeded, strings.Join(q.AllowedTargetTypes, " "))
+}
+
+// IsEmpty returns whether the quota has no fields set.
+func (q ProjectQuota) IsEmpty() bool {
+	return q.MaxTokens == 0 && q.MaxTokenTTLSeconds == 0 && len(q.AllowedTargetTypes) == 0
+}
+
+// Value stores the quota as JSON, or NULL when empty.
+func (q ProjectQuota) Value() (driver.Value, error) {
+	if q.IsEmpty() {
+		return nil, nil
+	}
+	return json.Marshal(q)
+}
+
+// Scan reads the quota from JSON. NULL results in an empty quota.
+func (q *ProjectQuota) Scan(src interface{}) error {
+	*q = ProjectQuota{}
+
+	switch v := src.(type) {
+	case nil:
+		return nil
+	case []byte:
+		return json.Unmarshal(v, q)
+	case string:
+		return json.Unmarshal([]byte(v), q)
+	default:
+		return fmt.Errorf("unsupported quota type %T", src)
+	}
+}
@@ -0,0 +1,119 @@
+package db
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProjectQuotaValueScan(t *testing.T) {
+	tests := []struct {
+		name  string
+		quota ProjectQuota
+	}{
+		{
+			name: "empty quota",
+		},
+		{
+			name: "full quota",
+			quota: ProjectQuota{
+				MaxTokens:          5,
+				MaxTokenTTLSeconds: 3600,
+				AllowedTargetTypes: []string{"aws_account"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v, err := tt.quota.Value()
+			assert.NoError(t, err)
+
+			got := ProjectQuota{MaxTokens: 99}
+			assert.NoError(t, got.Scan(v))
+			assert.Equal(t, tt.quota, got)
+		})
+	}
+}
+
+func TestProjectQuotaScanString(t *testing.T) {
+	got := ProjectQuota{}
+	assert.NoError(t, got.Scan(`{"max_tokens": 3}`))
+	assert.Equal(t, ProjectQuota{MaxTokens: 3}, got)
+}
+
+func TestProjectQuotaValidateTokenCount(t *testing.T) {
+	tests := []struct {
+		name    string
+		quota   ProjectQuota
+		count   int
+		wantErr bool
+	}{
+		{
+			name:  "default under limit",
+			count: 1,
+		},
+		{
+			name:    "default at limit",
+			count:   DefaultMaxTokens,
+			wantErr: true,
+		},
+		{
+			name:  "custom under limit",
+			quota: ProjectQuota{MaxTokens: 5},
+			count: 4,
+		},
+		{
+			name:    "custom at limit",
+			quota:   ProjectQuota{MaxTokens: 5},
+			count:   5,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.quota.ValidateTokenCount(tt.count)
+			assert.Equal(t, tt.wantErr, errors.Is(err, ErrQuotaExceeded))
+		})
+	}
+}
+
+func TestProjectQuotaValidateTokenTTL(t *testing.T) {
+	tests := []struct {
+		name      string
+		quota     ProjectQuota
+		expiresAt string
+		wantErr   bool
+	}{
+		{
+			name:      "unlimited",
+			expiresAt: "2023-01-01T00:00:00Z",
+		},
+		{
+			name:      "within limit",
+			quota:     ProjectQuota{MaxTokenTTLSeconds: 3600},
+			expiresAt: "2022-01-01T01:00:00Z",
+		},
+		{
+			name:      "over limit",
+			quota:     ProjectQuota{MaxTokenTTLSeconds: 3600},
+			expiresAt: "2022-01-01T01:00:01Z",
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.quota.ValidateTokenTTL("2022-01-01T00:00:00Z", tt.expiresAt)
+			assert.Equal(t, tt.wantErr, errors.Is(err, ErrQuotaExceeded))
+		})
+	}
+}
+
+func TestProjectQuotaValidateTargetType(t *testing.T) {
+	assert.NoError(t, ProjectQuota{}.ValidateTargetType("aws_account"))
+	assert.NoError(t, ProjectQuota{AllowedTargetTypes: []string{"aws_account"}}.ValidateTargetType("aws_account"))
+	assert.ErrorIs(t, ProjectQuota{AllowedTargetTypes: []string{"other"}}.ValidateTargetType("aws_account"), ErrQuotaExceeded)
+}
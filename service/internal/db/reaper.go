@@ -0,0 +1,216 @@
+package db
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const defaultReaperBatchSize = 100
+
+// ReaperOption is a function for configuring the reaper.
+type ReaperOption func(*reaper)
+
+// WithReaperLogger sets the logger used to report reaped tokens.
+func WithReaperLogger(l log.Logger) ReaperOption {
+	return func(r *reaper) {
+		r.logger = l
+	}
+}
+
+// WithReaperBatchSize sets how many expired tokens are listed at a time. Zero
+// or less lists them all at once.
+func WithReaperBatchSize(n int) ReaperOption {
+	return func(r *reaper) {
+		r.batchSize = n
+	}
+}
+
+// WithReaperTombstoneRetention sets how long token tombstones are kept before
+// the reaper purges them. It should match the client's WithTombstoneRetention.
+// Defaults to 7 days.
+func WithReaperTombstoneRetention(d time.Duration) ReaperOption {
+	return func(r *reaper) {
+		r.tombstoneRetention = d
+	}
+}
+
+// WithReaperRegisterer registers the reaper's deleted and failed token
+// counters with reg. Without it the counters are kept but not exported.
+func WithReaperRegisterer(reg prometheus.Registerer) ReaperOption {
+	return func(r *reaper) {
+		r.registerer = reg
+	}
+}
+
+type reaper struct {
+	client             Client
+	logger             log.Logger
+	batchSize          int
+	tombstoneRetention time.Duration
+	registerer         prometheus.Registerer
+	deleted            prometheus.Counter
+	failed             prometheus.Counter
+	now                func() time.Time
+	newTicker          func(time.Duration) (<-chan time.Time, func())
+}
+
+// StartReaper deletes expired tokens across all projects, purges deleted
+// projects past their recovery window and purges token tombstones past their
+// retention every interval until the context is cancelled or the returned
+// stop function is called. Runs never overlap; ticks that occur while a run
+// is in progress are dropped. The stop function blocks until any in-progress
+// run has finished.
+func StartReaper(ctx context.Context, c Client, interval time.Duration, opts ...ReaperOption) func() {
+	r := newReaper(c, opts...)
+
+	if r.registerer != nil {
+		for _, col := range []prometheus.Collector{r.deleted, r.failed} {
+			if err := r.registerer.Register(col); err != nil {
+				level.Error(r.logger).Log("message", "error registering reaper metrics", "error", err)
+			}
+		}
+	}
+
+	return r.start(ctx, interval)
+}
+
+func newReaper(c Client, opts ...ReaperOption) *reaper {
+	r := &reaper{
+		client:             c,
+		logger:             log.NewNopLogger(),
+		batchSize:          defaultReaperBatchSize,
+		tombstoneRetention: defaultTombstoneRetention,
+		deleted: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "cello_db_reaper_deleted_tokens_total",
+			Help: "Number of expired tokens deleted by the reaper.",
+		}),
+		failed: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "cello_db_reaper_failed_tokens_total",
+			Help: "Number of expired tokens the reaper failed to delete.",
+		}),
+		now: time.Now,
+		newTicker: func(d time.Duration) (<-chan time.Time, func()) {
+			t := time.NewTicker(d)
+			return t.C, t.Stop
+		},
+	}
+
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	return r
+}
+
+func (r *reaper) start(ctx context.Context, interval time.Duration) func() {
+	ctx, cancel := context.WithCancel(ctx)
+	tick, stopTicker := r.newTicker(interval)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer stopTicker()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-tick:
+				n, err := r.reap(ctx)
+				if ctx.Err() != nil {
+					return
+				}
+				if err != nil {
+					level.Error(r.logger).Log("message", "error reaping expired tokens", "reaped", n, "error", err)
+					continue
+				}
+				level.Info(r.logger).Log("message", "reaped expired tokens", "reaped", n)
+
+				purged, err := r.client.PurgeDeletedProjects(ctx, r.now())
+				if ctx.Err() != nil {
+					return
+				}
+				if err != nil {
+					level.Error(r.logger).Log("message", "error purging deleted projects", "error", err)
+					continue
+				}
+				if purged > 0 {
+					level.Info(r.logger).Log("message", "purged deleted projects", "purged", purged)
+				}
+
+				purged, err = r.client.PurgeTokenTombstones(ctx, r.now().Add(-r.tombstoneRetention))
+				if ctx.Err() != nil {
+					return
+				}
+				if err != nil {
+					level.Error(r.logger).Log("message", "error purging token tombstones", "error", err)
+					continue
+				}
+				if purged > 0 {
+					level.Info(r.logger).Log("message", "purged token tombstones", "purged", purged)
+				}
+			}
+		}
+	}()
+
+	return func() {
+		cancel()
+		wg.Wait()
+	}
+}
+
+// reap deletes expired tokens, returning the number deleted. Expired tokens
+// are listed in batches to find their projects, and each of those projects
+// has all of its expired tokens deleted at once. It stops at the first failed
+// deletion or once the context is done.
+func (r *reaper) reap(ctx context.Context) (int, error) {
+	now := r.now()
+	reaped := 0
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return reaped, err
+		}
+
+		entries, err := r.client.ListExpiredTokenEntriesGlobal(ctx, now, r.batchSize)
+		if err != nil {
+			return reaped, err
+		}
+
+		var projects []string
+		listed := map[string]int{}
+		for _, e := range entries {
+			if listed[e.ProjectID] == 0 {
+				projects = append(projects, e.ProjectID)
+			}
+			listed[e.ProjectID]++
+		}
+
+		batch := 0
+		for _, project := range projects {
+			if err := ctx.Err(); err != nil {
+				return reaped, err
+			}
+			n, err := r.client.DeleteExpiredTokens(ctx, project, now)
+			if err != nil {
+				r.failed.Add(float64(listed[project]))
+				return reaped, err
+			}
+			r.deleted.Add(float64(n))
+			batch += n
+		}
+		reaped += batch
+
+		// An unlimited batch size lists every expired token at once. A batch
+		// that deleted nothing would be listed again, so it ends the run too.
+		if r.batchSize <= 0 || len(entries) < r.batchSize || batch == 0 {
+			return reaped, nil
+		}
+	}
+}
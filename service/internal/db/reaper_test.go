@@ -0,0 +1,267 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+// reaperClient is a fake Client serving a fixed set of expired tokens.
+type reaperClient struct {
+	Client
+
+	mu        sync.Mutex
+	expired   []TokenEntry
+	deleted   []string
+	listErr   error
+	deleteErr error
+	onDelete  func()
+	deletedCh chan string
+	purgedAt  chan time.Time
+	purgedTo  chan time.Time
+}
+
+func (c *reaperClient) PurgeDeletedProjects(ctx context.Context, now time.Time) (int, error) {
+	if c.purgedAt != nil {
+		c.purgedAt <- now
+	}
+	return 0, nil
+}
+
+func (c *reaperClient) PurgeTokenTombstones(ctx context.Context, before time.Time) (int, error) {
+	if c.purgedTo != nil {
+		c.purgedTo <- before
+	}
+	return 0, nil
+}
+
+func (c *reaperClient) ListExpiredTokenEntriesGlobal(ctx context.Context, now time.Time, limit int) ([]TokenEntry, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.listErr != nil {
+		return nil, c.listErr
+	}
+
+	if limit <= 0 || limit > len(c.expired) {
+		limit = len(c.expired)
+	}
+	return append([]TokenEntry{}, c.expired[:limit]...), nil
+}
+
+func (c *reaperClient) DeleteExpiredTokens(ctx context.Context, project string, now time.Time) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.onDelete != nil {
+		c.onDelete()
+	}
+
+	if c.deleteErr != nil {
+		return 0, c.deleteErr
+	}
+
+	remaining := []TokenEntry{}
+	n := 0
+	for _, e := range c.expired {
+		if e.ProjectID != project {
+			remaining = append(remaining, e)
+			continue
+		}
+
+		c.deleted = append(c.deleted, e.TokenID)
+		if c.deletedCh != nil {
+			c.deletedCh <- e.TokenID
+		}
+		n++
+	}
+	c.expired = remaining
+	return n, nil
+}
+
+func newTestReaper(c Client, tick chan time.Time) *reaper {
+	r := newReaper(c, WithReaperBatchSize(2), WithReaperTombstoneRetention(time.Hour))
+	r.now = func() time.Time { return time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC) }
+	r.newTicker = func(time.Duration) (<-chan time.Time, func()) { return tick, func() {} }
+	return r
+}
+
+func TestReaperReapsOnTick(t *testing.T) {
+	c := &reaperClient{
+		expired:   []TokenEntry{{TokenID: "a"}, {TokenID: "b"}, {TokenID: "c"}},
+		deletedCh: make(chan string, 3),
+	}
+	tick := make(chan time.Time)
+
+	stop := newTestReaper(c, tick).start(context.Background(), time.Minute)
+	defer stop()
+
+	// Nothing is deleted before the first tick.
+	select {
+	case <-c.deletedCh:
+		t.Fatal("unexpected deletion before tick")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	tick <- time.Now()
+	for _, want := range []string{"a", "b", "c"} {
+		select {
+		case got := <-c.deletedCh:
+			assert.Equal(t, want, got)
+		case <-time.After(time.Second):
+			t.Fatalf("timed out waiting for deletion of %s", want)
+		}
+	}
+}
+
+func TestReaperPurgesDeletedProjectsOnTick(t *testing.T) {
+	c := &reaperClient{purgedAt: make(chan time.Time, 1)}
+	tick := make(chan time.Time)
+
+	stop := newTestReaper(c, tick).start(context.Background(), time.Minute)
+	defer stop()
+
+	tick <- time.Now()
+	select {
+	case got := <-c.purgedAt:
+		assert.Equal(t, time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC), got)
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for deleted projects to be purged")
+	}
+}
+
+func TestReaperPurgesTokenTombstonesOnTick(t *testing.T) {
+	c := &reaperClient{purgedTo: make(chan time.Time, 1)}
+	tick := make(chan time.Time)
+
+	stop := newTestReaper(c, tick).start(context.Background(), time.Minute)
+	defer stop()
+
+	tick <- time.Now()
+	select {
+	case got := <-c.purgedTo:
+		assert.Equal(t, time.Date(2021, 12, 31, 23, 0, 0, 0, time.UTC), got)
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for token tombstones to be purged")
+	}
+}
+
+func TestReaperReap(t *testing.T) {
+	c := &reaperClient{expired: []TokenEntry{
+		{ProjectID: "project1", TokenID: "a"},
+		{ProjectID: "project2", TokenID: "b"},
+		{ProjectID: "project3", TokenID: "c"},
+		{ProjectID: "project1", TokenID: "d"},
+	}}
+
+	r := newTestReaper(c, nil)
+	n, err := r.reap(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 4, n)
+	// Each listed project has all of its expired tokens deleted at once.
+	assert.Equal(t, []string{"a", "d", "b", "c"}, c.deleted)
+	assert.Equal(t, float64(4), testutil.ToFloat64(r.deleted))
+	assert.Equal(t, float64(0), testutil.ToFloat64(r.failed))
+}
+
+func TestReaperReapUnlimitedBatch(t *testing.T) {
+	c := &reaperClient{expired: []TokenEntry{{TokenID: "a"}, {TokenID: "b"}, {TokenID: "c"}}}
+
+	r := newTestReaper(c, nil)
+	r.batchSize = 0
+	n, err := r.reap(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 3, n)
+	assert.Equal(t, []string{"a", "b", "c"}, c.deleted)
+}
+
+func TestReaperReapDeleteError(t *testing.T) {
+	c := &reaperClient{
+		expired:   []TokenEntry{{TokenID: "a"}, {TokenID: "b"}},
+		deleteErr: errors.New("boom"),
+	}
+
+	r := newTestReaper(c, nil)
+	n, err := r.reap(context.Background())
+	assert.EqualError(t, err, "boom")
+	assert.Equal(t, 0, n)
+	assert.Equal(t, float64(0), testutil.ToFloat64(r.deleted))
+	assert.Equal(t, float64(2), testutil.ToFloat64(r.failed))
+}
+
+func TestReaperReapStopsOnCancelledContext(t *testing.T) {
+	c := &reaperClient{expired: []TokenEntry{{TokenID: "a"}, {TokenID: "b"}}}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	n, err := newTestReaper(c, nil).reap(ctx)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, 0, n)
+	assert.Empty(t, c.deleted)
+}
+
+func TestReaperSkipsPurgesWhenCancelledMidReap(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	c := &reaperClient{
+		expired:  []TokenEntry{{ProjectID: "project1", TokenID: "a"}, {ProjectID: "project2", TokenID: "b"}},
+		onDelete: cancel,
+		purgedAt: make(chan time.Time, 1),
+		purgedTo: make(chan time.Time, 1),
+	}
+	tick := make(chan time.Time)
+
+	stop := newTestReaper(c, tick).start(ctx, time.Minute)
+	tick <- time.Now()
+	stop()
+
+	assert.Equal(t, []string{"a"}, c.deleted)
+	assert.Empty(t, c.purgedAt)
+	assert.Empty(t, c.purgedTo)
+}
+
+func TestStartReaperRegistersMetrics(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	stop := StartReaper(context.Background(), &reaperClient{}, time.Hour, WithReaperRegisterer(reg))
+	defer stop()
+
+	families, err := reg.Gather()
+	assert.NoError(t, err)
+
+	var names []string
+	for _, f := range families {
+		names = append(names, f.GetName())
+	}
+	assert.ElementsMatch(t, []string{"cello_db_reaper_deleted_tokens_total", "cello_db_reaper_failed_tokens_total"}, names)
+}
+
+func TestReaperReapError(t *testing.T) {
+	c := &reaperClient{listErr: errors.New("boom")}
+
+	n, err := newTestReaper(c, nil).reap(context.Background())
+	assert.EqualError(t, err, "boom")
+	assert.Equal(t, 0, n)
+}
+
+func TestReaperStopsOnCancelledContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	stop := newTestReaper(&reaperClient{}, make(chan time.Time)).start(ctx, time.Minute)
+	cancel()
+
+	done := make(chan struct{})
+	go func() {
+		stop()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("reaper did not stop after context was cancelled")
+	}
+}
@@ -0,0 +1,398 @@
+package db
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/cello-proj/cello/internal/types"
+)
+
+// redactedSecret replaces token secrets in recorded calls.
+const redactedSecret = "REDACTED"
+
+var _ Client = (*RecordingClient)(nil)
+
+// Call is a Client call captured by RecordingClient.
+type Call struct {
+	// Method is the name of the Client method called.
+	Method string
+	// Args are the arguments passed, excluding the context.
+	Args []interface{}
+	// Results are the values returned, excluding the error.
+	Results []interface{}
+	Err     error
+}
+
+// RecordingClient wraps a Client and records each call made through it so
+// tests can assert which calls were made and in what order. Token secrets
+// are redacted from recorded arguments and results.
+type RecordingClient struct {
+	client Client
+
+	mu    sync.Mutex
+	calls []Call
+}
+
+// NewRecordingClient returns a RecordingClient wrapping c.
+func NewRecordingClient(c Client) *RecordingClient {
+	return &RecordingClient{client: c}
+}
+
+// Calls returns the calls recorded so far, oldest first.
+func (r *RecordingClient) Calls() []Call {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([]Call{}, r.calls...)
+}
+
+// Methods returns the names of the methods called so far, oldest first.
+func (r *RecordingClient) Methods() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	res := []string{}
+	for _, c := range r.calls {
+		res = append(res, c.Method)
+	}
+	return res
+}
+
+// Reset discards the recorded calls.
+func (r *RecordingClient) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.calls = nil
+}
+
+// record appends a call, redacting any token secrets.
+func (r *RecordingClient) record(method string, args, results []interface{}, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.calls = append(r.calls, Call{
+		Method:  method,
+		Args:    redactSecrets(args),
+		Results: redactSecrets(results),
+		Err:     err,
+	})
+}
+
+// redactSecrets returns a copy of values with token secrets replaced.
+func redactSecrets(values []interface{}) []interface{} {
+	if values == nil {
+		return nil
+	}
+
+	res := make([]interface{}, 0, len(values))
+	for _, v := range values {
+		switch t := v.(type) {
+		case types.Token:
+			v = redactSecret(t)
+		case []types.Token:
+			tokens := make([]types.Token, 0, len(t))
+			for _, token := range t {
+				tokens = append(tokens, redactSecret(token))
+			}
+			v = tokens
+		}
+		res = append(res, v)
+	}
+	return res
+}
+
+func redactSecret(t types.Token) types.Token {
+	if t.Secret != "" {
+		t.Secret = redactedSecret
+	}
+	return t
+}
+
+// StreamProjectEntries implements Client. Only the call is recorded, not the
+// streamed entries.
+func (r *RecordingClient) StreamProjectEntries(ctx context.Context) (<-chan ProjectEntry, <-chan error) {
+	r.record("StreamProjectEntries", []interface{}{}, nil, nil)
+	return r.client.StreamProjectEntries(ctx)
+}
+
+// CreateProjectEntry implements Client.
+func (r *RecordingClient) CreateProjectEntry(ctx context.Context, pe ProjectEntry) error {
+	err := r.client.CreateProjectEntry(ctx, pe)
+	r.record("CreateProjectEntry", []interface{}{pe}, nil, err)
+	return err
+}
+
+// CreateProjectWithToken implements Client.
+func (r *RecordingClient) CreateProjectWithToken(ctx context.Context, pe ProjectEntry, token types.Token) error {
+	err := r.client.CreateProjectWithToken(ctx, pe, token)
+	r.record("CreateProjectWithToken", []interface{}{pe, token}, nil, err)
+	return err
+}
+
+// ImportProject implements Client.
+func (r *RecordingClient) ImportProject(ctx context.Context, data []byte) error {
+	err := r.client.ImportProject(ctx, data)
+	r.record("ImportProject", []interface{}{data}, nil, err)
+	return err
+}
+
+// DeleteProjectEntry implements Client.
+func (r *RecordingClient) DeleteProjectEntry(ctx context.Context, project string) error {
+	err := r.client.DeleteProjectEntry(ctx, project)
+	r.record("DeleteProjectEntry", []interface{}{project}, nil, err)
+	return err
+}
+
+// RestoreProjectEntry implements Client.
+func (r *RecordingClient) RestoreProjectEntry(ctx context.Context, project string) error {
+	err := r.client.RestoreProjectEntry(ctx, project)
+	r.record("RestoreProjectEntry", []interface{}{project}, nil, err)
+	return err
+}
+
+// PurgeDeletedProjects implements Client.
+func (r *RecordingClient) PurgeDeletedProjects(ctx context.Context, now time.Time) (int, error) {
+	res, err := r.client.PurgeDeletedProjects(ctx, now)
+	r.record("PurgeDeletedProjects", []interface{}{now}, []interface{}{res}, err)
+	return res, err
+}
+
+// ReadProjectEntry implements Client.
+func (r *RecordingClient) ReadProjectEntry(ctx context.Context, project string) (ProjectEntry, error) {
+	res, err := r.client.ReadProjectEntry(ctx, project)
+	r.record("ReadProjectEntry", []interface{}{project}, []interface{}{res}, err)
+	return res, err
+}
+
+// ReadProjectEntryWithETag implements Client.
+func (r *RecordingClient) ReadProjectEntryWithETag(ctx context.Context, project string) (ProjectEntry, string, error) {
+	pe, etag, err := r.client.ReadProjectEntryWithETag(ctx, project)
+	r.record("ReadProjectEntryWithETag", []interface{}{project}, []interface{}{pe, etag}, err)
+	return pe, etag, err
+}
+
+// UpdateProjectEntry implements Client.
+func (r *RecordingClient) UpdateProjectEntry(ctx context.Context, pe ProjectEntry) error {
+	err := r.client.UpdateProjectEntry(ctx, pe)
+	r.record("UpdateProjectEntry", []interface{}{pe}, nil, err)
+	return err
+}
+
+// UpdateProjectEntryIfMatch implements Client.
+func (r *RecordingClient) UpdateProjectEntryIfMatch(ctx context.Context, pe ProjectEntry, etag string) error {
+	err := r.client.UpdateProjectEntryIfMatch(ctx, pe, etag)
+	r.record("UpdateProjectEntryIfMatch", []interface{}{pe, etag}, nil, err)
+	return err
+}
+
+// FindDuplicateRepositories implements Client.
+func (r *RecordingClient) FindDuplicateRepositories(ctx context.Context) (map[string][]string, error) {
+	res, err := r.client.FindDuplicateRepositories(ctx)
+	r.record("FindDuplicateRepositories", []interface{}{}, []interface{}{res}, err)
+	return res, err
+}
+
+// ListProjectEntries implements Client.
+func (r *RecordingClient) ListProjectEntries(ctx context.Context, opts ListOptions) ([]ProjectEntry, string, error) {
+	entries, cursor, err := r.client.ListProjectEntries(ctx, opts)
+	r.record("ListProjectEntries", []interface{}{opts}, []interface{}{entries, cursor}, err)
+	return entries, cursor, err
+}
+
+// ListProjectsByRepository implements Client.
+func (r *RecordingClient) ListProjectsByRepository(ctx context.Context, repository string) ([]ProjectEntry, error) {
+	res, err := r.client.ListProjectsByRepository(ctx, repository)
+	r.record("ListProjectsByRepository", []interface{}{repository}, []interface{}{res}, err)
+	return res, err
+}
+
+// ReadProjectActivity implements Client.
+func (r *RecordingClient) ReadProjectActivity(ctx context.Context, project string) (ProjectEntry, time.Time, error) {
+	pe, lastActivity, err := r.client.ReadProjectActivity(ctx, project)
+	r.record("ReadProjectActivity", []interface{}{project}, []interface{}{pe, lastActivity}, err)
+	return pe, lastActivity, err
+}
+
+// ReadProjectsWithTokenCounts implements Client.
+func (r *RecordingClient) ReadProjectsWithTokenCounts(ctx context.Context, ids []string) ([]ProjectSummary, error) {
+	res, err := r.client.ReadProjectsWithTokenCounts(ctx, ids)
+	r.record("ReadProjectsWithTokenCounts", []interface{}{ids}, []interface{}{res}, err)
+	return res, err
+}
+
+// CreateTokenEntry implements Client.
+func (r *RecordingClient) CreateTokenEntry(ctx context.Context, token types.Token) error {
+	err := r.client.CreateTokenEntry(ctx, token)
+	r.record("CreateTokenEntry", []interface{}{token}, nil, err)
+	return err
+}
+
+// CreateTokenEntries implements Client.
+func (r *RecordingClient) CreateTokenEntries(ctx context.Context, tokens []types.Token) error {
+	err := r.client.CreateTokenEntries(ctx, tokens)
+	r.record("CreateTokenEntries", []interface{}{tokens}, nil, err)
+	return err
+}
+
+// IssueToken implements Client.
+func (r *RecordingClient) IssueToken(ctx context.Context, project, roleID string, ttl time.Duration) (types.Token, error) {
+	res, err := r.client.IssueToken(ctx, project, roleID, ttl)
+	r.record("IssueToken", []interface{}{project, roleID, ttl}, []interface{}{res}, err)
+	return res, err
+}
+
+// VerifyTokenSecret implements Client. The secret is never recorded.
+func (r *RecordingClient) VerifyTokenSecret(ctx context.Context, token, secret string) (TokenEntry, error) {
+	res, err := r.client.VerifyTokenSecret(ctx, token, secret)
+	r.record("VerifyTokenSecret", []interface{}{token, redactedSecret}, []interface{}{res}, err)
+	return res, err
+}
+
+// VerifyAndTouch implements Client. The secret is never recorded.
+func (r *RecordingClient) VerifyAndTouch(ctx context.Context, project, token, secret string) (TokenEntry, error) {
+	res, err := r.client.VerifyAndTouch(ctx, project, token, secret)
+	r.record("VerifyAndTouch", []interface{}{project, token, redactedSecret}, []interface{}{res}, err)
+	return res, err
+}
+
+// AddPendingSecret implements Client. The secret is never recorded.
+func (r *RecordingClient) AddPendingSecret(ctx context.Context, token string, ttl time.Duration) (string, error) {
+	res, err := r.client.AddPendingSecret(ctx, token, ttl)
+	r.record("AddPendingSecret", []interface{}{token, ttl}, []interface{}{redactedSecret}, err)
+	return res, err
+}
+
+// PromotePendingSecret implements Client.
+func (r *RecordingClient) PromotePendingSecret(ctx context.Context, token string) error {
+	err := r.client.PromotePendingSecret(ctx, token)
+	r.record("PromotePendingSecret", []interface{}{token}, nil, err)
+	return err
+}
+
+// DeleteTokenEntry implements Client.
+func (r *RecordingClient) DeleteTokenEntry(ctx context.Context, token string) error {
+	err := r.client.DeleteTokenEntry(ctx, token)
+	r.record("DeleteTokenEntry", []interface{}{token}, nil, err)
+	return err
+}
+
+// DeleteExpiredTokens implements Client.
+func (r *RecordingClient) DeleteExpiredTokens(ctx context.Context, project string, now time.Time) (int, error) {
+	res, err := r.client.DeleteExpiredTokens(ctx, project, now)
+	r.record("DeleteExpiredTokens", []interface{}{project, now}, []interface{}{res}, err)
+	return res, err
+}
+
+// NextTokenSequence implements Client.
+func (r *RecordingClient) NextTokenSequence(ctx context.Context, project string) (int64, error) {
+	res, err := r.client.NextTokenSequence(ctx, project)
+	r.record("NextTokenSequence", []interface{}{project}, []interface{}{res}, err)
+	return res, err
+}
+
+// ReadTokenEntry implements Client.
+func (r *RecordingClient) ReadTokenEntry(ctx context.Context, token string) (TokenEntry, error) {
+	res, err := r.client.ReadTokenEntry(ctx, token)
+	r.record("ReadTokenEntry", []interface{}{token}, []interface{}{res}, err)
+	return res, err
+}
+
+// ReadTokenEntryScoped implements Client.
+func (r *RecordingClient) ReadTokenEntryScoped(ctx context.Context, project, token string) (TokenEntry, error) {
+	res, err := r.client.ReadTokenEntryScoped(ctx, project, token)
+	r.record("ReadTokenEntryScoped", []interface{}{project, token}, []interface{}{res}, err)
+	return res, err
+}
+
+// CountTokenEntries implements Client.
+func (r *RecordingClient) CountTokenEntries(ctx context.Context, project string) (int, error) {
+	res, err := r.client.CountTokenEntries(ctx, project)
+	r.record("CountTokenEntries", []interface{}{project}, []interface{}{res}, err)
+	return res, err
+}
+
+// PreviewAffectedTokenCount implements Client.
+func (r *RecordingClient) PreviewAffectedTokenCount(ctx context.Context, project string, predicate Predicate) (int, error) {
+	res, err := r.client.PreviewAffectedTokenCount(ctx, project, predicate)
+	r.record("PreviewAffectedTokenCount", []interface{}{project, predicate}, []interface{}{res}, err)
+	return res, err
+}
+
+// ListTokenEntries implements Client.
+func (r *RecordingClient) ListTokenEntries(ctx context.Context, project string) ([]TokenEntry, error) {
+	res, err := r.client.ListTokenEntries(ctx, project)
+	r.record("ListTokenEntries", []interface{}{project}, []interface{}{res}, err)
+	return res, err
+}
+
+// ListTokenEntriesCreatedBetween implements Client.
+func (r *RecordingClient) ListTokenEntriesCreatedBetween(ctx context.Context, project string, start, end time.Time) ([]TokenEntry, error) {
+	res, err := r.client.ListTokenEntriesCreatedBetween(ctx, project, start, end)
+	r.record("ListTokenEntriesCreatedBetween", []interface{}{project, start, end}, []interface{}{res}, err)
+	return res, err
+}
+
+// ListTokenEntriesWithTTL implements Client.
+func (r *RecordingClient) ListTokenEntriesWithTTL(ctx context.Context, project string, now time.Time) ([]TokenWithTTL, error) {
+	res, err := r.client.ListTokenEntriesWithTTL(ctx, project, now)
+	r.record("ListTokenEntriesWithTTL", []interface{}{project, now}, []interface{}{res}, err)
+	return res, err
+}
+
+// ListTokenEntriesByUrgency implements Client.
+func (r *RecordingClient) ListTokenEntriesByUrgency(ctx context.Context, project string, now time.Time) ([]TokenWithTTL, error) {
+	res, err := r.client.ListTokenEntriesByUrgency(ctx, project, now)
+	r.record("ListTokenEntriesByUrgency", []interface{}{project, now}, []interface{}{res}, err)
+	return res, err
+}
+
+// ListTokenEntriesPaged implements Client.
+func (r *RecordingClient) ListTokenEntriesPaged(ctx context.Context, project string, limit int, cursor string) (ListTokenEntriesResult, error) {
+	res, err := r.client.ListTokenEntriesPaged(ctx, project, limit, cursor)
+	r.record("ListTokenEntriesPaged", []interface{}{project, limit, cursor}, []interface{}{res}, err)
+	return res, err
+}
+
+// ListExpiredTokenEntriesGlobal implements Client.
+func (r *RecordingClient) ListExpiredTokenEntriesGlobal(ctx context.Context, now time.Time, limit int) ([]TokenEntry, error) {
+	res, err := r.client.ListExpiredTokenEntriesGlobal(ctx, now, limit)
+	r.record("ListExpiredTokenEntriesGlobal", []interface{}{now, limit}, []interface{}{res}, err)
+	return res, err
+}
+
+// ListTokenChanges implements Client.
+func (r *RecordingClient) ListTokenChanges(ctx context.Context, project, syncToken string) (TokenChangeSet, error) {
+	res, err := r.client.ListTokenChanges(ctx, project, syncToken)
+	r.record("ListTokenChanges", []interface{}{project, syncToken}, []interface{}{res}, err)
+	return res, err
+}
+
+// ListTokenHistory implements Client.
+func (r *RecordingClient) ListTokenHistory(ctx context.Context, project string) ([]TokenHistoryEntry, error) {
+	res, err := r.client.ListTokenHistory(ctx, project)
+	r.record("ListTokenHistory", []interface{}{project}, []interface{}{res}, err)
+	return res, err
+}
+
+// PurgeTokenTombstones implements Client.
+func (r *RecordingClient) PurgeTokenTombstones(ctx context.Context, before time.Time) (int, error) {
+	res, err := r.client.PurgeTokenTombstones(ctx, before)
+	r.record("PurgeTokenTombstones", []interface{}{before}, []interface{}{res}, err)
+	return res, err
+}
+
+// SystemStats implements Client.
+func (r *RecordingClient) SystemStats(ctx context.Context, now time.Time) (SystemStats, error) {
+	res, err := r.client.SystemStats(ctx, now)
+	r.record("SystemStats", []interface{}{now}, []interface{}{res}, err)
+	return res, err
+}
+
+// Health implements Client.
+func (r *RecordingClient) Health(ctx context.Context) error {
+	err := r.client.Health(ctx)
+	r.record("Health", []interface{}{}, nil, err)
+	return err
+}
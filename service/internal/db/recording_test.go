@@ -0,0 +1,91 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/cello-proj/cello/internal/types"
+	"github.com/stretchr/testify/assert"
+)
+
+// memoryProjectClient is a fake Client storing projects and tokens in memory.
+type memoryProjectClient struct {
+	Client
+
+	projects map[string]ProjectEntry
+}
+
+func (c *memoryProjectClient) CreateProjectEntry(ctx context.Context, pe ProjectEntry) error {
+	c.projects[pe.ProjectID] = pe
+	return nil
+}
+
+func (c *memoryProjectClient) ReadProjectEntry(ctx context.Context, project string) (ProjectEntry, error) {
+	pe, ok := c.projects[project]
+	if !ok {
+		return ProjectEntry{}, ErrProjectNotFound
+	}
+	return pe, nil
+}
+
+func (c *memoryProjectClient) IssueToken(ctx context.Context, project, roleID string, ttl time.Duration) (types.Token, error) {
+	return types.Token{ProjectID: project, RoleID: roleID, Secret: "s3cr3t"}, nil
+}
+
+func (c *memoryProjectClient) CreateTokenEntries(ctx context.Context, tokens []types.Token) error {
+	return nil
+}
+
+func TestRecordingClient(t *testing.T) {
+	ctx := context.Background()
+	r := NewRecordingClient(&memoryProjectClient{projects: map[string]ProjectEntry{}})
+
+	pe := ProjectEntry{ProjectID: "project1", Repository: "repo"}
+	assert.Nil(t, r.CreateProjectEntry(ctx, pe))
+
+	got, err := r.ReadProjectEntry(ctx, "project1")
+	assert.Nil(t, err)
+	assert.Equal(t, pe, got)
+
+	_, err = r.ReadProjectEntry(ctx, "project2")
+	assert.True(t, errors.Is(err, ErrProjectNotFound))
+
+	assert.Equal(t, []string{"CreateProjectEntry", "ReadProjectEntry", "ReadProjectEntry"}, r.Methods())
+	assert.Equal(t, []Call{
+		{Method: "CreateProjectEntry", Args: []interface{}{pe}},
+		{Method: "ReadProjectEntry", Args: []interface{}{"project1"}, Results: []interface{}{pe}},
+		{Method: "ReadProjectEntry", Args: []interface{}{"project2"}, Results: []interface{}{ProjectEntry{}}, Err: ErrProjectNotFound},
+	}, r.Calls())
+
+	r.Reset()
+	assert.Empty(t, r.Calls())
+}
+
+func TestRecordingClientRedactsSecrets(t *testing.T) {
+	r := NewRecordingClient(&memoryProjectClient{})
+
+	token, err := r.IssueToken(context.Background(), "project1", "role-id", time.Hour)
+	assert.Nil(t, err)
+	assert.Equal(t, "s3cr3t", token.Secret)
+
+	calls := r.Calls()
+	assert.Len(t, calls, 1)
+	assert.Equal(t, redactedSecret, calls[0].Results[0].(types.Token).Secret)
+}
+
+func TestRecordingClientRedactsBatchSecrets(t *testing.T) {
+	r := NewRecordingClient(&memoryProjectClient{})
+
+	tokens := []types.Token{{ProjectID: "project1", Secret: "s3cr3t"}, {ProjectID: "project1"}}
+	assert.Nil(t, r.CreateTokenEntries(context.Background(), tokens))
+	assert.Equal(t, "s3cr3t", tokens[0].Secret)
+
+	calls := r.Calls()
+	assert.Len(t, calls, 1)
+	assert.Equal(t, []types.Token{
+		{ProjectID: "project1", Secret: redactedSecret},
+		{ProjectID: "project1"},
+	}, calls[0].Args[0])
+}
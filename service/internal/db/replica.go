@@ -0,0 +1,60 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	"github.com/upper/db/v4"
+)
+
+// replicaLagQuery reports how far the replica trails the primary. A replica
+// that has replayed everything it received is considered current even when
+// the primary has been idle and the last replayed transaction is old.
+const replicaLagQuery = `SELECT COALESCE(
+	CASE WHEN pg_last_wal_receive_lsn() = pg_last_wal_replay_lsn() THEN 0
+	ELSE EXTRACT(EPOCH FROM now() - pg_last_xact_replay_timestamp()) END, 0)`
+
+// WithReadReplica serves ListTokenEntries from the replica at host. When the
+// replica cannot be reached, its lag cannot be determined or it exceeds
+// maxLag, the primary is used instead.
+func WithReadReplica(host string, maxLag time.Duration) Option {
+	return func(c *SQLClient) {
+		c.replicaHost = host
+		c.maxReplicaLag = maxLag
+	}
+}
+
+// replicaLag returns the replication lag of the replica behind sess.
+func replicaLag(ctx context.Context, sess db.Session) (time.Duration, error) {
+	row, err := sess.WithContext(ctx).SQL().QueryRow(replicaLagQuery)
+	if err != nil {
+		return 0, err
+	}
+
+	var seconds float64
+	if err := row.Scan(&seconds); err != nil {
+		return 0, err
+	}
+
+	return time.Duration(seconds * float64(time.Second)), nil
+}
+
+// readSession returns the session for the read replica when one is configured
+// and within the staleness bound, otherwise the session for the primary.
+func (d SQLClient) readSession(ctx context.Context) (db.Session, error) {
+	if d.replicaHost == "" {
+		return d.createSession()
+	}
+
+	replica, err := d.session(d.replica, d.replicaHost)
+	if err != nil {
+		return d.createSession()
+	}
+
+	lag, err := d.replicaLag(ctx, replica)
+	if err != nil || lag > d.maxReplicaLag {
+		return d.createSession()
+	}
+
+	return replica, nil
+}
@@ -0,0 +1,103 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/upper/db/v4"
+	"github.com/upper/db/v4/adapter/postgresql"
+)
+
+// fakeSession is a db.Session that records which host it was opened for and
+// how it was configured.
+type fakeSession struct {
+	db.Session
+
+	host            string
+	closed          bool
+	maxOpenConns    int
+	maxIdleConns    int
+	connMaxLifetime time.Duration
+}
+
+func (s *fakeSession) Close() error {
+	s.closed = true
+	return nil
+}
+
+func (s *fakeSession) SetMaxOpenConns(n int) {
+	s.maxOpenConns = n
+}
+
+func (s *fakeSession) SetMaxIdleConns(n int) {
+	s.maxIdleConns = n
+}
+
+func (s *fakeSession) SetConnMaxLifetime(d time.Duration) {
+	s.connMaxLifetime = d
+}
+
+func TestReadSession(t *testing.T) {
+	errConn := errors.New("connection refused")
+
+	tests := []struct {
+		name        string
+		replicaHost string
+		openErr     error
+		lag         time.Duration
+		lagErr      error
+		want        string
+	}{
+		{
+			name: "no replica uses primary",
+			want: "primary",
+		},
+		{
+			name:        "replica within bound",
+			replicaHost: "replica",
+			lag:         time.Second,
+			want:        "replica",
+		},
+		{
+			name:        "replica lag exceeds bound falls back to primary",
+			replicaHost: "replica",
+			lag:         time.Minute,
+			want:        "primary",
+		},
+		{
+			name:        "replica lag error falls back to primary",
+			replicaHost: "replica",
+			lagErr:      errConn,
+			want:        "primary",
+		},
+		{
+			name:        "replica unreachable falls back to primary",
+			replicaHost: "replica",
+			openErr:     errConn,
+			want:        "primary",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := NewSQLClient("primary", "cello", "user", "pass", nil, WithReadReplica(tt.replicaHost, 5*time.Second))
+			c.open = func(settings db.ConnectionURL) (db.Session, error) {
+				host := settings.(postgresql.ConnectionURL).Host
+				if host == "replica" && tt.openErr != nil {
+					return nil, tt.openErr
+				}
+				return &fakeSession{host: host}, nil
+			}
+			c.replicaLag = func(ctx context.Context, sess db.Session) (time.Duration, error) {
+				return tt.lag, tt.lagErr
+			}
+
+			sess, err := c.readSession(context.Background())
+			assert.Nil(t, err)
+			assert.Equal(t, tt.want, sess.(*fakeSession).host)
+		})
+	}
+}
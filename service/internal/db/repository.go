@@ -0,0 +1,103 @@
+package db
+
+import (
+	"context"
+	"strings"
+
+	"github.com/upper/db/v4"
+)
+
+// normalizeRepository returns the canonical form of a git repository URI so
+// that trivially different spellings of the same repository match: case, a
+// trailing slash and a .git suffix are ignored.
+func normalizeRepository(repository string) string {
+	repository = strings.ToLower(strings.TrimSpace(repository))
+	repository = strings.TrimRight(repository, "/")
+	return strings.TrimSuffix(repository, ".git")
+}
+
+// repositoryVariants returns the lowercased stored spellings that refer to
+// repository: its normalized form with each combination of the .git suffix
+// and trailing slash that normalizeRepository removes.
+func repositoryVariants(repository string) []string {
+	n := normalizeRepository(repository)
+
+	res := []string{}
+	for _, suffix := range []string{"", ".git"} {
+		for _, slash := range []string{"", "/"} {
+			res = append(res, n+suffix+slash)
+		}
+	}
+	return res
+}
+
+// ListProjectsByRepository returns all projects configured with the
+// repository, ordered by project id.
+func (d SQLClient) ListProjectsByRepository(ctx context.Context, repository string) ([]ProjectEntry, error) {
+	ctx, cancel := d.withTimeout(ctx)
+	defer cancel()
+
+	res := []ProjectEntry{}
+
+	sess, err := d.createSession()
+	if err != nil {
+		return res, err
+	}
+
+	err = sess.WithContext(ctx).Collection(ProjectEntryDB).
+		Find(liveProject(db.Cond{}), db.Raw("LOWER(repository) IN ?", repositoryVariants(repository))).
+		OrderBy("project").
+		All(&res)
+	return res, err
+}
+
+// FindDuplicateRepositories returns each repository mapped to more than one
+// project, along with the ids of those projects. Repositories are compared,
+// and keyed, in their normalized form.
+func (d SQLClient) FindDuplicateRepositories(ctx context.Context) (map[string][]string, error) {
+	ctx, cancel := d.withTimeout(ctx)
+	defer cancel()
+
+	sess, err := d.createSession()
+	if err != nil {
+		return nil, err
+	}
+
+	// Normalization can't be expressed in the grouping query, so the
+	// repositories are grouped here.
+	entries := []ProjectEntry{}
+	err = sess.WithContext(ctx).SQL().
+		Select("project", "repository").
+		From(ProjectEntryDB).
+		Where(db.Cond{"deleted_at IS": nil}).
+		OrderBy("project").
+		All(&entries)
+	if err != nil {
+		return nil, err
+	}
+
+	return duplicateRepositories(entries), nil
+}
+
+// groupByRepository maps each normalized repository to the ids of its
+// projects.
+func groupByRepository(entries []ProjectEntry) map[string][]string {
+	res := map[string][]string{}
+	for _, e := range entries {
+		repository := normalizeRepository(e.Repository)
+		res[repository] = append(res[repository], e.ProjectID)
+	}
+	return res
+}
+
+// duplicateRepositories groups the entries by repository, keeping only the
+// repositories with more than one project.
+func duplicateRepositories(entries []ProjectEntry) map[string][]string {
+	res := groupByRepository(entries)
+	for repository, projects := range res {
+		if len(projects) < 2 {
+			delete(res, repository)
+		}
+	}
+	return res
+}
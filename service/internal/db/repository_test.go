@@ -0,0 +1,115 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeRepository(t *testing.T) {
+	tests := []struct {
+		name       string
+		repository string
+		want       string
+	}{
+		{"unchanged", "git@github.com:cello-proj/cello", "git@github.com:cello-proj/cello"},
+		{"git suffix", "git@github.com:cello-proj/cello.git", "git@github.com:cello-proj/cello"},
+		{"trailing slash", "https://github.com/cello-proj/cello/", "https://github.com/cello-proj/cello"},
+		{"whitespace", "  https://github.com/cello-proj/cello.git ", "https://github.com/cello-proj/cello"},
+		{"case", "git@GitHub.com:Cello-Proj/Cello.git", "git@github.com:cello-proj/cello"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, normalizeRepository(tt.repository))
+		})
+	}
+}
+
+func TestRepositoryVariants(t *testing.T) {
+	stored := []string{
+		"git@github.com:cello-proj/cello.git",
+		"git@github.com:cello-proj/cello",
+		"git@github.com:cello-proj/cello/",
+		"git@github.com:cello-proj/cello.git/",
+	}
+
+	for _, s := range stored {
+		assert.Contains(t, repositoryVariants("git@github.com:cello-proj/cello.git"), s)
+		assert.Contains(t, repositoryVariants(s), "git@github.com:cello-proj/cello.git")
+	}
+	for _, v := range repositoryVariants("git@github.com:Cello-Proj/cello/") {
+		assert.Equal(t, "git@github.com:cello-proj/cello", normalizeRepository(v))
+	}
+	assert.NotContains(t, repositoryVariants("git@github.com:cello-proj/cello.git"), "git@github.com:cello-proj/other.git")
+}
+
+func TestGroupByRepository(t *testing.T) {
+	entries := []ProjectEntry{
+		{ProjectID: "project1", Repository: "git@github.com:cello-proj/cello.git"},
+		{ProjectID: "project2", Repository: "git@github.com:Cello-Proj/cello/"},
+		{ProjectID: "project3", Repository: "git@github.com:cello-proj/other.git"},
+	}
+
+	want := map[string][]string{
+		"git@github.com:cello-proj/cello": {"project1", "project2"},
+		"git@github.com:cello-proj/other": {"project3"},
+	}
+
+	assert.Equal(t, want, groupByRepository(entries))
+	assert.Equal(t, map[string][]string{}, groupByRepository(nil))
+}
+
+func TestFindDuplicateRepositories(t *testing.T) {
+	c, mock := newMockSQLClient(t)
+
+	mock.ExpectQuery(`SELECT "project", "repository" FROM "projects" WHERE \("deleted_at" IS NULL\) ORDER BY "project" ASC`).
+		WillReturnRows(sqlmock.NewRows([]string{"project", "repository"}).
+			AddRow("project1", "git@github.com:cello-proj/cello.git").
+			AddRow("project2", "git@github.com:cello-proj/other.git").
+			AddRow("project3", "git@github.com:Cello-Proj/cello").
+			AddRow("project4", "git@github.com:cello-proj/cello/"))
+
+	got, err := c.FindDuplicateRepositories(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, map[string][]string{"git@github.com:cello-proj/cello": {"project1", "project3", "project4"}}, got)
+}
+
+func TestListProjectsByRepository(t *testing.T) {
+	c, mock := newMockSQLClient(t)
+	expectPrimaryKey(mock, ProjectEntryDB, "project")
+
+	mock.ExpectQuery(`SELECT \* FROM "projects" WHERE \("deleted_at" IS NULL AND LOWER\(repository\) IN \(\$1, \$2, \$3, \$4\)\) ORDER BY "project" ASC`).
+		WithArgs("git@github.com:cello-proj/cello", "git@github.com:cello-proj/cello/", "git@github.com:cello-proj/cello.git", "git@github.com:cello-proj/cello.git/").
+		WillReturnRows(sqlmock.NewRows([]string{"project", "repository"}).
+			AddRow("project1", "git@github.com:Cello-Proj/cello.git"))
+
+	got, err := c.ListProjectsByRepository(context.Background(), "git@github.com:cello-proj/Cello/")
+	assert.Nil(t, err)
+	assert.Equal(t, []ProjectEntry{{ProjectID: "project1", Repository: "git@github.com:Cello-Proj/cello.git"}}, got)
+}
+
+func TestInMemoryClientRepositories(t *testing.T) {
+	ctx := context.Background()
+	c := NewInMemoryClient()
+
+	repositories := map[string]string{
+		"project1": "git@github.com:cello-proj/cello.git",
+		"project2": "git@github.com:Cello-Proj/Cello.git",
+		"project3": "git@github.com:cello-proj/other.git",
+		"project4": "git@github.com:cello-proj/cello.git/",
+	}
+	for project, repository := range repositories {
+		assert.Nil(t, c.CreateProjectEntry(ctx, ProjectEntry{ProjectID: project, Repository: repository}))
+	}
+
+	got, err := c.ListProjectsByRepository(ctx, "git@github.com:cello-proj/cello")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"project1", "project2", "project4"}, []string{got[0].ProjectID, got[1].ProjectID, got[2].ProjectID})
+
+	dups, err := c.FindDuplicateRepositories(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string][]string{"git@github.com:cello-proj/cello": {"project1", "project2", "project4"}}, dups)
+}
@@ -0,0 +1,64 @@
+package db
+
+import (
+	"context"
+	"errors"
+
+	"github.com/upper/db/v4"
+)
+
+// WithScopedProjectCheck makes ReadTokenEntryScoped check whether the project
+// exists when the token is not found, so ErrProjectNotFound can be returned.
+// This costs an extra read on every miss.
+func WithScopedProjectCheck() Option {
+	return func(c *SQLClient) {
+		c.scopedProjectCheck = true
+	}
+}
+
+// ReadTokenEntryScoped returns the token only if it belongs to the project.
+// ErrTokenNotFound is returned on a miss, or ErrProjectNotFound if the
+// project does not exist and WithScopedProjectCheck is enabled.
+func (d SQLClient) ReadTokenEntryScoped(ctx context.Context, project, token string) (TokenEntry, error) {
+	ctx, cancel := d.withTimeout(ctx)
+	defer cancel()
+
+	project = d.projectID(project)
+
+	res := TokenEntry{}
+
+	sess, err := d.createSession()
+	if err != nil {
+		return res, err
+	}
+
+	sess = sess.WithContext(ctx)
+
+	err = sess.Collection(TokenEntryDB).Find(db.Cond{"project": project, "token_id": token}).One(&res)
+	if !errors.Is(err, db.ErrNoMoreRows) {
+		return res, err
+	}
+
+	projectExists := func() (bool, error) {
+		return sess.Collection(ProjectEntryDB).Find(liveProject(db.Cond{"project": project})).Exists()
+	}
+	return res, scopedMissError(d.scopedProjectCheck, projectExists)
+}
+
+// scopedMissError returns the error for a scoped token read that matched no
+// row.
+func scopedMissError(checkProject bool, projectExists func() (bool, error)) error {
+	if !checkProject {
+		return ErrTokenNotFound
+	}
+
+	exists, err := projectExists()
+	if err != nil {
+		return err
+	}
+
+	if !exists {
+		return ErrProjectNotFound
+	}
+	return ErrTokenNotFound
+}
@@ -0,0 +1,93 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadTokenEntryScoped(t *testing.T) {
+	tokenQuery := `SELECT \* FROM "tokens" WHERE \("project" = \$1 AND "token_id" = \$2\) LIMIT 1`
+	projectQuery := `SELECT count\(1\) AS _t FROM "projects" WHERE \("deleted_at" IS NULL AND "project" = \$1\) LIMIT 1`
+
+	tests := []struct {
+		name         string
+		opts         []Option
+		tokenRows    *sqlmock.Rows
+		checkProject bool
+		projectRows  *sqlmock.Rows
+		projectErr   error
+		want         TokenEntry
+		wantErr      error
+	}{
+		{
+			name: "token in project",
+			tokenRows: sqlmock.NewRows([]string{"project", "token_id", "created_at", "expires_at"}).
+				AddRow("project1", "token1", "2022-06-21T14:56:10Z", "2023-06-21T14:56:10Z"),
+			want: TokenEntry{
+				ProjectID: "project1",
+				TokenID:   "token1",
+				CreatedAt: "2022-06-21T14:56:10Z",
+				ExpiresAt: "2023-06-21T14:56:10Z",
+			},
+		},
+		{
+			name:      "token not found without project check",
+			tokenRows: sqlmock.NewRows([]string{"project", "token_id"}),
+			wantErr:   ErrTokenNotFound,
+		},
+		{
+			name:         "token not found when project exists",
+			opts:         []Option{WithScopedProjectCheck()},
+			tokenRows:    sqlmock.NewRows([]string{"project", "token_id"}),
+			checkProject: true,
+			projectRows:  sqlmock.NewRows([]string{"_t"}).AddRow(1),
+			wantErr:      ErrTokenNotFound,
+		},
+		{
+			name:         "project not found",
+			opts:         []Option{WithScopedProjectCheck()},
+			tokenRows:    sqlmock.NewRows([]string{"project", "token_id"}),
+			checkProject: true,
+			projectRows:  sqlmock.NewRows([]string{"_t"}),
+			wantErr:      ErrProjectNotFound,
+		},
+		{
+			name:         "project check error",
+			opts:         []Option{WithScopedProjectCheck()},
+			tokenRows:    sqlmock.NewRows([]string{"project", "token_id"}),
+			checkProject: true,
+			projectErr:   errors.New("connection refused"),
+			wantErr:      errors.New("connection refused"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, mock := newMockSQLClient(t, tt.opts...)
+
+			expectPrimaryKey(mock, TokenEntryDB, "token_id")
+			mock.ExpectQuery(tokenQuery).WithArgs("project1", "token1").WillReturnRows(tt.tokenRows)
+			if tt.checkProject {
+				expectPrimaryKey(mock, ProjectEntryDB, "project")
+				q := mock.ExpectQuery(projectQuery).WithArgs("project1")
+				if tt.projectErr != nil {
+					q.WillReturnError(tt.projectErr)
+				} else {
+					q.WillReturnRows(tt.projectRows)
+				}
+			}
+
+			got, err := c.ReadTokenEntryScoped(context.Background(), "project1", "token1")
+			if tt.wantErr != nil {
+				assert.EqualError(t, err, tt.wantErr.Error())
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,100 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	"github.com/upper/db/v4"
+)
+
+const defaultProjectRetention = 7 * 24 * time.Hour
+
+// WithProjectRetention sets how long a deleted project can be restored before
+// it is purged. Defaults to 7 days.
+func WithProjectRetention(d time.Duration) Option {
+	return func(c *SQLClient) {
+		c.projectRetention = d
+	}
+}
+
+// liveProject restricts cond to projects that have not been deleted.
+func liveProject(cond db.Cond) db.Cond {
+	res := db.Cond{"deleted_at IS": nil}
+	for k, v := range cond {
+		res[k] = v
+	}
+	return res
+}
+
+// RestoreProjectEntry restores a deleted project that has not yet been
+// purged. ErrProjectNotFound is returned if there is no such project.
+//
+// Only the database entry is restored. Deleting a project through the API
+// also removes its Vault role and targets, which are not recoverable from
+// here, so a restored project cannot issue credentials until it has been
+// provisioned in Vault again.
+func (d SQLClient) RestoreProjectEntry(ctx context.Context, project string) error {
+	ctx, cancel := d.withTimeout(ctx)
+	defer cancel()
+
+	project = d.projectID(project)
+
+	sess, err := d.createSession()
+	if err != nil {
+		return err
+	}
+
+	res, err := sess.WithContext(ctx).SQL().
+		Update(ProjectEntryDB).
+		Set("deleted_at", nil).
+		Where(db.Cond{"project": project, "deleted_at >": d.now().Add(-d.projectRetention)}).
+		Exec()
+	if err != nil {
+		return err
+	}
+
+	return notFound(requireRowsAffected(res), ErrProjectNotFound)
+}
+
+// PurgeDeletedProjects permanently deletes projects, and their tokens, that
+// were deleted longer than the retention before now. It returns the number of
+// projects purged.
+func (d SQLClient) PurgeDeletedProjects(ctx context.Context, now time.Time) (int, error) {
+	ctx, cancel := d.withTimeout(ctx)
+	defer cancel()
+
+	sess, err := d.createSession()
+	if err != nil {
+		return 0, err
+	}
+
+	purged := 0
+	err = sess.WithContext(ctx).Tx(func(sess db.Session) error {
+		n, err := purgeDeletedProjects(sess, d.now(), "deleted_at < ?", now.Add(-d.projectRetention))
+		purged = n
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return purged, nil
+}
+
+// purgeDeletedProjects permanently deletes the deleted projects matching the
+// where clause, recording the deletion of their tokens.
+func purgeDeletedProjects(sess db.Session, deletedAt time.Time, where string, args ...interface{}) (int, error) {
+	projects := "SELECT project FROM " + ProjectEntryDB + " WHERE deleted_at IS NOT NULL AND " + where
+
+	if err := recordTokenDeletions(sess, deletedAt, "project IN ("+projects+")", args...); err != nil {
+		return 0, err
+	}
+
+	res, err := sess.SQL().Exec("DELETE FROM "+ProjectEntryDB+" WHERE deleted_at IS NOT NULL AND "+where, args...)
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := res.RowsAffected()
+	return int(n), err
+}
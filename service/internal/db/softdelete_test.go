@@ -0,0 +1,50 @@
+package db
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/upper/db/v4"
+)
+
+func TestLiveProject(t *testing.T) {
+	tests := []struct {
+		name string
+		cond db.Cond
+		want db.Cond
+	}{
+		{
+			name: "no conditions",
+			cond: db.Cond{},
+			want: db.Cond{"deleted_at IS": nil},
+		},
+		{
+			name: "excludes deleted projects",
+			cond: db.Cond{"project": "project1"},
+			want: db.Cond{"deleted_at IS": nil, "project": "project1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, liveProject(tt.cond))
+		})
+	}
+}
+
+func TestLiveProjectDoesNotModifyCond(t *testing.T) {
+	cond := db.Cond{"project": "project1"}
+	liveProject(cond)
+	assert.Equal(t, db.Cond{"project": "project1"}, cond)
+}
+
+func TestProjectRetention(t *testing.T) {
+	c, err := NewSQLClient("localhost", "cello", "cello", "", nil)
+	assert.Nil(t, err)
+	assert.Equal(t, 7*24*time.Hour, c.projectRetention)
+
+	c, err = NewSQLClient("localhost", "cello", "cello", "", nil, WithProjectRetention(time.Hour))
+	assert.Nil(t, err)
+	assert.Equal(t, time.Hour, c.projectRetention)
+}
@@ -0,0 +1,42 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/upper/db/v4"
+	"github.com/upper/db/v4/adapter/postgresql"
+)
+
+// newMockSQLClient returns a SQLClient whose statements are served by
+// sqlmock. The expectations must all be met by the end of the test.
+func newMockSQLClient(t *testing.T, opts ...Option) (SQLClient, sqlmock.Sqlmock) {
+	sqlDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("unable to create sqlmock: %v", err)
+	}
+
+	c, err := NewSQLClient("localhost", "cello", "cello", "", nil, opts...)
+	if err != nil {
+		t.Fatalf("unable to create client: %v", err)
+	}
+	c.open = func(db.ConnectionURL) (db.Session, error) {
+		return postgresql.New(sqlDB)
+	}
+
+	mock.ExpectQuery(`SELECT\s+CURRENT_DATABASE\(\)`).
+		WillReturnRows(sqlmock.NewRows([]string{"name"}).AddRow("cello"))
+
+	t.Cleanup(func() {
+		assert.Nil(t, mock.ExpectationsWereMet())
+	})
+	return c, mock
+}
+
+// expectPrimaryKey expects the primary key lookup upper/db makes the first
+// time a collection is written through a session.
+func expectPrimaryKey(mock sqlmock.Sqlmock, table, pkey string) {
+	mock.ExpectQuery(`SELECT\s+"pg_attribute"."attname" AS "pkey".*'"` + table + `"'::regclass`).
+		WillReturnRows(sqlmock.NewRows([]string{"pkey"}).AddRow(pkey))
+}
@@ -0,0 +1,50 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// SystemStats summarizes projects and tokens across the whole system.
+type SystemStats struct {
+	TotalProjects int64 `json:"total_projects"`
+	TotalTokens   int64 `json:"total_tokens"`
+	ExpiredTokens int64 `json:"expired_tokens"`
+	// NextExpiry is the soonest expiry at or after now, or the zero time if
+	// every token has expired.
+	NextExpiry time.Time `json:"next_expiry"`
+}
+
+// SystemStats returns aggregate project and token statistics as of now. Only
+// tokens of live projects are counted. A token is expired once its expiry is
+// before now, matching DeleteExpiredTokens.
+func (d SQLClient) SystemStats(ctx context.Context, now time.Time) (SystemStats, error) {
+	ctx, cancel := d.withTimeout(ctx)
+	defer cancel()
+
+	res := SystemStats{}
+
+	sess, err := d.createSession()
+	if err != nil {
+		return res, err
+	}
+
+	row, err := sess.WithContext(ctx).SQL().QueryRow(
+		"SELECT (SELECT COUNT(*) FROM "+ProjectEntryDB+" WHERE deleted_at IS NULL), "+
+			"COUNT(t.token_id), COUNT(t.token_id) FILTER (WHERE t.expires_at < ?), MIN(t.expires_at) FILTER (WHERE t.expires_at >= ?) "+
+			"FROM "+TokenEntryDB+" t JOIN "+ProjectEntryDB+" p ON p.project = t.project AND p.deleted_at IS NULL",
+		now, now,
+	)
+	if err != nil {
+		return res, err
+	}
+
+	var nextExpiry sql.NullTime
+	if err := row.Scan(&res.TotalProjects, &res.TotalTokens, &res.ExpiredTokens, &nextExpiry); err != nil {
+		return res, err
+	}
+	res.NextExpiry = nextExpiry.Time
+
+	return res, nil
+}
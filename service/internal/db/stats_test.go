@@ -0,0 +1,75 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSystemStats(t *testing.T) {
+	c, mock := newMockSQLClient(t)
+	now := time.Date(2022, 1, 1, 12, 0, 0, 0, time.UTC)
+	next := now.Add(time.Hour)
+
+	mock.ExpectQuery(`SELECT \(SELECT COUNT\(\*\) FROM projects WHERE deleted_at IS NULL\), `+
+		`COUNT\(t.token_id\), COUNT\(t.token_id\) FILTER \(WHERE t.expires_at < \$1\), MIN\(t.expires_at\) FILTER \(WHERE t.expires_at >= \$2\) `+
+		`FROM tokens t JOIN projects p ON p.project = t.project AND p.deleted_at IS NULL`).
+		WithArgs(now, now).
+		WillReturnRows(sqlmock.NewRows([]string{"projects", "tokens", "expired", "next"}).AddRow(2, 5, 1, next))
+
+	got, err := c.SystemStats(context.Background(), now)
+	assert.Nil(t, err)
+	assert.Equal(t, SystemStats{TotalProjects: 2, TotalTokens: 5, ExpiredTokens: 1, NextExpiry: next}, got)
+}
+
+func TestSystemStatsNoUpcomingExpiry(t *testing.T) {
+	c, mock := newMockSQLClient(t)
+	now := time.Date(2022, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	mock.ExpectQuery(`SELECT`).
+		WillReturnRows(sqlmock.NewRows([]string{"projects", "tokens", "expired", "next"}).AddRow(1, 0, 0, nil))
+
+	got, err := c.SystemStats(context.Background(), now)
+	assert.Nil(t, err)
+	assert.Equal(t, SystemStats{TotalProjects: 1}, got)
+}
+
+func TestInMemoryClientSystemStats(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2022, 1, 1, 12, 0, 0, 0, time.UTC)
+	c := NewInMemoryClient()
+
+	for _, project := range []string{"project1", "project2"} {
+		assert.Nil(t, c.CreateProjectEntry(ctx, ProjectEntry{ProjectID: project, Repository: testRepository}))
+	}
+
+	// Expired an hour ago, expiring exactly now, and expiring in an hour.
+	assert.Nil(t, c.CreateTokenEntry(ctx, testToken("project1", "token1", now.Add(-2*time.Hour))))
+	assert.Nil(t, c.CreateTokenEntry(ctx, testToken("project1", "token2", now.Add(-time.Hour))))
+	assert.Nil(t, c.CreateTokenEntry(ctx, testToken("project1", "token3", now)))
+	// Tokens of deleted projects are not counted.
+	assert.Nil(t, c.CreateTokenEntry(ctx, testToken("project2", "token4", now)))
+	assert.Nil(t, c.DeleteProjectEntry(ctx, "project2"))
+
+	got, err := c.SystemStats(ctx, now)
+	assert.Nil(t, err)
+	assert.Equal(t, SystemStats{TotalProjects: 1, TotalTokens: 3, ExpiredTokens: 1, NextExpiry: now}, got)
+
+	// DeleteExpiredTokens agrees on which tokens are expired.
+	deleted, err := c.DeleteExpiredTokens(ctx, "project1", now)
+	assert.Nil(t, err)
+	assert.Equal(t, int(got.ExpiredTokens), deleted)
+}
+
+func TestSystemStatsError(t *testing.T) {
+	c, mock := newMockSQLClient(t)
+
+	mock.ExpectQuery(`SELECT`).WillReturnError(errors.New("boom"))
+
+	_, err := c.SystemStats(context.Background(), time.Now())
+	assert.EqualError(t, err, "boom")
+}
@@ -0,0 +1,72 @@
+package db
+
+import (
+	"context"
+
+	"github.com/upper/db/v4"
+)
+
+// rowIterator iterates over query results. It is satisfied by db.Result.
+type rowIterator interface {
+	Next(ptr interface{}) bool
+	Err() error
+	Close() error
+}
+
+// StreamProjectEntries emits every project, ordered by project id, without
+// loading them all into memory. The entries channel is closed once all
+// projects have been sent, an error occurs or the context is cancelled. At
+// most one error, including the context error on cancellation, is sent on the
+// error channel before it is closed.
+func (d SQLClient) StreamProjectEntries(ctx context.Context) (<-chan ProjectEntry, <-chan error) {
+	sess, err := d.createSession()
+	if err != nil {
+		out := make(chan ProjectEntry)
+		errc := make(chan error, 1)
+		errc <- err
+		close(out)
+		close(errc)
+		return out, errc
+	}
+
+	res := sess.WithContext(ctx).Collection(ProjectEntryDB).Find(liveProject(db.Cond{})).OrderBy("project")
+	return streamProjectEntries(ctx, res)
+}
+
+// streamProjectEntries sends each project from the iterator, closing it once
+// iteration stops.
+func streamProjectEntries(ctx context.Context, it rowIterator) (<-chan ProjectEntry, <-chan error) {
+	out := make(chan ProjectEntry)
+	errc := make(chan error, 1)
+
+	go func() {
+		defer close(errc)
+		defer close(out)
+		defer it.Close()
+
+		for {
+			if err := ctx.Err(); err != nil {
+				errc <- err
+				return
+			}
+
+			pe := ProjectEntry{}
+			if !it.Next(&pe) {
+				break
+			}
+
+			select {
+			case out <- pe:
+			case <-ctx.Done():
+				errc <- ctx.Err()
+				return
+			}
+		}
+
+		if err := it.Err(); err != nil {
+			errc <- err
+		}
+	}()
+
+	return out, errc
+}
@@ -0,0 +1,91 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeIterator iterates over a fixed set of projects, optionally failing
+// after them.
+type fakeIterator struct {
+	entries []ProjectEntry
+	err     error
+	closed  bool
+}
+
+func (it *fakeIterator) Next(ptr interface{}) bool {
+	if len(it.entries) == 0 {
+		return false
+	}
+
+	*ptr.(*ProjectEntry) = it.entries[0]
+	it.entries = it.entries[1:]
+	return true
+}
+
+func (it *fakeIterator) Err() error {
+	return it.err
+}
+
+func (it *fakeIterator) Close() error {
+	it.closed = true
+	return nil
+}
+
+func TestStreamProjectEntries(t *testing.T) {
+	errQuery := errors.New("connection reset")
+
+	tests := []struct {
+		name    string
+		entries []ProjectEntry
+		err     error
+	}{
+		{
+			name:    "streams all projects",
+			entries: []ProjectEntry{{ProjectID: "project1"}, {ProjectID: "project2"}, {ProjectID: "project3"}},
+		},
+		{
+			name: "no projects",
+		},
+		{
+			name:    "error is propagated after sent projects",
+			entries: []ProjectEntry{{ProjectID: "project1"}},
+			err:     errQuery,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			it := &fakeIterator{entries: append([]ProjectEntry{}, tt.entries...), err: tt.err}
+			out, errc := streamProjectEntries(context.Background(), it)
+
+			got := []ProjectEntry{}
+			for pe := range out {
+				got = append(got, pe)
+			}
+
+			assert.Equal(t, append([]ProjectEntry{}, tt.entries...), got)
+			assert.Equal(t, tt.err, <-errc)
+			assert.True(t, it.closed)
+		})
+	}
+}
+
+func TestStreamProjectEntriesCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	it := &fakeIterator{entries: []ProjectEntry{{ProjectID: "project1"}, {ProjectID: "project2"}, {ProjectID: "project3"}}}
+	out, errc := streamProjectEntries(ctx, it)
+
+	assert.Equal(t, ProjectEntry{ProjectID: "project1"}, <-out)
+	cancel()
+
+	for range out {
+	}
+
+	assert.Equal(t, context.Canceled, <-errc)
+	assert.True(t, it.closed)
+}
@@ -0,0 +1,62 @@
+package db
+
+import (
+	"context"
+
+	"github.com/upper/db/v4"
+)
+
+// ProjectSummary is a project along with its number of tokens.
+type ProjectSummary struct {
+	ProjectEntry `db:",inline"`
+	TokenCount   int `db:"token_count"`
+}
+
+// ReadProjectsWithTokenCounts returns the projects with the ids, ordered by
+// project id, along with each one's token count. Projects that do not exist
+// are omitted.
+func (d SQLClient) ReadProjectsWithTokenCounts(ctx context.Context, ids []string) ([]ProjectSummary, error) {
+	ctx, cancel := d.withTimeout(ctx)
+	defer cancel()
+
+	res := []ProjectSummary{}
+
+	ids = d.projectIDs(ids)
+	if len(ids) == 0 {
+		return res, nil
+	}
+
+	sess, err := d.createSession()
+	if err != nil {
+		return nil, err
+	}
+
+	err = sess.WithContext(ctx).SQL().
+		Select("p.project", "p.repository", "p.quota", db.Raw("COUNT(t.token_id) AS token_count")).
+		From(ProjectEntryDB+" AS p").
+		LeftJoin(TokenEntryDB+" AS t").On("t.project = p.project").
+		Where("p.project IN ? AND p.deleted_at IS NULL", ids).
+		GroupBy("p.project").
+		OrderBy("p.project").
+		All(&res)
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// projectIDs canonicalizes the project ids, dropping duplicates.
+func (d SQLClient) projectIDs(ids []string) []string {
+	seen := map[string]bool{}
+	res := []string{}
+	for _, id := range ids {
+		id = d.projectID(id)
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		res = append(res, id)
+	}
+	return res
+}
@@ -0,0 +1,79 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/upper/db/v4"
+)
+
+func TestProjectIDs(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []Option
+		ids  []string
+		want []string
+	}{
+		{
+			name: "no ids",
+			want: []string{},
+		},
+		{
+			name: "duplicates dropped",
+			ids:  []string{"project1", "project2", "project1"},
+			want: []string{"project1", "project2"},
+		},
+		{
+			name: "lowercased duplicates dropped",
+			opts: []Option{WithLowercaseProjectIDs()},
+			ids:  []string{"Project1", "project1", "PROJECT2"},
+			want: []string{"project1", "project2"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := NewSQLClient("localhost", "cello", "cello", "", nil, tt.opts...)
+			assert.Nil(t, err)
+			assert.Equal(t, tt.want, c.projectIDs(tt.ids))
+		})
+	}
+}
+
+func TestReadProjectsWithTokenCounts(t *testing.T) {
+	c, mock := newMockSQLClient(t, WithLowercaseProjectIDs())
+
+	// Projects without tokens are kept by the left join with a count of zero.
+	mock.ExpectQuery(`SELECT "p"."project", "p"."repository", "p"."quota", COUNT\(t.token_id\) AS token_count `+
+		`FROM "projects" AS "p" LEFT JOIN "tokens" AS "t" ON \(t.project = p.project\) `+
+		`WHERE \(p.project IN \(\$1, \$2\) AND p.deleted_at IS NULL\) GROUP BY "p"."project" ORDER BY "p"."project" ASC`).
+		WithArgs("project1", "project2").
+		WillReturnRows(sqlmock.NewRows([]string{"project", "repository", "quota", "token_count"}).
+			AddRow("project1", testRepository, nil, 2).
+			AddRow("project2", testRepository, `{"max_tokens":5}`, 0))
+
+	got, err := c.ReadProjectsWithTokenCounts(context.Background(), []string{"Project1", "project2", "PROJECT1"})
+	assert.Nil(t, err)
+	assert.Equal(t, []ProjectSummary{
+		{ProjectEntry: ProjectEntry{ProjectID: "project1", Repository: testRepository}, TokenCount: 2},
+		{ProjectEntry: ProjectEntry{ProjectID: "project2", Repository: testRepository, Quota: ProjectQuota{MaxTokens: 5}}, TokenCount: 0},
+	}, got)
+}
+
+func TestReadProjectsWithTokenCountsNoIDs(t *testing.T) {
+	c, err := NewSQLClient("localhost", "cello", "cello", "", nil)
+	assert.Nil(t, err)
+
+	opened := false
+	c.open = func(db.ConnectionURL) (db.Session, error) {
+		opened = true
+		return &fakeSession{}, nil
+	}
+
+	res, err := c.ReadProjectsWithTokenCounts(context.Background(), nil)
+	assert.Nil(t, err)
+	assert.Empty(t, res)
+	assert.False(t, opened, "store must not be touched")
+}
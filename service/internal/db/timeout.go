@@ -0,0 +1,29 @@
+package db
+
+import (
+	"context"
+	"time"
+)
+
+// WithDefaultTimeout bounds each call whose context has no deadline to d. A
+// deadline already set by the caller is left as is. The zero value, the
+// default, applies no timeout. StreamProjectEntries is not bounded as a
+// stream may legitimately run longer than any single query.
+func WithDefaultTimeout(d time.Duration) Option {
+	return func(c *SQLClient) {
+		c.defaultTimeout = d
+	}
+}
+
+// withTimeout applies the default timeout to ctx if it has no deadline.
+func (d SQLClient) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if d.defaultTimeout <= 0 {
+		return ctx, func() {}
+	}
+
+	if _, ok := ctx.Deadline(); ok {
+		return ctx, func() {}
+	}
+
+	return context.WithTimeout(ctx, d.defaultTimeout)
+}
@@ -0,0 +1,92 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/upper/db/v4"
+)
+
+// slowSession is a db.Session whose Ping blocks until its context is done.
+type slowSession struct {
+	fakeSession
+
+	ctx context.Context
+}
+
+func (s *slowSession) WithContext(ctx context.Context) db.Session {
+	return &slowSession{ctx: ctx}
+}
+
+func (s *slowSession) Ping() error {
+	<-s.ctx.Done()
+	return s.ctx.Err()
+}
+
+func TestDefaultTimeout(t *testing.T) {
+	c, err := NewSQLClient("localhost", "cello", "cello", "", nil, WithDefaultTimeout(10*time.Millisecond))
+	assert.Nil(t, err)
+	c.open = func(db.ConnectionURL) (db.Session, error) {
+		return &slowSession{}, nil
+	}
+
+	start := time.Now()
+	err = c.Health(context.Background())
+	assert.Equal(t, context.DeadlineExceeded, err)
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+func TestWithTimeout(t *testing.T) {
+	callerDeadline := time.Now().Add(time.Hour)
+
+	tests := []struct {
+		name           string
+		defaultTimeout time.Duration
+		ctx            func() (context.Context, context.CancelFunc)
+		wantDeadline   bool
+		want           time.Time
+	}{
+		{
+			name: "no default timeout",
+			ctx: func() (context.Context, context.CancelFunc) {
+				return context.Background(), func() {}
+			},
+		},
+		{
+			name:           "default timeout applied",
+			defaultTimeout: time.Minute,
+			ctx: func() (context.Context, context.CancelFunc) {
+				return context.Background(), func() {}
+			},
+			wantDeadline: true,
+		},
+		{
+			name:           "caller deadline kept",
+			defaultTimeout: time.Minute,
+			ctx: func() (context.Context, context.CancelFunc) {
+				return context.WithDeadline(context.Background(), callerDeadline)
+			},
+			wantDeadline: true,
+			want:         callerDeadline,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parent, cancelParent := tt.ctx()
+			defer cancelParent()
+
+			c := SQLClient{defaultTimeout: tt.defaultTimeout}
+			ctx, cancel := c.withTimeout(parent)
+			defer cancel()
+
+			deadline, ok := ctx.Deadline()
+			assert.Equal(t, tt.wantDeadline, ok)
+			if !tt.want.IsZero() {
+				assert.Equal(t, tt.want, deadline)
+			}
+		})
+	}
+}
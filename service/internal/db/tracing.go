@@ -0,0 +1,408 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	"github.com/cello-proj/cello/internal/types"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+const tracerName = "github.com/cello-proj/cello/service/internal/db"
+
+var _ Client = (*TracingClient)(nil)
+
+// TracingClient wraps a Client and starts an OpenTelemetry span named
+// db.<Method> around each call. Spans carry the backend, the table and,
+// where known, the project. Token secrets are never recorded.
+type TracingClient struct {
+	client  Client
+	backend string
+	tracer  trace.Tracer
+}
+
+// NewTracingClient returns a TracingClient wrapping c. Backend is recorded on
+// each span, e.g. BackendPostgres. A nil tp disables tracing.
+func NewTracingClient(c Client, backend string, tp trace.TracerProvider) *TracingClient {
+	if tp == nil {
+		tp = noop.NewTracerProvider()
+	}
+
+	return &TracingClient{
+		client:  c,
+		backend: backend,
+		tracer:  tp.Tracer(tracerName),
+	}
+}
+
+// start starts the span for the operation. Empty table and project are not
+// recorded.
+func (c *TracingClient) start(ctx context.Context, operation, table, project string) (context.Context, trace.Span) {
+	attrs := []attribute.KeyValue{attribute.String("db.system", c.backend)}
+	if table != "" {
+		attrs = append(attrs, attribute.String("db.sql.table", table))
+	}
+	if project != "" {
+		attrs = append(attrs, attribute.String("cello.project", project))
+	}
+
+	return c.tracer.Start(ctx, "db."+operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attrs...),
+	)
+}
+
+// endSpan ends the span, recording err on it if set.
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// StreamProjectEntries implements Client. The span ends once the stream
+// ends, with the error it ended with, if any.
+func (c *TracingClient) StreamProjectEntries(ctx context.Context) (<-chan ProjectEntry, <-chan error) {
+	ctx, span := c.start(ctx, "StreamProjectEntries", ProjectEntryDB, "")
+	entries, errc := c.client.StreamProjectEntries(ctx)
+
+	out := make(chan error, 1)
+	go func() {
+		defer close(out)
+
+		var err error
+		for e := range errc {
+			if err == nil {
+				err = e
+			}
+			out <- e
+		}
+		endSpan(span, err)
+	}()
+
+	return entries, out
+}
+
+// CreateProjectEntry implements Client.
+func (c *TracingClient) CreateProjectEntry(ctx context.Context, pe ProjectEntry) error {
+	ctx, span := c.start(ctx, "CreateProjectEntry", ProjectEntryDB, pe.ProjectID)
+	err := c.client.CreateProjectEntry(ctx, pe)
+	endSpan(span, err)
+	return err
+}
+
+// CreateProjectWithToken implements Client.
+func (c *TracingClient) CreateProjectWithToken(ctx context.Context, pe ProjectEntry, token types.Token) error {
+	ctx, span := c.start(ctx, "CreateProjectWithToken", ProjectEntryDB, pe.ProjectID)
+	err := c.client.CreateProjectWithToken(ctx, pe, token)
+	endSpan(span, err)
+	return err
+}
+
+// ImportProject implements Client.
+func (c *TracingClient) ImportProject(ctx context.Context, data []byte) error {
+	ctx, span := c.start(ctx, "ImportProject", ProjectEntryDB, "")
+	err := c.client.ImportProject(ctx, data)
+	endSpan(span, err)
+	return err
+}
+
+// DeleteProjectEntry implements Client.
+func (c *TracingClient) DeleteProjectEntry(ctx context.Context, project string) error {
+	ctx, span := c.start(ctx, "DeleteProjectEntry", ProjectEntryDB, project)
+	err := c.client.DeleteProjectEntry(ctx, project)
+	endSpan(span, err)
+	return err
+}
+
+// RestoreProjectEntry implements Client.
+func (c *TracingClient) RestoreProjectEntry(ctx context.Context, project string) error {
+	ctx, span := c.start(ctx, "RestoreProjectEntry", ProjectEntryDB, project)
+	err := c.client.RestoreProjectEntry(ctx, project)
+	endSpan(span, err)
+	return err
+}
+
+// PurgeDeletedProjects implements Client.
+func (c *TracingClient) PurgeDeletedProjects(ctx context.Context, now time.Time) (int, error) {
+	ctx, span := c.start(ctx, "PurgeDeletedProjects", ProjectEntryDB, "")
+	res, err := c.client.PurgeDeletedProjects(ctx, now)
+	endSpan(span, err)
+	return res, err
+}
+
+// ReadProjectEntry implements Client.
+func (c *TracingClient) ReadProjectEntry(ctx context.Context, project string) (ProjectEntry, error) {
+	ctx, span := c.start(ctx, "ReadProjectEntry", ProjectEntryDB, project)
+	res, err := c.client.ReadProjectEntry(ctx, project)
+	endSpan(span, err)
+	return res, err
+}
+
+// ReadProjectEntryWithETag implements Client.
+func (c *TracingClient) ReadProjectEntryWithETag(ctx context.Context, project string) (ProjectEntry, string, error) {
+	ctx, span := c.start(ctx, "ReadProjectEntryWithETag", ProjectEntryDB, project)
+	pe, etag, err := c.client.ReadProjectEntryWithETag(ctx, project)
+	endSpan(span, err)
+	return pe, etag, err
+}
+
+// UpdateProjectEntry implements Client.
+func (c *TracingClient) UpdateProjectEntry(ctx context.Context, pe ProjectEntry) error {
+	ctx, span := c.start(ctx, "UpdateProjectEntry", ProjectEntryDB, pe.ProjectID)
+	err := c.client.UpdateProjectEntry(ctx, pe)
+	endSpan(span, err)
+	return err
+}
+
+// UpdateProjectEntryIfMatch implements Client.
+func (c *TracingClient) UpdateProjectEntryIfMatch(ctx context.Context, pe ProjectEntry, etag string) error {
+	ctx, span := c.start(ctx, "UpdateProjectEntryIfMatch", ProjectEntryDB, pe.ProjectID)
+	err := c.client.UpdateProjectEntryIfMatch(ctx, pe, etag)
+	endSpan(span, err)
+	return err
+}
+
+// FindDuplicateRepositories implements Client.
+func (c *TracingClient) FindDuplicateRepositories(ctx context.Context) (map[string][]string, error) {
+	ctx, span := c.start(ctx, "FindDuplicateRepositories", ProjectEntryDB, "")
+	res, err := c.client.FindDuplicateRepositories(ctx)
+	endSpan(span, err)
+	return res, err
+}
+
+// ListProjectEntries implements Client.
+func (c *TracingClient) ListProjectEntries(ctx context.Context, opts ListOptions) ([]ProjectEntry, string, error) {
+	ctx, span := c.start(ctx, "ListProjectEntries", ProjectEntryDB, "")
+	entries, cursor, err := c.client.ListProjectEntries(ctx, opts)
+	endSpan(span, err)
+	return entries, cursor, err
+}
+
+// ListProjectsByRepository implements Client.
+func (c *TracingClient) ListProjectsByRepository(ctx context.Context, repository string) ([]ProjectEntry, error) {
+	ctx, span := c.start(ctx, "ListProjectsByRepository", ProjectEntryDB, "")
+	res, err := c.client.ListProjectsByRepository(ctx, repository)
+	endSpan(span, err)
+	return res, err
+}
+
+// ReadProjectActivity implements Client.
+func (c *TracingClient) ReadProjectActivity(ctx context.Context, project string) (ProjectEntry, time.Time, error) {
+	ctx, span := c.start(ctx, "ReadProjectActivity", ProjectEntryDB, project)
+	pe, lastActivity, err := c.client.ReadProjectActivity(ctx, project)
+	endSpan(span, err)
+	return pe, lastActivity, err
+}
+
+// ReadProjectsWithTokenCounts implements Client.
+func (c *TracingClient) ReadProjectsWithTokenCounts(ctx context.Context, ids []string) ([]ProjectSummary, error) {
+	ctx, span := c.start(ctx, "ReadProjectsWithTokenCounts", ProjectEntryDB, "")
+	res, err := c.client.ReadProjectsWithTokenCounts(ctx, ids)
+	endSpan(span, err)
+	return res, err
+}
+
+// CreateTokenEntry implements Client.
+func (c *TracingClient) CreateTokenEntry(ctx context.Context, token types.Token) error {
+	ctx, span := c.start(ctx, "CreateTokenEntry", TokenEntryDB, token.ProjectID)
+	err := c.client.CreateTokenEntry(ctx, token)
+	endSpan(span, err)
+	return err
+}
+
+// CreateTokenEntries implements Client.
+func (c *TracingClient) CreateTokenEntries(ctx context.Context, tokens []types.Token) error {
+	ctx, span := c.start(ctx, "CreateTokenEntries", TokenEntryDB, "")
+	err := c.client.CreateTokenEntries(ctx, tokens)
+	endSpan(span, err)
+	return err
+}
+
+// IssueToken implements Client.
+func (c *TracingClient) IssueToken(ctx context.Context, project, roleID string, ttl time.Duration) (types.Token, error) {
+	ctx, span := c.start(ctx, "IssueToken", TokenEntryDB, project)
+	res, err := c.client.IssueToken(ctx, project, roleID, ttl)
+	endSpan(span, err)
+	return res, err
+}
+
+// VerifyTokenSecret implements Client.
+func (c *TracingClient) VerifyTokenSecret(ctx context.Context, token, secret string) (TokenEntry, error) {
+	ctx, span := c.start(ctx, "VerifyTokenSecret", TokenEntryDB, "")
+	res, err := c.client.VerifyTokenSecret(ctx, token, secret)
+	endSpan(span, err)
+	return res, err
+}
+
+// VerifyAndTouch implements Client.
+func (c *TracingClient) VerifyAndTouch(ctx context.Context, project, token, secret string) (TokenEntry, error) {
+	ctx, span := c.start(ctx, "VerifyAndTouch", TokenEntryDB, project)
+	res, err := c.client.VerifyAndTouch(ctx, project, token, secret)
+	endSpan(span, err)
+	return res, err
+}
+
+// AddPendingSecret implements Client.
+func (c *TracingClient) AddPendingSecret(ctx context.Context, token string, ttl time.Duration) (string, error) {
+	ctx, span := c.start(ctx, "AddPendingSecret", TokenEntryDB, "")
+	res, err := c.client.AddPendingSecret(ctx, token, ttl)
+	endSpan(span, err)
+	return res, err
+}
+
+// PromotePendingSecret implements Client.
+func (c *TracingClient) PromotePendingSecret(ctx context.Context, token string) error {
+	ctx, span := c.start(ctx, "PromotePendingSecret", TokenEntryDB, "")
+	err := c.client.PromotePendingSecret(ctx, token)
+	endSpan(span, err)
+	return err
+}
+
+// DeleteTokenEntry implements Client.
+func (c *TracingClient) DeleteTokenEntry(ctx context.Context, token string) error {
+	ctx, span := c.start(ctx, "DeleteTokenEntry", TokenEntryDB, "")
+	err := c.client.DeleteTokenEntry(ctx, token)
+	endSpan(span, err)
+	return err
+}
+
+// DeleteExpiredTokens implements Client.
+func (c *TracingClient) DeleteExpiredTokens(ctx context.Context, project string, now time.Time) (int, error) {
+	ctx, span := c.start(ctx, "DeleteExpiredTokens", TokenEntryDB, project)
+	res, err := c.client.DeleteExpiredTokens(ctx, project, now)
+	endSpan(span, err)
+	return res, err
+}
+
+// NextTokenSequence implements Client.
+func (c *TracingClient) NextTokenSequence(ctx context.Context, project string) (int64, error) {
+	ctx, span := c.start(ctx, "NextTokenSequence", TokenSequenceDB, project)
+	res, err := c.client.NextTokenSequence(ctx, project)
+	endSpan(span, err)
+	return res, err
+}
+
+// ReadTokenEntry implements Client.
+func (c *TracingClient) ReadTokenEntry(ctx context.Context, token string) (TokenEntry, error) {
+	ctx, span := c.start(ctx, "ReadTokenEntry", TokenEntryDB, "")
+	res, err := c.client.ReadTokenEntry(ctx, token)
+	endSpan(span, err)
+	return res, err
+}
+
+// ReadTokenEntryScoped implements Client.
+func (c *TracingClient) ReadTokenEntryScoped(ctx context.Context, project, token string) (TokenEntry, error) {
+	ctx, span := c.start(ctx, "ReadTokenEntryScoped", TokenEntryDB, project)
+	res, err := c.client.ReadTokenEntryScoped(ctx, project, token)
+	endSpan(span, err)
+	return res, err
+}
+
+// CountTokenEntries implements Client.
+func (c *TracingClient) CountTokenEntries(ctx context.Context, project string) (int, error) {
+	ctx, span := c.start(ctx, "CountTokenEntries", TokenEntryDB, project)
+	res, err := c.client.CountTokenEntries(ctx, project)
+	endSpan(span, err)
+	return res, err
+}
+
+// PreviewAffectedTokenCount implements Client.
+func (c *TracingClient) PreviewAffectedTokenCount(ctx context.Context, project string, predicate Predicate) (int, error) {
+	ctx, span := c.start(ctx, "PreviewAffectedTokenCount", TokenEntryDB, project)
+	res, err := c.client.PreviewAffectedTokenCount(ctx, project, predicate)
+	endSpan(span, err)
+	return res, err
+}
+
+// ListTokenEntries implements Client.
+func (c *TracingClient) ListTokenEntries(ctx context.Context, project string) ([]TokenEntry, error) {
+	ctx, span := c.start(ctx, "ListTokenEntries", TokenEntryDB, project)
+	res, err := c.client.ListTokenEntries(ctx, project)
+	endSpan(span, err)
+	return res, err
+}
+
+// ListTokenEntriesCreatedBetween implements Client.
+func (c *TracingClient) ListTokenEntriesCreatedBetween(ctx context.Context, project string, start, end time.Time) ([]TokenEntry, error) {
+	ctx, span := c.start(ctx, "ListTokenEntriesCreatedBetween", TokenEntryDB, project)
+	res, err := c.client.ListTokenEntriesCreatedBetween(ctx, project, start, end)
+	endSpan(span, err)
+	return res, err
+}
+
+// ListTokenEntriesWithTTL implements Client.
+func (c *TracingClient) ListTokenEntriesWithTTL(ctx context.Context, project string, now time.Time) ([]TokenWithTTL, error) {
+	ctx, span := c.start(ctx, "ListTokenEntriesWithTTL", TokenEntryDB, project)
+	res, err := c.client.ListTokenEntriesWithTTL(ctx, project, now)
+	endSpan(span, err)
+	return res, err
+}
+
+// ListTokenEntriesByUrgency implements Client.
+func (c *TracingClient) ListTokenEntriesByUrgency(ctx context.Context, project string, now time.Time) ([]TokenWithTTL, error) {
+	ctx, span := c.start(ctx, "ListTokenEntriesByUrgency", TokenEntryDB, project)
+	res, err := c.client.ListTokenEntriesByUrgency(ctx, project, now)
+	endSpan(span, err)
+	return res, err
+}
+
+// ListTokenEntriesPaged implements Client.
+func (c *TracingClient) ListTokenEntriesPaged(ctx context.Context, project string, limit int, cursor string) (ListTokenEntriesResult, error) {
+	ctx, span := c.start(ctx, "ListTokenEntriesPaged", TokenEntryDB, project)
+	res, err := c.client.ListTokenEntriesPaged(ctx, project, limit, cursor)
+	endSpan(span, err)
+	return res, err
+}
+
+// ListExpiredTokenEntriesGlobal implements Client.
+func (c *TracingClient) ListExpiredTokenEntriesGlobal(ctx context.Context, now time.Time, limit int) ([]TokenEntry, error) {
+	ctx, span := c.start(ctx, "ListExpiredTokenEntriesGlobal", TokenEntryDB, "")
+	res, err := c.client.ListExpiredTokenEntriesGlobal(ctx, now, limit)
+	endSpan(span, err)
+	return res, err
+}
+
+// ListTokenChanges implements Client.
+func (c *TracingClient) ListTokenChanges(ctx context.Context, project, syncToken string) (TokenChangeSet, error) {
+	ctx, span := c.start(ctx, "ListTokenChanges", TokenTombstoneDB, project)
+	res, err := c.client.ListTokenChanges(ctx, project, syncToken)
+	endSpan(span, err)
+	return res, err
+}
+
+// ListTokenHistory implements Client.
+func (c *TracingClient) ListTokenHistory(ctx context.Context, project string) ([]TokenHistoryEntry, error) {
+	ctx, span := c.start(ctx, "ListTokenHistory", TokenHistoryDB, project)
+	res, err := c.client.ListTokenHistory(ctx, project)
+	endSpan(span, err)
+	return res, err
+}
+
+// PurgeTokenTombstones implements Client.
+func (c *TracingClient) PurgeTokenTombstones(ctx context.Context, before time.Time) (int, error) {
+	ctx, span := c.start(ctx, "PurgeTokenTombstones", TokenTombstoneDB, "")
+	res, err := c.client.PurgeTokenTombstones(ctx, before)
+	endSpan(span, err)
+	return res, err
+}
+
+// SystemStats implements Client.
+func (c *TracingClient) SystemStats(ctx context.Context, now time.Time) (SystemStats, error) {
+	ctx, span := c.start(ctx, "SystemStats", "", "")
+	res, err := c.client.SystemStats(ctx, now)
+	endSpan(span, err)
+	return res, err
+}
+
+// Health implements Client.
+func (c *TracingClient) Health(ctx context.Context) error {
+	ctx, span := c.start(ctx, "Health", "", "")
+	err := c.client.Health(ctx)
+	endSpan(span, err)
+	return err
+}
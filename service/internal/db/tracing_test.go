@@ -0,0 +1,59 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestTracingClient(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus codes.Code
+	}{
+		{
+			name:       "success",
+			wantStatus: codes.Unset,
+		},
+		{
+			name:       "error",
+			err:        errors.New("boom"),
+			wantStatus: codes.Error,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sr := tracetest.NewSpanRecorder()
+			c := NewTracingClient(&instrumentedFakeClient{err: tt.err}, BackendPostgres, sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr)))
+
+			_, err := c.ReadProjectEntry(context.Background(), "project1")
+			assert.Equal(t, tt.err, err)
+
+			spans := sr.Ended()
+			assert.Len(t, spans, 1)
+			assert.Equal(t, "db.ReadProjectEntry", spans[0].Name())
+			assert.Equal(t, tt.wantStatus, spans[0].Status().Code)
+			assert.ElementsMatch(t, []attribute.KeyValue{
+				attribute.String("db.system", BackendPostgres),
+				attribute.String("db.sql.table", ProjectEntryDB),
+				attribute.String("cello.project", "project1"),
+			}, spans[0].Attributes())
+		})
+	}
+}
+
+func TestTracingClientNoProvider(t *testing.T) {
+	c := NewTracingClient(&instrumentedFakeClient{}, BackendPostgres, nil)
+
+	pe, err := c.ReadProjectEntry(context.Background(), "project1")
+	assert.Nil(t, err)
+	assert.Equal(t, "project1", pe.ProjectID)
+}
@@ -5,7 +5,9 @@ import (
 	"os"
 	"strings"
 	"sync"
+	"time"
 
+	"github.com/cello-proj/cello/internal/types"
 	"github.com/kelseyhightower/envconfig"
 )
 
@@ -36,6 +38,17 @@ type Vars struct {
 	DBName         string   `split_words:"true" required:"true"`
 	DBOptions      string   `split_words:"true"`
 	ImageURIs      []string `envconfig:"IMAGE_URIS"`
+
+	DBReaperInterval     time.Duration `split_words:"true"`
+	DBTombstoneRetention time.Duration `split_words:"true" default:"168h"`
+	DBMaxOpenConns       int           `split_words:"true"`
+	DBMaxIdleConns       int           `split_words:"true"`
+	DBDefaultTimeout     time.Duration `split_words:"true"`
+	DBStrictOptions      bool          `split_words:"true"`
+	TargetARNAllowlist   []string      `envconfig:"TARGET_ARN_ALLOWLIST"`
+	ARNCacheSize         int           `envconfig:"ARN_CACHE_SIZE"`
+
+	RequireTargetsForTokens bool `split_words:"true"`
 }
 
 var (
@@ -59,7 +72,7 @@ func (values Vars) validate() error {
 	if len(values.AdminSecret) < 16 {
 		return errors.New("admin secret must be at least 16 characers long")
 	}
-	return nil
+	return types.ARNAllowlist{Patterns: values.TargetARNAllowlist}.Validate()
 }
 
 func migrateLegacyPrefix() {
@@ -2,8 +2,10 @@ package env
 
 import (
 	"os"
+	"path/filepath"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -25,6 +27,15 @@ var prefixedEnvVars = map[string]string{
 	"_DB_USER":                      "argoco",
 	"_DB_PASSWORD":                  "1234",
 	"_DB_OPTIONS":                   "sslrootcert=rds-ca.pem sslmode=verify-full",
+	"_DB_REAPER_INTERVAL":           "1h",
+	"_DB_TOMBSTONE_RETENTION":       "24h",
+	"_DB_MAX_OPEN_CONNS":            "20",
+	"_DB_MAX_IDLE_CONNS":            "5",
+	"_DB_DEFAULT_TIMEOUT":           "30s",
+	"_DB_STRICT_OPTIONS":            "true",
+	"_TARGET_ARN_ALLOWLIST":         "arn:aws:iam::012345678901:role/*,arn:aws:iam::aws:policy/*",
+	"_ARN_CACHE_SIZE":               "1000",
+	"_REQUIRE_TARGETS_FOR_TOKENS":   "true",
 }
 
 var nonPrefixedEnvVars = map[string]string{
@@ -81,6 +92,15 @@ func TestGetEnv(t *testing.T) {
 	assert.Equal(t, "argoco", vars.DBUser)
 	assert.Equal(t, "1234", vars.DBPassword)
 	assert.Equal(t, "sslrootcert=rds-ca.pem sslmode=verify-full", vars.DBOptions)
+	assert.Equal(t, time.Hour, vars.DBReaperInterval)
+	assert.Equal(t, 24*time.Hour, vars.DBTombstoneRetention)
+	assert.Equal(t, 20, vars.DBMaxOpenConns)
+	assert.Equal(t, 5, vars.DBMaxIdleConns)
+	assert.Equal(t, 30*time.Second, vars.DBDefaultTimeout)
+	assert.True(t, vars.DBStrictOptions)
+	assert.Equal(t, []string{"arn:aws:iam::012345678901:role/*", "arn:aws:iam::aws:policy/*"}, vars.TargetARNAllowlist)
+	assert.Equal(t, 1000, vars.ARNCacheSize)
+	assert.True(t, vars.RequireTargetsForTokens)
 }
 
 func TestDefaults(t *testing.T) {
@@ -92,14 +112,22 @@ func TestDefaults(t *testing.T) {
 	os.Setenv("VAULT_ADDR", "1.2.3.4")
 	os.Setenv("ARGO_ADDR", "2.3.4.5")
 	os.Setenv(appPrefix+"_GIT_AUTH_METHOD", "https")
+	os.Setenv(appPrefix+"_DB_HOST", "localhost")
+	os.Setenv(appPrefix+"_DB_USER", "argoco")
+	os.Setenv(appPrefix+"_DB_PASSWORD", "1234")
+	os.Setenv(appPrefix+"_DB_NAME", "argocloudops")
 
 	// When
-	vars, _ := GetEnv()
+	vars, err := GetEnv()
 
 	// Then
+	assert.NoError(t, err)
 	assert.Equal(t, "argo", vars.ArgoNamespace)
 	assert.Equal(t, "cello.yaml", vars.ConfigFilePath)
 	assert.Equal(t, 8443, vars.Port)
+	assert.Equal(t, time.Duration(0), vars.DBReaperInterval)
+	assert.Equal(t, 7*24*time.Hour, vars.DBTombstoneRetention)
+	assert.False(t, vars.DBStrictOptions)
 }
 
 func TestValidations(t *testing.T) {
@@ -119,6 +147,20 @@ func TestValidations(t *testing.T) {
 	assert.Error(t, err)
 }
 
+func TestInvalidARNAllowlistPattern(t *testing.T) {
+	// Given
+	reset()
+	setEnvVars(prefixedEnvVars, appPrefix)
+	setEnvVars(nonPrefixedEnvVars, "")
+	os.Setenv(appPrefix+"_TARGET_ARN_ALLOWLIST", "arn:aws:iam::012345678901:role/[a-z")
+
+	// When
+	_, err := GetEnv()
+
+	// Then
+	assert.ErrorIs(t, err, filepath.ErrBadPattern)
+}
+
 func TestRequiredVars(t *testing.T) {
 	// Given
 	reset()
@@ -0,0 +1,99 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrDependencyUnhealthy conveys that at least one dependency check failed.
+var ErrDependencyUnhealthy = errors.New("dependency unhealthy")
+
+// DependencyStatus is the result of checking a single dependency.
+type DependencyStatus struct {
+	Name    string        `json:"name"`
+	Healthy bool          `json:"healthy"`
+	Latency time.Duration `json:"latency"`
+	Error   string        `json:"error,omitempty"`
+}
+
+// Report is the result of checking a set of dependencies, ordered by name.
+type Report struct {
+	Dependencies []DependencyStatus `json:"dependencies"`
+}
+
+// Healthy returns whether every dependency is healthy.
+func (r Report) Healthy() bool {
+	for _, d := range r.Dependencies {
+		if !d.Healthy {
+			return false
+		}
+	}
+	return true
+}
+
+// String returns a human readable summary of the report, one line per
+// dependency.
+func (r Report) String() string {
+	lines := []string{}
+	for _, d := range r.Dependencies {
+		if d.Healthy {
+			lines = append(lines, fmt.Sprintf("%s: ok (%s)", d.Name, d.Latency))
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("%s: unhealthy (%s): %s", d.Name, d.Latency, d.Error))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// CheckDependencies runs the checks concurrently and reports the status of
+// each, e.g. {db.HealthCheckName: db.HealthCheck(client)}.
+// ErrDependencyUnhealthy, naming the failed dependencies, is returned along
+// with the full report if any check fails.
+func CheckDependencies(ctx context.Context, checks map[string]func(context.Context) error) (Report, error) {
+	statuses := make([]DependencyStatus, 0, len(checks))
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for name, check := range checks {
+		wg.Add(1)
+		go func(name string, check func(context.Context) error) {
+			defer wg.Done()
+
+			start := time.Now()
+			err := check(ctx)
+			s := DependencyStatus{
+				Name:    name,
+				Healthy: err == nil,
+				Latency: time.Since(start),
+			}
+			if err != nil {
+				s.Error = err.Error()
+			}
+
+			mu.Lock()
+			statuses = append(statuses, s)
+			mu.Unlock()
+		}(name, check)
+	}
+	wg.Wait()
+
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Name < statuses[j].Name })
+	report := Report{Dependencies: statuses}
+
+	unhealthy := []string{}
+	for _, s := range statuses {
+		if !s.Healthy {
+			unhealthy = append(unhealthy, s.Name)
+		}
+	}
+	if len(unhealthy) > 0 {
+		return report, fmt.Errorf("%w: %s", ErrDependencyUnhealthy, strings.Join(unhealthy, ", "))
+	}
+
+	return report, nil
+}
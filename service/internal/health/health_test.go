@@ -0,0 +1,73 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckDependencies(t *testing.T) {
+	ok := func(ctx context.Context) error { return nil }
+	failing := func(ctx context.Context) error { return errors.New("connection refused") }
+
+	tests := []struct {
+		name        string
+		checks      map[string]func(context.Context) error
+		wantHealthy map[string]bool
+		wantErr     string
+	}{
+		{
+			name:        "all healthy",
+			checks:      map[string]func(context.Context) error{"db": ok, "vault": ok},
+			wantHealthy: map[string]bool{"db": true, "vault": true},
+		},
+		{
+			name:        "mixed",
+			checks:      map[string]func(context.Context) error{"db": failing, "replica": failing, "vault": ok},
+			wantHealthy: map[string]bool{"db": false, "replica": false, "vault": true},
+			wantErr:     "dependency unhealthy: db, replica",
+		},
+		{
+			name:        "no checks",
+			checks:      map[string]func(context.Context) error{},
+			wantHealthy: map[string]bool{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report, err := CheckDependencies(context.Background(), tt.checks)
+			if tt.wantErr != "" {
+				assert.True(t, errors.Is(err, ErrDependencyUnhealthy))
+				assert.EqualError(t, err, tt.wantErr)
+			} else {
+				assert.Nil(t, err)
+			}
+
+			got := map[string]bool{}
+			names := []string{}
+			for _, d := range report.Dependencies {
+				got[d.Name] = d.Healthy
+				names = append(names, d.Name)
+				if !d.Healthy {
+					assert.Equal(t, "connection refused", d.Error)
+				}
+			}
+			assert.Equal(t, tt.wantHealthy, got)
+			assert.IsIncreasing(t, names)
+			assert.Equal(t, tt.wantErr == "", report.Healthy())
+		})
+	}
+}
+
+func TestReportString(t *testing.T) {
+	report := Report{Dependencies: []DependencyStatus{
+		{Name: "db", Healthy: true, Latency: 2 * time.Millisecond},
+		{Name: "vault", Latency: 5 * time.Millisecond, Error: "connection refused"},
+	}}
+
+	assert.Equal(t, "db: ok (2ms)\nvault: unhealthy (5ms): connection refused", report.String())
+}
@@ -8,6 +8,9 @@ import (
 	"fmt"
 	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/cello-proj/cello/internal/validations"
 	"github.com/cello-proj/cello/service/internal/credentials"
@@ -20,6 +23,7 @@ import (
 	"github.com/argoproj/argo-workflows/v3/cmd/argo/commands/client"
 	"github.com/go-kit/log"
 	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
 )
 
 var (
@@ -30,7 +34,17 @@ var (
 // version string
 )
 
+// shutdownTimeout bounds how long in-flight requests are given to finish once
+// a shutdown signal is received.
+const shutdownTimeout = 30 * time.Second
+
 func main() {
+	os.Exit(run())
+}
+
+// run starts the service and blocks until it fails or receives SIGINT or
+// SIGTERM, returning the exit code. Deferred cleanup runs before main exits.
+func run() int {
 	var (
 		logger    = log.With(log.NewLogfmtLogger(log.NewSyncWriter(os.Stdout)), "ts", log.DefaultTimestampUTC)
 		errLogger = log.With(log.NewLogfmtLogger(log.NewSyncWriter(os.Stderr)), "ts", log.DefaultTimestampUTC)
@@ -43,6 +57,9 @@ func main() {
 
 	setLogLevel(&logger, env.LogLevel)
 
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
 	level.Info(logger).Log("message", fmt.Sprintf("loading config '%s'", env.ConfigFilePath))
 	config, err := loadConfig(env.ConfigFilePath)
 	if err != nil {
@@ -52,18 +69,39 @@ func main() {
 
 	// temp, will rm after config restructure
 	validations.SetImageURIs(env.ImageURIs)
+	validations.SetARNCacheSize(env.ARNCacheSize)
 
 	// The Argo context is needed for any Argo client method calls or else, nil errors.
 	argoCtx, argoClient, err := client.NewAPIClient(context.Background())
 	if err != nil {
 		level.Error(errLogger).Log("message", "error creating argo-workflow client", "error", err)
-		os.Exit(1)
+		return 1
 	}
 
-	dbClient, err := db.NewSQLClient(env.DBHost, env.DBName, env.DBUser, env.DBPassword, util.OptionsToMap(env.DBOptions))
+	dbOpts := []db.Option{
+		db.WithMaxOpenConns(env.DBMaxOpenConns),
+		db.WithMaxIdleConns(env.DBMaxIdleConns),
+		db.WithDefaultTimeout(env.DBDefaultTimeout),
+		db.WithTombstoneRetention(env.DBTombstoneRetention),
+	}
+	if !env.DBStrictOptions {
+		dbOpts = append(dbOpts, db.WithUnknownOptionsWarning(logger))
+	}
+
+	dbClient, err := db.NewSQLClient(env.DBHost, env.DBName, env.DBUser, env.DBPassword, util.OptionsToMap(env.DBOptions), dbOpts...)
 	if err != nil {
 		level.Error(errLogger).Log("message", "error creating db client", "error", err)
-		os.Exit(1)
+		return 1
+	}
+	defer dbClient.Close()
+
+	if env.DBReaperInterval > 0 {
+		stopReaper := db.StartReaper(ctx, dbClient, env.DBReaperInterval,
+			db.WithReaperLogger(logger),
+			db.WithReaperTombstoneRetention(env.DBTombstoneRetention),
+			db.WithReaperRegisterer(prometheus.DefaultRegisterer),
+		)
+		defer stopReaper()
 	}
 
 	// Any Argo Workflow client method calls need the context returned from NewAPIClient, otherwise
@@ -80,11 +118,33 @@ func main() {
 		dbClient:               dbClient,
 	}
 
+	srv := &http.Server{
+		Addr:    fmt.Sprintf(":%d", env.Port),
+		Handler: setupRouter(h),
+	}
+
+	errc := make(chan error, 1)
+	go func() {
+		errc <- srv.ListenAndServeTLS("ssl/certificate.crt", "ssl/certificate.key")
+	}()
 	level.Info(logger).Log("message", "starting web service", "vault addr", env.VaultAddress, "argoAddr", env.ArgoAddress)
-	if err := http.ListenAndServeTLS(fmt.Sprintf(":%d", env.Port), "ssl/certificate.crt", "ssl/certificate.key", setupRouter(h)); err != nil {
+
+	select {
+	case err := <-errc:
 		level.Error(errLogger).Log("message", "error starting service", "error", err)
-		os.Exit(1)
+		return 1
+	case <-ctx.Done():
+	}
+
+	level.Info(logger).Log("message", "shutting down web service")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		level.Error(errLogger).Log("message", "error shutting down service", "error", err)
+		return 1
 	}
+
+	return 0
 }
 
 func setLogLevel(logger *log.Logger, logLevel string) {
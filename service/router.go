@@ -23,6 +23,7 @@ func setupRouter(h handler) *mux.Router {
 	r.HandleFunc("/projects", h.createProject).Methods(http.MethodPost)
 	r.HandleFunc("/projects/{projectName}", h.getProject).Methods(http.MethodGet)
 	r.HandleFunc("/projects/{projectName}", h.deleteProject).Methods(http.MethodDelete)
+	r.HandleFunc("/projects/{projectName}/quota", h.updateProjectQuota).Methods(http.MethodPut)
 	r.HandleFunc("/projects/{projectName}/targets", h.listTargets).Methods(http.MethodGet)
 	r.HandleFunc("/projects/{projectName}/targets", h.createTarget).Methods(http.MethodPost)
 	r.HandleFunc("/projects/{projectName}/targets/{targetName}", h.getTarget).Methods(http.MethodGet)
@@ -8,6 +8,7 @@ import (
 	"github.com/cello-proj/cello/internal/types"
 	"github.com/cello-proj/cello/service/internal/credentials"
 	"sync"
+	"time"
 )
 
 // Ensure, that CredsProviderMock does implement credentials.Provider.
@@ -26,7 +27,7 @@ var _ credentials.Provider = &CredsProviderMock{}
 // 			CreateTargetFunc: func(s string, target types.Target) error {
 // 				panic("mock out the CreateTarget method")
 // 			},
-// 			CreateTokenFunc: func(s string) (types.Token, error) {
+// 			CreateTokenFunc: func(s string, duration time.Duration) (types.Token, error) {
 // 				panic("mock out the CreateToken method")
 // 			},
 // 			DeleteProjectFunc: func(s string) error {
@@ -76,7 +77,7 @@ type CredsProviderMock struct {
 	CreateTargetFunc func(s string, target types.Target) error
 
 	// CreateTokenFunc mocks the CreateToken method.
-	CreateTokenFunc func(s string) (types.Token, error)
+	CreateTokenFunc func(s string, duration time.Duration) (types.Token, error)
 
 	// DeleteProjectFunc mocks the DeleteProject method.
 	DeleteProjectFunc func(s string) error
@@ -129,6 +130,8 @@ type CredsProviderMock struct {
 		CreateToken []struct {
 			// S is the s argument value.
 			S string
+			// Duration is the duration argument value.
+			Duration time.Duration
 		}
 		// DeleteProject holds details about calls to the DeleteProject method.
 		DeleteProject []struct {
@@ -279,29 +282,33 @@ func (mock *CredsProviderMock) CreateTargetCalls() []struct {
 }
 
 // CreateToken calls CreateTokenFunc.
-func (mock *CredsProviderMock) CreateToken(s string) (types.Token, error) {
+func (mock *CredsProviderMock) CreateToken(s string, duration time.Duration) (types.Token, error) {
 	if mock.CreateTokenFunc == nil {
 		panic("CredsProviderMock.CreateTokenFunc: method is nil but Provider.CreateToken was just called")
 	}
 	callInfo := struct {
-		S string
+		S        string
+		Duration time.Duration
 	}{
-		S: s,
+		S:        s,
+		Duration: duration,
 	}
 	mock.lockCreateToken.Lock()
 	mock.calls.CreateToken = append(mock.calls.CreateToken, callInfo)
 	mock.lockCreateToken.Unlock()
-	return mock.CreateTokenFunc(s)
+	return mock.CreateTokenFunc(s, duration)
 }
 
 // CreateTokenCalls gets all the calls that were made to CreateToken.
 // Check the length with:
 //     len(mockedProvider.CreateTokenCalls())
 func (mock *CredsProviderMock) CreateTokenCalls() []struct {
-	S string
+	S        string
+	Duration time.Duration
 } {
 	var calls []struct {
-		S string
+		S        string
+		Duration time.Duration
 	}
 	mock.lockCreateToken.RLock()
 	calls = mock.calls.CreateToken
@@ -8,6 +8,7 @@ import (
 	"github.com/cello-proj/cello/internal/types"
 	"github.com/cello-proj/cello/service/internal/db"
 	"sync"
+	"time"
 )
 
 // Ensure, that DBClientMock does implement db.Client.
@@ -20,30 +21,129 @@ var _ db.Client = &DBClientMock{}
 //
 //		// make and configure a mocked db.Client
 //		mockedClient := &DBClientMock{
+//			AddPendingSecretFunc: func(ctx context.Context, token string, ttl time.Duration) (string, error) {
+//				panic("mock out the AddPendingSecret method")
+//			},
+//			CountTokenEntriesFunc: func(ctx context.Context, project string) (int, error) {
+//				panic("mock out the CountTokenEntries method")
+//			},
 //			CreateProjectEntryFunc: func(ctx context.Context, pe db.ProjectEntry) error {
 //				panic("mock out the CreateProjectEntry method")
 //			},
+//			CreateProjectWithTokenFunc: func(ctx context.Context, pe db.ProjectEntry, token types.Token) error {
+//				panic("mock out the CreateProjectWithToken method")
+//			},
+//			CreateTokenEntriesFunc: func(ctx context.Context, tokens []types.Token) error {
+//				panic("mock out the CreateTokenEntries method")
+//			},
 //			CreateTokenEntryFunc: func(ctx context.Context, token types.Token) error {
 //				panic("mock out the CreateTokenEntry method")
 //			},
+//			DeleteExpiredTokensFunc: func(ctx context.Context, project string, now time.Time) (int, error) {
+//				panic("mock out the DeleteExpiredTokens method")
+//			},
 //			DeleteProjectEntryFunc: func(ctx context.Context, project string) error {
 //				panic("mock out the DeleteProjectEntry method")
 //			},
 //			DeleteTokenEntryFunc: func(ctx context.Context, token string) error {
 //				panic("mock out the DeleteTokenEntry method")
 //			},
+//			FindDuplicateRepositoriesFunc: func(ctx context.Context) (map[string][]string, error) {
+//				panic("mock out the FindDuplicateRepositories method")
+//			},
 //			HealthFunc: func(ctx context.Context) error {
 //				panic("mock out the Health method")
 //			},
+//			ImportProjectFunc: func(ctx context.Context, data []byte) error {
+//				panic("mock out the ImportProject method")
+//			},
+//			IssueTokenFunc: func(ctx context.Context, project string, roleID string, ttl time.Duration) (types.Token, error) {
+//				panic("mock out the IssueToken method")
+//			},
+//			ListExpiredTokenEntriesGlobalFunc: func(ctx context.Context, now time.Time, limit int) ([]db.TokenEntry, error) {
+//				panic("mock out the ListExpiredTokenEntriesGlobal method")
+//			},
+//			ListProjectEntriesFunc: func(ctx context.Context, opts db.ListOptions) ([]db.ProjectEntry, string, error) {
+//				panic("mock out the ListProjectEntries method")
+//			},
+//			ListProjectsByRepositoryFunc: func(ctx context.Context, repository string) ([]db.ProjectEntry, error) {
+//				panic("mock out the ListProjectsByRepository method")
+//			},
+//			ListTokenChangesFunc: func(ctx context.Context, project string, syncToken string) (db.TokenChangeSet, error) {
+//				panic("mock out the ListTokenChanges method")
+//			},
 //			ListTokenEntriesFunc: func(ctx context.Context, project string) ([]db.TokenEntry, error) {
 //				panic("mock out the ListTokenEntries method")
 //			},
+//			ListTokenEntriesByUrgencyFunc: func(ctx context.Context, project string, now time.Time) ([]db.TokenWithTTL, error) {
+//				panic("mock out the ListTokenEntriesByUrgency method")
+//			},
+//			ListTokenEntriesCreatedBetweenFunc: func(ctx context.Context, project string, start time.Time, end time.Time) ([]db.TokenEntry, error) {
+//				panic("mock out the ListTokenEntriesCreatedBetween method")
+//			},
+//			ListTokenEntriesPagedFunc: func(ctx context.Context, project string, limit int, cursor string) (db.ListTokenEntriesResult, error) {
+//				panic("mock out the ListTokenEntriesPaged method")
+//			},
+//			ListTokenEntriesWithTTLFunc: func(ctx context.Context, project string, now time.Time) ([]db.TokenWithTTL, error) {
+//				panic("mock out the ListTokenEntriesWithTTL method")
+//			},
+//			ListTokenHistoryFunc: func(ctx context.Context, project string) ([]db.TokenHistoryEntry, error) {
+//				panic("mock out the ListTokenHistory method")
+//			},
+//			NextTokenSequenceFunc: func(ctx context.Context, project string) (int64, error) {
+//				panic("mock out the NextTokenSequence method")
+//			},
+//			PreviewAffectedTokenCountFunc: func(ctx context.Context, project string, predicate db.Predicate) (int, error) {
+//				panic("mock out the PreviewAffectedTokenCount method")
+//			},
+//			PromotePendingSecretFunc: func(ctx context.Context, token string) error {
+//				panic("mock out the PromotePendingSecret method")
+//			},
+//			PurgeDeletedProjectsFunc: func(ctx context.Context, now time.Time) (int, error) {
+//				panic("mock out the PurgeDeletedProjects method")
+//			},
+//			PurgeTokenTombstonesFunc: func(ctx context.Context, before time.Time) (int, error) {
+//				panic("mock out the PurgeTokenTombstones method")
+//			},
+//			ReadProjectActivityFunc: func(ctx context.Context, project string) (db.ProjectEntry, time.Time, error) {
+//				panic("mock out the ReadProjectActivity method")
+//			},
 //			ReadProjectEntryFunc: func(ctx context.Context, project string) (db.ProjectEntry, error) {
 //				panic("mock out the ReadProjectEntry method")
 //			},
+//			ReadProjectEntryWithETagFunc: func(ctx context.Context, project string) (db.ProjectEntry, string, error) {
+//				panic("mock out the ReadProjectEntryWithETag method")
+//			},
+//			ReadProjectsWithTokenCountsFunc: func(ctx context.Context, ids []string) ([]db.ProjectSummary, error) {
+//				panic("mock out the ReadProjectsWithTokenCounts method")
+//			},
 //			ReadTokenEntryFunc: func(ctx context.Context, token string) (db.TokenEntry, error) {
 //				panic("mock out the ReadTokenEntry method")
 //			},
+//			ReadTokenEntryScopedFunc: func(ctx context.Context, project string, token string) (db.TokenEntry, error) {
+//				panic("mock out the ReadTokenEntryScoped method")
+//			},
+//			RestoreProjectEntryFunc: func(ctx context.Context, project string) error {
+//				panic("mock out the RestoreProjectEntry method")
+//			},
+//			StreamProjectEntriesFunc: func(ctx context.Context) (<-chan db.ProjectEntry, <-chan error) {
+//				panic("mock out the StreamProjectEntries method")
+//			},
+//			SystemStatsFunc: func(ctx context.Context, now time.Time) (db.SystemStats, error) {
+//				panic("mock out the SystemStats method")
+//			},
+//			UpdateProjectEntryFunc: func(ctx context.Context, pe db.ProjectEntry) error {
+//				panic("mock out the UpdateProjectEntry method")
+//			},
+//			UpdateProjectEntryIfMatchFunc: func(ctx context.Context, pe db.ProjectEntry, etag string) error {
+//				panic("mock out the UpdateProjectEntryIfMatch method")
+//			},
+//			VerifyAndTouchFunc: func(ctx context.Context, project string, token string, secret string) (db.TokenEntry, error) {
+//				panic("mock out the VerifyAndTouch method")
+//			},
+//			VerifyTokenSecretFunc: func(ctx context.Context, token string, secret string) (db.TokenEntry, error) {
+//				panic("mock out the VerifyTokenSecret method")
+//			},
 //		}
 //
 //		// use mockedClient in code that requires db.Client
@@ -51,32 +151,147 @@ var _ db.Client = &DBClientMock{}
 //
 //	}
 type DBClientMock struct {
+	// AddPendingSecretFunc mocks the AddPendingSecret method.
+	AddPendingSecretFunc func(ctx context.Context, token string, ttl time.Duration) (string, error)
+
+	// CountTokenEntriesFunc mocks the CountTokenEntries method.
+	CountTokenEntriesFunc func(ctx context.Context, project string) (int, error)
+
 	// CreateProjectEntryFunc mocks the CreateProjectEntry method.
 	CreateProjectEntryFunc func(ctx context.Context, pe db.ProjectEntry) error
 
+	// CreateProjectWithTokenFunc mocks the CreateProjectWithToken method.
+	CreateProjectWithTokenFunc func(ctx context.Context, pe db.ProjectEntry, token types.Token) error
+
+	// CreateTokenEntriesFunc mocks the CreateTokenEntries method.
+	CreateTokenEntriesFunc func(ctx context.Context, tokens []types.Token) error
+
 	// CreateTokenEntryFunc mocks the CreateTokenEntry method.
 	CreateTokenEntryFunc func(ctx context.Context, token types.Token) error
 
+	// DeleteExpiredTokensFunc mocks the DeleteExpiredTokens method.
+	DeleteExpiredTokensFunc func(ctx context.Context, project string, now time.Time) (int, error)
+
 	// DeleteProjectEntryFunc mocks the DeleteProjectEntry method.
 	DeleteProjectEntryFunc func(ctx context.Context, project string) error
 
 	// DeleteTokenEntryFunc mocks the DeleteTokenEntry method.
 	DeleteTokenEntryFunc func(ctx context.Context, token string) error
 
+	// FindDuplicateRepositoriesFunc mocks the FindDuplicateRepositories method.
+	FindDuplicateRepositoriesFunc func(ctx context.Context) (map[string][]string, error)
+
 	// HealthFunc mocks the Health method.
 	HealthFunc func(ctx context.Context) error
 
+	// ImportProjectFunc mocks the ImportProject method.
+	ImportProjectFunc func(ctx context.Context, data []byte) error
+
+	// IssueTokenFunc mocks the IssueToken method.
+	IssueTokenFunc func(ctx context.Context, project string, roleID string, ttl time.Duration) (types.Token, error)
+
+	// ListExpiredTokenEntriesGlobalFunc mocks the ListExpiredTokenEntriesGlobal method.
+	ListExpiredTokenEntriesGlobalFunc func(ctx context.Context, now time.Time, limit int) ([]db.TokenEntry, error)
+
+	// ListProjectEntriesFunc mocks the ListProjectEntries method.
+	ListProjectEntriesFunc func(ctx context.Context, opts db.ListOptions) ([]db.ProjectEntry, string, error)
+
+	// ListProjectsByRepositoryFunc mocks the ListProjectsByRepository method.
+	ListProjectsByRepositoryFunc func(ctx context.Context, repository string) ([]db.ProjectEntry, error)
+
+	// ListTokenChangesFunc mocks the ListTokenChanges method.
+	ListTokenChangesFunc func(ctx context.Context, project string, syncToken string) (db.TokenChangeSet, error)
+
 	// ListTokenEntriesFunc mocks the ListTokenEntries method.
 	ListTokenEntriesFunc func(ctx context.Context, project string) ([]db.TokenEntry, error)
 
+	// ListTokenEntriesByUrgencyFunc mocks the ListTokenEntriesByUrgency method.
+	ListTokenEntriesByUrgencyFunc func(ctx context.Context, project string, now time.Time) ([]db.TokenWithTTL, error)
+
+	// ListTokenEntriesCreatedBetweenFunc mocks the ListTokenEntriesCreatedBetween method.
+	ListTokenEntriesCreatedBetweenFunc func(ctx context.Context, project string, start time.Time, end time.Time) ([]db.TokenEntry, error)
+
+	// ListTokenEntriesPagedFunc mocks the ListTokenEntriesPaged method.
+	ListTokenEntriesPagedFunc func(ctx context.Context, project string, limit int, cursor string) (db.ListTokenEntriesResult, error)
+
+	// ListTokenEntriesWithTTLFunc mocks the ListTokenEntriesWithTTL method.
+	ListTokenEntriesWithTTLFunc func(ctx context.Context, project string, now time.Time) ([]db.TokenWithTTL, error)
+
+	// ListTokenHistoryFunc mocks the ListTokenHistory method.
+	ListTokenHistoryFunc func(ctx context.Context, project string) ([]db.TokenHistoryEntry, error)
+
+	// NextTokenSequenceFunc mocks the NextTokenSequence method.
+	NextTokenSequenceFunc func(ctx context.Context, project string) (int64, error)
+
+	// PreviewAffectedTokenCountFunc mocks the PreviewAffectedTokenCount method.
+	PreviewAffectedTokenCountFunc func(ctx context.Context, project string, predicate db.Predicate) (int, error)
+
+	// PromotePendingSecretFunc mocks the PromotePendingSecret method.
+	PromotePendingSecretFunc func(ctx context.Context, token string) error
+
+	// PurgeDeletedProjectsFunc mocks the PurgeDeletedProjects method.
+	PurgeDeletedProjectsFunc func(ctx context.Context, now time.Time) (int, error)
+
+	// PurgeTokenTombstonesFunc mocks the PurgeTokenTombstones method.
+	PurgeTokenTombstonesFunc func(ctx context.Context, before time.Time) (int, error)
+
+	// ReadProjectActivityFunc mocks the ReadProjectActivity method.
+	ReadProjectActivityFunc func(ctx context.Context, project string) (db.ProjectEntry, time.Time, error)
+
 	// ReadProjectEntryFunc mocks the ReadProjectEntry method.
 	ReadProjectEntryFunc func(ctx context.Context, project string) (db.ProjectEntry, error)
 
+	// ReadProjectEntryWithETagFunc mocks the ReadProjectEntryWithETag method.
+	ReadProjectEntryWithETagFunc func(ctx context.Context, project string) (db.ProjectEntry, string, error)
+
+	// ReadProjectsWithTokenCountsFunc mocks the ReadProjectsWithTokenCounts method.
+	ReadProjectsWithTokenCountsFunc func(ctx context.Context, ids []string) ([]db.ProjectSummary, error)
+
 	// ReadTokenEntryFunc mocks the ReadTokenEntry method.
 	ReadTokenEntryFunc func(ctx context.Context, token string) (db.TokenEntry, error)
 
+	// ReadTokenEntryScopedFunc mocks the ReadTokenEntryScoped method.
+	ReadTokenEntryScopedFunc func(ctx context.Context, project string, token string) (db.TokenEntry, error)
+
+	// RestoreProjectEntryFunc mocks the RestoreProjectEntry method.
+	RestoreProjectEntryFunc func(ctx context.Context, project string) error
+
+	// StreamProjectEntriesFunc mocks the StreamProjectEntries method.
+	StreamProjectEntriesFunc func(ctx context.Context) (<-chan db.ProjectEntry, <-chan error)
+
+	// SystemStatsFunc mocks the SystemStats method.
+	SystemStatsFunc func(ctx context.Context, now time.Time) (db.SystemStats, error)
+
+	// UpdateProjectEntryFunc mocks the UpdateProjectEntry method.
+	UpdateProjectEntryFunc func(ctx context.Context, pe db.ProjectEntry) error
+
+	// UpdateProjectEntryIfMatchFunc mocks the UpdateProjectEntryIfMatch method.
+	UpdateProjectEntryIfMatchFunc func(ctx context.Context, pe db.ProjectEntry, etag string) error
+
+	// VerifyAndTouchFunc mocks the VerifyAndTouch method.
+	VerifyAndTouchFunc func(ctx context.Context, project string, token string, secret string) (db.TokenEntry, error)
+
+	// VerifyTokenSecretFunc mocks the VerifyTokenSecret method.
+	VerifyTokenSecretFunc func(ctx context.Context, token string, secret string) (db.TokenEntry, error)
+
 	// calls tracks calls to the methods.
 	calls struct {
+		// AddPendingSecret holds details about calls to the AddPendingSecret method.
+		AddPendingSecret []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Token is the token argument value.
+			Token string
+			// TTL is the ttl argument value.
+			TTL time.Duration
+		}
+		// CountTokenEntries holds details about calls to the CountTokenEntries method.
+		CountTokenEntries []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Project is the project argument value.
+			Project string
+		}
 		// CreateProjectEntry holds details about calls to the CreateProjectEntry method.
 		CreateProjectEntry []struct {
 			// Ctx is the ctx argument value.
@@ -84,6 +299,22 @@ type DBClientMock struct {
 			// Pe is the pe argument value.
 			Pe db.ProjectEntry
 		}
+		// CreateProjectWithToken holds details about calls to the CreateProjectWithToken method.
+		CreateProjectWithToken []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Pe is the pe argument value.
+			Pe db.ProjectEntry
+			// Token is the token argument value.
+			Token types.Token
+		}
+		// CreateTokenEntries holds details about calls to the CreateTokenEntries method.
+		CreateTokenEntries []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Tokens is the tokens argument value.
+			Tokens []types.Token
+		}
 		// CreateTokenEntry holds details about calls to the CreateTokenEntry method.
 		CreateTokenEntry []struct {
 			// Ctx is the ctx argument value.
@@ -91,6 +322,15 @@ type DBClientMock struct {
 			// Token is the token argument value.
 			Token types.Token
 		}
+		// DeleteExpiredTokens holds details about calls to the DeleteExpiredTokens method.
+		DeleteExpiredTokens []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Project is the project argument value.
+			Project string
+			// Now is the now argument value.
+			Now time.Time
+		}
 		// DeleteProjectEntry holds details about calls to the DeleteProjectEntry method.
 		DeleteProjectEntry []struct {
 			// Ctx is the ctx argument value.
@@ -105,11 +345,66 @@ type DBClientMock struct {
 			// Token is the token argument value.
 			Token string
 		}
+		// FindDuplicateRepositories holds details about calls to the FindDuplicateRepositories method.
+		FindDuplicateRepositories []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+		}
 		// Health holds details about calls to the Health method.
 		Health []struct {
 			// Ctx is the ctx argument value.
 			Ctx context.Context
 		}
+		// ImportProject holds details about calls to the ImportProject method.
+		ImportProject []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Data is the data argument value.
+			Data []byte
+		}
+		// IssueToken holds details about calls to the IssueToken method.
+		IssueToken []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Project is the project argument value.
+			Project string
+			// RoleID is the roleID argument value.
+			RoleID string
+			// TTL is the ttl argument value.
+			TTL time.Duration
+		}
+		// ListExpiredTokenEntriesGlobal holds details about calls to the ListExpiredTokenEntriesGlobal method.
+		ListExpiredTokenEntriesGlobal []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Now is the now argument value.
+			Now time.Time
+			// Limit is the limit argument value.
+			Limit int
+		}
+		// ListProjectEntries holds details about calls to the ListProjectEntries method.
+		ListProjectEntries []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Opts is the opts argument value.
+			Opts db.ListOptions
+		}
+		// ListProjectsByRepository holds details about calls to the ListProjectsByRepository method.
+		ListProjectsByRepository []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Repository is the repository argument value.
+			Repository string
+		}
+		// ListTokenChanges holds details about calls to the ListTokenChanges method.
+		ListTokenChanges []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Project is the project argument value.
+			Project string
+			// SyncToken is the syncToken argument value.
+			SyncToken string
+		}
 		// ListTokenEntries holds details about calls to the ListTokenEntries method.
 		ListTokenEntries []struct {
 			// Ctx is the ctx argument value.
@@ -117,6 +412,97 @@ type DBClientMock struct {
 			// Project is the project argument value.
 			Project string
 		}
+		// ListTokenEntriesByUrgency holds details about calls to the ListTokenEntriesByUrgency method.
+		ListTokenEntriesByUrgency []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Project is the project argument value.
+			Project string
+			// Now is the now argument value.
+			Now time.Time
+		}
+		// ListTokenEntriesCreatedBetween holds details about calls to the ListTokenEntriesCreatedBetween method.
+		ListTokenEntriesCreatedBetween []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Project is the project argument value.
+			Project string
+			// Start is the start argument value.
+			Start time.Time
+			// End is the end argument value.
+			End time.Time
+		}
+		// ListTokenEntriesPaged holds details about calls to the ListTokenEntriesPaged method.
+		ListTokenEntriesPaged []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Project is the project argument value.
+			Project string
+			// Limit is the limit argument value.
+			Limit int
+			// Cursor is the cursor argument value.
+			Cursor string
+		}
+		// ListTokenEntriesWithTTL holds details about calls to the ListTokenEntriesWithTTL method.
+		ListTokenEntriesWithTTL []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Project is the project argument value.
+			Project string
+			// Now is the now argument value.
+			Now time.Time
+		}
+		// ListTokenHistory holds details about calls to the ListTokenHistory method.
+		ListTokenHistory []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Project is the project argument value.
+			Project string
+		}
+		// NextTokenSequence holds details about calls to the NextTokenSequence method.
+		NextTokenSequence []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Project is the project argument value.
+			Project string
+		}
+		// PreviewAffectedTokenCount holds details about calls to the PreviewAffectedTokenCount method.
+		PreviewAffectedTokenCount []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Project is the project argument value.
+			Project string
+			// Predicate is the predicate argument value.
+			Predicate db.Predicate
+		}
+		// PromotePendingSecret holds details about calls to the PromotePendingSecret method.
+		PromotePendingSecret []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Token is the token argument value.
+			Token string
+		}
+		// PurgeDeletedProjects holds details about calls to the PurgeDeletedProjects method.
+		PurgeDeletedProjects []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Now is the now argument value.
+			Now time.Time
+		}
+		// PurgeTokenTombstones holds details about calls to the PurgeTokenTombstones method.
+		PurgeTokenTombstones []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Before is the before argument value.
+			Before time.Time
+		}
+		// ReadProjectActivity holds details about calls to the ReadProjectActivity method.
+		ReadProjectActivity []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Project is the project argument value.
+			Project string
+		}
 		// ReadProjectEntry holds details about calls to the ReadProjectEntry method.
 		ReadProjectEntry []struct {
 			// Ctx is the ctx argument value.
@@ -124,6 +510,20 @@ type DBClientMock struct {
 			// Project is the project argument value.
 			Project string
 		}
+		// ReadProjectEntryWithETag holds details about calls to the ReadProjectEntryWithETag method.
+		ReadProjectEntryWithETag []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Project is the project argument value.
+			Project string
+		}
+		// ReadProjectsWithTokenCounts holds details about calls to the ReadProjectsWithTokenCounts method.
+		ReadProjectsWithTokenCounts []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Ids is the ids argument value.
+			Ids []string
+		}
 		// ReadTokenEntry holds details about calls to the ReadTokenEntry method.
 		ReadTokenEntry []struct {
 			// Ctx is the ctx argument value.
@@ -131,297 +531,1650 @@ type DBClientMock struct {
 			// Token is the token argument value.
 			Token string
 		}
+		// ReadTokenEntryScoped holds details about calls to the ReadTokenEntryScoped method.
+		ReadTokenEntryScoped []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Project is the project argument value.
+			Project string
+			// Token is the token argument value.
+			Token string
+		}
+		// RestoreProjectEntry holds details about calls to the RestoreProjectEntry method.
+		RestoreProjectEntry []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Project is the project argument value.
+			Project string
+		}
+		// StreamProjectEntries holds details about calls to the StreamProjectEntries method.
+		StreamProjectEntries []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+		}
+		// SystemStats holds details about calls to the SystemStats method.
+		SystemStats []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Now is the now argument value.
+			Now time.Time
+		}
+		// UpdateProjectEntry holds details about calls to the UpdateProjectEntry method.
+		UpdateProjectEntry []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Pe is the pe argument value.
+			Pe db.ProjectEntry
+		}
+		// UpdateProjectEntryIfMatch holds details about calls to the UpdateProjectEntryIfMatch method.
+		UpdateProjectEntryIfMatch []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Pe is the pe argument value.
+			Pe db.ProjectEntry
+			// Etag is the etag argument value.
+			Etag string
+		}
+		// VerifyAndTouch holds details about calls to the VerifyAndTouch method.
+		VerifyAndTouch []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Project is the project argument value.
+			Project string
+			// Token is the token argument value.
+			Token string
+			// Secret is the secret argument value.
+			Secret string
+		}
+		// VerifyTokenSecret holds details about calls to the VerifyTokenSecret method.
+		VerifyTokenSecret []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Token is the token argument value.
+			Token string
+			// Secret is the secret argument value.
+			Secret string
+		}
+	}
+	lockAddPendingSecret               sync.RWMutex
+	lockCountTokenEntries              sync.RWMutex
+	lockCreateProjectEntry             sync.RWMutex
+	lockCreateProjectWithToken         sync.RWMutex
+	lockCreateTokenEntries             sync.RWMutex
+	lockCreateTokenEntry               sync.RWMutex
+	lockDeleteExpiredTokens            sync.RWMutex
+	lockDeleteProjectEntry             sync.RWMutex
+	lockDeleteTokenEntry               sync.RWMutex
+	lockFindDuplicateRepositories      sync.RWMutex
+	lockHealth                         sync.RWMutex
+	lockImportProject                  sync.RWMutex
+	lockIssueToken                     sync.RWMutex
+	lockListExpiredTokenEntriesGlobal  sync.RWMutex
+	lockListProjectEntries             sync.RWMutex
+	lockListProjectsByRepository       sync.RWMutex
+	lockListTokenChanges               sync.RWMutex
+	lockListTokenEntries               sync.RWMutex
+	lockListTokenEntriesByUrgency      sync.RWMutex
+	lockListTokenEntriesCreatedBetween sync.RWMutex
+	lockListTokenEntriesPaged          sync.RWMutex
+	lockListTokenEntriesWithTTL        sync.RWMutex
+	lockListTokenHistory               sync.RWMutex
+	lockNextTokenSequence              sync.RWMutex
+	lockPreviewAffectedTokenCount      sync.RWMutex
+	lockPromotePendingSecret           sync.RWMutex
+	lockPurgeDeletedProjects           sync.RWMutex
+	lockPurgeTokenTombstones           sync.RWMutex
+	lockReadProjectActivity            sync.RWMutex
+	lockReadProjectEntry               sync.RWMutex
+	lockReadProjectEntryWithETag       sync.RWMutex
+	lockReadProjectsWithTokenCounts    sync.RWMutex
+	lockReadTokenEntry                 sync.RWMutex
+	lockReadTokenEntryScoped           sync.RWMutex
+	lockRestoreProjectEntry            sync.RWMutex
+	lockStreamProjectEntries           sync.RWMutex
+	lockSystemStats                    sync.RWMutex
+	lockUpdateProjectEntry             sync.RWMutex
+	lockUpdateProjectEntryIfMatch      sync.RWMutex
+	lockVerifyAndTouch                 sync.RWMutex
+	lockVerifyTokenSecret              sync.RWMutex
+}
+
+// AddPendingSecret calls AddPendingSecretFunc.
+func (mock *DBClientMock) AddPendingSecret(ctx context.Context, token string, ttl time.Duration) (string, error) {
+	if mock.AddPendingSecretFunc == nil {
+		panic("DBClientMock.AddPendingSecretFunc: method is nil but Client.AddPendingSecret was just called")
+	}
+	callInfo := struct {
+		Ctx   context.Context
+		Token string
+		TTL   time.Duration
+	}{
+		Ctx:   ctx,
+		Token: token,
+		TTL:   ttl,
+	}
+	mock.lockAddPendingSecret.Lock()
+	mock.calls.AddPendingSecret = append(mock.calls.AddPendingSecret, callInfo)
+	mock.lockAddPendingSecret.Unlock()
+	return mock.AddPendingSecretFunc(ctx, token, ttl)
+}
+
+// AddPendingSecretCalls gets all the calls that were made to AddPendingSecret.
+// Check the length with:
+//
+//	len(mockedClient.AddPendingSecretCalls())
+func (mock *DBClientMock) AddPendingSecretCalls() []struct {
+	Ctx   context.Context
+	Token string
+	TTL   time.Duration
+} {
+	var calls []struct {
+		Ctx   context.Context
+		Token string
+		TTL   time.Duration
+	}
+	mock.lockAddPendingSecret.RLock()
+	calls = mock.calls.AddPendingSecret
+	mock.lockAddPendingSecret.RUnlock()
+	return calls
+}
+
+// CountTokenEntries calls CountTokenEntriesFunc.
+func (mock *DBClientMock) CountTokenEntries(ctx context.Context, project string) (int, error) {
+	if mock.CountTokenEntriesFunc == nil {
+		panic("DBClientMock.CountTokenEntriesFunc: method is nil but Client.CountTokenEntries was just called")
+	}
+	callInfo := struct {
+		Ctx     context.Context
+		Project string
+	}{
+		Ctx:     ctx,
+		Project: project,
+	}
+	mock.lockCountTokenEntries.Lock()
+	mock.calls.CountTokenEntries = append(mock.calls.CountTokenEntries, callInfo)
+	mock.lockCountTokenEntries.Unlock()
+	return mock.CountTokenEntriesFunc(ctx, project)
+}
+
+// CountTokenEntriesCalls gets all the calls that were made to CountTokenEntries.
+// Check the length with:
+//
+//	len(mockedClient.CountTokenEntriesCalls())
+func (mock *DBClientMock) CountTokenEntriesCalls() []struct {
+	Ctx     context.Context
+	Project string
+} {
+	var calls []struct {
+		Ctx     context.Context
+		Project string
+	}
+	mock.lockCountTokenEntries.RLock()
+	calls = mock.calls.CountTokenEntries
+	mock.lockCountTokenEntries.RUnlock()
+	return calls
+}
+
+// CreateProjectEntry calls CreateProjectEntryFunc.
+func (mock *DBClientMock) CreateProjectEntry(ctx context.Context, pe db.ProjectEntry) error {
+	if mock.CreateProjectEntryFunc == nil {
+		panic("DBClientMock.CreateProjectEntryFunc: method is nil but Client.CreateProjectEntry was just called")
+	}
+	callInfo := struct {
+		Ctx context.Context
+		Pe  db.ProjectEntry
+	}{
+		Ctx: ctx,
+		Pe:  pe,
+	}
+	mock.lockCreateProjectEntry.Lock()
+	mock.calls.CreateProjectEntry = append(mock.calls.CreateProjectEntry, callInfo)
+	mock.lockCreateProjectEntry.Unlock()
+	return mock.CreateProjectEntryFunc(ctx, pe)
+}
+
+// CreateProjectEntryCalls gets all the calls that were made to CreateProjectEntry.
+// Check the length with:
+//
+//	len(mockedClient.CreateProjectEntryCalls())
+func (mock *DBClientMock) CreateProjectEntryCalls() []struct {
+	Ctx context.Context
+	Pe  db.ProjectEntry
+} {
+	var calls []struct {
+		Ctx context.Context
+		Pe  db.ProjectEntry
+	}
+	mock.lockCreateProjectEntry.RLock()
+	calls = mock.calls.CreateProjectEntry
+	mock.lockCreateProjectEntry.RUnlock()
+	return calls
+}
+
+// CreateProjectWithToken calls CreateProjectWithTokenFunc.
+func (mock *DBClientMock) CreateProjectWithToken(ctx context.Context, pe db.ProjectEntry, token types.Token) error {
+	if mock.CreateProjectWithTokenFunc == nil {
+		panic("DBClientMock.CreateProjectWithTokenFunc: method is nil but Client.CreateProjectWithToken was just called")
+	}
+	callInfo := struct {
+		Ctx   context.Context
+		Pe    db.ProjectEntry
+		Token types.Token
+	}{
+		Ctx:   ctx,
+		Pe:    pe,
+		Token: token,
+	}
+	mock.lockCreateProjectWithToken.Lock()
+	mock.calls.CreateProjectWithToken = append(mock.calls.CreateProjectWithToken, callInfo)
+	mock.lockCreateProjectWithToken.Unlock()
+	return mock.CreateProjectWithTokenFunc(ctx, pe, token)
+}
+
+// CreateProjectWithTokenCalls gets all the calls that were made to CreateProjectWithToken.
+// Check the length with:
+//
+//	len(mockedClient.CreateProjectWithTokenCalls())
+func (mock *DBClientMock) CreateProjectWithTokenCalls() []struct {
+	Ctx   context.Context
+	Pe    db.ProjectEntry
+	Token types.Token
+} {
+	var calls []struct {
+		Ctx   context.Context
+		Pe    db.ProjectEntry
+		Token types.Token
+	}
+	mock.lockCreateProjectWithToken.RLock()
+	calls = mock.calls.CreateProjectWithToken
+	mock.lockCreateProjectWithToken.RUnlock()
+	return calls
+}
+
+// CreateTokenEntries calls CreateTokenEntriesFunc.
+func (mock *DBClientMock) CreateTokenEntries(ctx context.Context, tokens []types.Token) error {
+	if mock.CreateTokenEntriesFunc == nil {
+		panic("DBClientMock.CreateTokenEntriesFunc: method is nil but Client.CreateTokenEntries was just called")
+	}
+	callInfo := struct {
+		Ctx    context.Context
+		Tokens []types.Token
+	}{
+		Ctx:    ctx,
+		Tokens: tokens,
+	}
+	mock.lockCreateTokenEntries.Lock()
+	mock.calls.CreateTokenEntries = append(mock.calls.CreateTokenEntries, callInfo)
+	mock.lockCreateTokenEntries.Unlock()
+	return mock.CreateTokenEntriesFunc(ctx, tokens)
+}
+
+// CreateTokenEntriesCalls gets all the calls that were made to CreateTokenEntries.
+// Check the length with:
+//
+//	len(mockedClient.CreateTokenEntriesCalls())
+func (mock *DBClientMock) CreateTokenEntriesCalls() []struct {
+	Ctx    context.Context
+	Tokens []types.Token
+} {
+	var calls []struct {
+		Ctx    context.Context
+		Tokens []types.Token
+	}
+	mock.lockCreateTokenEntries.RLock()
+	calls = mock.calls.CreateTokenEntries
+	mock.lockCreateTokenEntries.RUnlock()
+	return calls
+}
+
+// CreateTokenEntry calls CreateTokenEntryFunc.
+func (mock *DBClientMock) CreateTokenEntry(ctx context.Context, token types.Token) error {
+	if mock.CreateTokenEntryFunc == nil {
+		panic("DBClientMock.CreateTokenEntryFunc: method is nil but Client.CreateTokenEntry was just called")
+	}
+	callInfo := struct {
+		Ctx   context.Context
+		Token types.Token
+	}{
+		Ctx:   ctx,
+		Token: token,
+	}
+	mock.lockCreateTokenEntry.Lock()
+	mock.calls.CreateTokenEntry = append(mock.calls.CreateTokenEntry, callInfo)
+	mock.lockCreateTokenEntry.Unlock()
+	return mock.CreateTokenEntryFunc(ctx, token)
+}
+
+// CreateTokenEntryCalls gets all the calls that were made to CreateTokenEntry.
+// Check the length with:
+//
+//	len(mockedClient.CreateTokenEntryCalls())
+func (mock *DBClientMock) CreateTokenEntryCalls() []struct {
+	Ctx   context.Context
+	Token types.Token
+} {
+	var calls []struct {
+		Ctx   context.Context
+		Token types.Token
+	}
+	mock.lockCreateTokenEntry.RLock()
+	calls = mock.calls.CreateTokenEntry
+	mock.lockCreateTokenEntry.RUnlock()
+	return calls
+}
+
+// DeleteExpiredTokens calls DeleteExpiredTokensFunc.
+func (mock *DBClientMock) DeleteExpiredTokens(ctx context.Context, project string, now time.Time) (int, error) {
+	if mock.DeleteExpiredTokensFunc == nil {
+		panic("DBClientMock.DeleteExpiredTokensFunc: method is nil but Client.DeleteExpiredTokens was just called")
+	}
+	callInfo := struct {
+		Ctx     context.Context
+		Project string
+		Now     time.Time
+	}{
+		Ctx:     ctx,
+		Project: project,
+		Now:     now,
+	}
+	mock.lockDeleteExpiredTokens.Lock()
+	mock.calls.DeleteExpiredTokens = append(mock.calls.DeleteExpiredTokens, callInfo)
+	mock.lockDeleteExpiredTokens.Unlock()
+	return mock.DeleteExpiredTokensFunc(ctx, project, now)
+}
+
+// DeleteExpiredTokensCalls gets all the calls that were made to DeleteExpiredTokens.
+// Check the length with:
+//
+//	len(mockedClient.DeleteExpiredTokensCalls())
+func (mock *DBClientMock) DeleteExpiredTokensCalls() []struct {
+	Ctx     context.Context
+	Project string
+	Now     time.Time
+} {
+	var calls []struct {
+		Ctx     context.Context
+		Project string
+		Now     time.Time
+	}
+	mock.lockDeleteExpiredTokens.RLock()
+	calls = mock.calls.DeleteExpiredTokens
+	mock.lockDeleteExpiredTokens.RUnlock()
+	return calls
+}
+
+// DeleteProjectEntry calls DeleteProjectEntryFunc.
+func (mock *DBClientMock) DeleteProjectEntry(ctx context.Context, project string) error {
+	if mock.DeleteProjectEntryFunc == nil {
+		panic("DBClientMock.DeleteProjectEntryFunc: method is nil but Client.DeleteProjectEntry was just called")
+	}
+	callInfo := struct {
+		Ctx     context.Context
+		Project string
+	}{
+		Ctx:     ctx,
+		Project: project,
+	}
+	mock.lockDeleteProjectEntry.Lock()
+	mock.calls.DeleteProjectEntry = append(mock.calls.DeleteProjectEntry, callInfo)
+	mock.lockDeleteProjectEntry.Unlock()
+	return mock.DeleteProjectEntryFunc(ctx, project)
+}
+
+// DeleteProjectEntryCalls gets all the calls that were made to DeleteProjectEntry.
+// Check the length with:
+//
+//	len(mockedClient.DeleteProjectEntryCalls())
+func (mock *DBClientMock) DeleteProjectEntryCalls() []struct {
+	Ctx     context.Context
+	Project string
+} {
+	var calls []struct {
+		Ctx     context.Context
+		Project string
+	}
+	mock.lockDeleteProjectEntry.RLock()
+	calls = mock.calls.DeleteProjectEntry
+	mock.lockDeleteProjectEntry.RUnlock()
+	return calls
+}
+
+// DeleteTokenEntry calls DeleteTokenEntryFunc.
+func (mock *DBClientMock) DeleteTokenEntry(ctx context.Context, token string) error {
+	if mock.DeleteTokenEntryFunc == nil {
+		panic("DBClientMock.DeleteTokenEntryFunc: method is nil but Client.DeleteTokenEntry was just called")
+	}
+	callInfo := struct {
+		Ctx   context.Context
+		Token string
+	}{
+		Ctx:   ctx,
+		Token: token,
+	}
+	mock.lockDeleteTokenEntry.Lock()
+	mock.calls.DeleteTokenEntry = append(mock.calls.DeleteTokenEntry, callInfo)
+	mock.lockDeleteTokenEntry.Unlock()
+	return mock.DeleteTokenEntryFunc(ctx, token)
+}
+
+// DeleteTokenEntryCalls gets all the calls that were made to DeleteTokenEntry.
+// Check the length with:
+//
+//	len(mockedClient.DeleteTokenEntryCalls())
+func (mock *DBClientMock) DeleteTokenEntryCalls() []struct {
+	Ctx   context.Context
+	Token string
+} {
+	var calls []struct {
+		Ctx   context.Context
+		Token string
+	}
+	mock.lockDeleteTokenEntry.RLock()
+	calls = mock.calls.DeleteTokenEntry
+	mock.lockDeleteTokenEntry.RUnlock()
+	return calls
+}
+
+// FindDuplicateRepositories calls FindDuplicateRepositoriesFunc.
+func (mock *DBClientMock) FindDuplicateRepositories(ctx context.Context) (map[string][]string, error) {
+	if mock.FindDuplicateRepositoriesFunc == nil {
+		panic("DBClientMock.FindDuplicateRepositoriesFunc: method is nil but Client.FindDuplicateRepositories was just called")
+	}
+	callInfo := struct {
+		Ctx context.Context
+	}{
+		Ctx: ctx,
+	}
+	mock.lockFindDuplicateRepositories.Lock()
+	mock.calls.FindDuplicateRepositories = append(mock.calls.FindDuplicateRepositories, callInfo)
+	mock.lockFindDuplicateRepositories.Unlock()
+	return mock.FindDuplicateRepositoriesFunc(ctx)
+}
+
+// FindDuplicateRepositoriesCalls gets all the calls that were made to FindDuplicateRepositories.
+// Check the length with:
+//
+//	len(mockedClient.FindDuplicateRepositoriesCalls())
+func (mock *DBClientMock) FindDuplicateRepositoriesCalls() []struct {
+	Ctx context.Context
+} {
+	var calls []struct {
+		Ctx context.Context
+	}
+	mock.lockFindDuplicateRepositories.RLock()
+	calls = mock.calls.FindDuplicateRepositories
+	mock.lockFindDuplicateRepositories.RUnlock()
+	return calls
+}
+
+// Health calls HealthFunc.
+func (mock *DBClientMock) Health(ctx context.Context) error {
+	if mock.HealthFunc == nil {
+		panic("DBClientMock.HealthFunc: method is nil but Client.Health was just called")
+	}
+	callInfo := struct {
+		Ctx context.Context
+	}{
+		Ctx: ctx,
+	}
+	mock.lockHealth.Lock()
+	mock.calls.Health = append(mock.calls.Health, callInfo)
+	mock.lockHealth.Unlock()
+	return mock.HealthFunc(ctx)
+}
+
+// HealthCalls gets all the calls that were made to Health.
+// Check the length with:
+//
+//	len(mockedClient.HealthCalls())
+func (mock *DBClientMock) HealthCalls() []struct {
+	Ctx context.Context
+} {
+	var calls []struct {
+		Ctx context.Context
+	}
+	mock.lockHealth.RLock()
+	calls = mock.calls.Health
+	mock.lockHealth.RUnlock()
+	return calls
+}
+
+// ImportProject calls ImportProjectFunc.
+func (mock *DBClientMock) ImportProject(ctx context.Context, data []byte) error {
+	if mock.ImportProjectFunc == nil {
+		panic("DBClientMock.ImportProjectFunc: method is nil but Client.ImportProject was just called")
+	}
+	callInfo := struct {
+		Ctx  context.Context
+		Data []byte
+	}{
+		Ctx:  ctx,
+		Data: data,
+	}
+	mock.lockImportProject.Lock()
+	mock.calls.ImportProject = append(mock.calls.ImportProject, callInfo)
+	mock.lockImportProject.Unlock()
+	return mock.ImportProjectFunc(ctx, data)
+}
+
+// ImportProjectCalls gets all the calls that were made to ImportProject.
+// Check the length with:
+//
+//	len(mockedClient.ImportProjectCalls())
+func (mock *DBClientMock) ImportProjectCalls() []struct {
+	Ctx  context.Context
+	Data []byte
+} {
+	var calls []struct {
+		Ctx  context.Context
+		Data []byte
+	}
+	mock.lockImportProject.RLock()
+	calls = mock.calls.ImportProject
+	mock.lockImportProject.RUnlock()
+	return calls
+}
+
+// IssueToken calls IssueTokenFunc.
+func (mock *DBClientMock) IssueToken(ctx context.Context, project string, roleID string, ttl time.Duration) (types.Token, error) {
+	if mock.IssueTokenFunc == nil {
+		panic("DBClientMock.IssueTokenFunc: method is nil but Client.IssueToken was just called")
+	}
+	callInfo := struct {
+		Ctx     context.Context
+		Project string
+		RoleID  string
+		TTL     time.Duration
+	}{
+		Ctx:     ctx,
+		Project: project,
+		RoleID:  roleID,
+		TTL:     ttl,
+	}
+	mock.lockIssueToken.Lock()
+	mock.calls.IssueToken = append(mock.calls.IssueToken, callInfo)
+	mock.lockIssueToken.Unlock()
+	return mock.IssueTokenFunc(ctx, project, roleID, ttl)
+}
+
+// IssueTokenCalls gets all the calls that were made to IssueToken.
+// Check the length with:
+//
+//	len(mockedClient.IssueTokenCalls())
+func (mock *DBClientMock) IssueTokenCalls() []struct {
+	Ctx     context.Context
+	Project string
+	RoleID  string
+	TTL     time.Duration
+} {
+	var calls []struct {
+		Ctx     context.Context
+		Project string
+		RoleID  string
+		TTL     time.Duration
+	}
+	mock.lockIssueToken.RLock()
+	calls = mock.calls.IssueToken
+	mock.lockIssueToken.RUnlock()
+	return calls
+}
+
+// ListExpiredTokenEntriesGlobal calls ListExpiredTokenEntriesGlobalFunc.
+func (mock *DBClientMock) ListExpiredTokenEntriesGlobal(ctx context.Context, now time.Time, limit int) ([]db.TokenEntry, error) {
+	if mock.ListExpiredTokenEntriesGlobalFunc == nil {
+		panic("DBClientMock.ListExpiredTokenEntriesGlobalFunc: method is nil but Client.ListExpiredTokenEntriesGlobal was just called")
+	}
+	callInfo := struct {
+		Ctx   context.Context
+		Now   time.Time
+		Limit int
+	}{
+		Ctx:   ctx,
+		Now:   now,
+		Limit: limit,
+	}
+	mock.lockListExpiredTokenEntriesGlobal.Lock()
+	mock.calls.ListExpiredTokenEntriesGlobal = append(mock.calls.ListExpiredTokenEntriesGlobal, callInfo)
+	mock.lockListExpiredTokenEntriesGlobal.Unlock()
+	return mock.ListExpiredTokenEntriesGlobalFunc(ctx, now, limit)
+}
+
+// ListExpiredTokenEntriesGlobalCalls gets all the calls that were made to ListExpiredTokenEntriesGlobal.
+// Check the length with:
+//
+//	len(mockedClient.ListExpiredTokenEntriesGlobalCalls())
+func (mock *DBClientMock) ListExpiredTokenEntriesGlobalCalls() []struct {
+	Ctx   context.Context
+	Now   time.Time
+	Limit int
+} {
+	var calls []struct {
+		Ctx   context.Context
+		Now   time.Time
+		Limit int
+	}
+	mock.lockListExpiredTokenEntriesGlobal.RLock()
+	calls = mock.calls.ListExpiredTokenEntriesGlobal
+	mock.lockListExpiredTokenEntriesGlobal.RUnlock()
+	return calls
+}
+
+// ListProjectEntries calls ListProjectEntriesFunc.
+func (mock *DBClientMock) ListProjectEntries(ctx context.Context, opts db.ListOptions) ([]db.ProjectEntry, string, error) {
+	if mock.ListProjectEntriesFunc == nil {
+		panic("DBClientMock.ListProjectEntriesFunc: method is nil but Client.ListProjectEntries was just called")
+	}
+	callInfo := struct {
+		Ctx  context.Context
+		Opts db.ListOptions
+	}{
+		Ctx:  ctx,
+		Opts: opts,
+	}
+	mock.lockListProjectEntries.Lock()
+	mock.calls.ListProjectEntries = append(mock.calls.ListProjectEntries, callInfo)
+	mock.lockListProjectEntries.Unlock()
+	return mock.ListProjectEntriesFunc(ctx, opts)
+}
+
+// ListProjectEntriesCalls gets all the calls that were made to ListProjectEntries.
+// Check the length with:
+//
+//	len(mockedClient.ListProjectEntriesCalls())
+func (mock *DBClientMock) ListProjectEntriesCalls() []struct {
+	Ctx  context.Context
+	Opts db.ListOptions
+} {
+	var calls []struct {
+		Ctx  context.Context
+		Opts db.ListOptions
+	}
+	mock.lockListProjectEntries.RLock()
+	calls = mock.calls.ListProjectEntries
+	mock.lockListProjectEntries.RUnlock()
+	return calls
+}
+
+// ListProjectsByRepository calls ListProjectsByRepositoryFunc.
+func (mock *DBClientMock) ListProjectsByRepository(ctx context.Context, repository string) ([]db.ProjectEntry, error) {
+	if mock.ListProjectsByRepositoryFunc == nil {
+		panic("DBClientMock.ListProjectsByRepositoryFunc: method is nil but Client.ListProjectsByRepository was just called")
+	}
+	callInfo := struct {
+		Ctx        context.Context
+		Repository string
+	}{
+		Ctx:        ctx,
+		Repository: repository,
+	}
+	mock.lockListProjectsByRepository.Lock()
+	mock.calls.ListProjectsByRepository = append(mock.calls.ListProjectsByRepository, callInfo)
+	mock.lockListProjectsByRepository.Unlock()
+	return mock.ListProjectsByRepositoryFunc(ctx, repository)
+}
+
+// ListProjectsByRepositoryCalls gets all the calls that were made to ListProjectsByRepository.
+// Check the length with:
+//
+//	len(mockedClient.ListProjectsByRepositoryCalls())
+func (mock *DBClientMock) ListProjectsByRepositoryCalls() []struct {
+	Ctx        context.Context
+	Repository string
+} {
+	var calls []struct {
+		Ctx        context.Context
+		Repository string
+	}
+	mock.lockListProjectsByRepository.RLock()
+	calls = mock.calls.ListProjectsByRepository
+	mock.lockListProjectsByRepository.RUnlock()
+	return calls
+}
+
+// ListTokenChanges calls ListTokenChangesFunc.
+func (mock *DBClientMock) ListTokenChanges(ctx context.Context, project string, syncToken string) (db.TokenChangeSet, error) {
+	if mock.ListTokenChangesFunc == nil {
+		panic("DBClientMock.ListTokenChangesFunc: method is nil but Client.ListTokenChanges was just called")
+	}
+	callInfo := struct {
+		Ctx       context.Context
+		Project   string
+		SyncToken string
+	}{
+		Ctx:       ctx,
+		Project:   project,
+		SyncToken: syncToken,
+	}
+	mock.lockListTokenChanges.Lock()
+	mock.calls.ListTokenChanges = append(mock.calls.ListTokenChanges, callInfo)
+	mock.lockListTokenChanges.Unlock()
+	return mock.ListTokenChangesFunc(ctx, project, syncToken)
+}
+
+// ListTokenChangesCalls gets all the calls that were made to ListTokenChanges.
+// Check the length with:
+//
+//	len(mockedClient.ListTokenChangesCalls())
+func (mock *DBClientMock) ListTokenChangesCalls() []struct {
+	Ctx       context.Context
+	Project   string
+	SyncToken string
+} {
+	var calls []struct {
+		Ctx       context.Context
+		Project   string
+		SyncToken string
+	}
+	mock.lockListTokenChanges.RLock()
+	calls = mock.calls.ListTokenChanges
+	mock.lockListTokenChanges.RUnlock()
+	return calls
+}
+
+// ListTokenEntries calls ListTokenEntriesFunc.
+func (mock *DBClientMock) ListTokenEntries(ctx context.Context, project string) ([]db.TokenEntry, error) {
+	if mock.ListTokenEntriesFunc == nil {
+		panic("DBClientMock.ListTokenEntriesFunc: method is nil but Client.ListTokenEntries was just called")
+	}
+	callInfo := struct {
+		Ctx     context.Context
+		Project string
+	}{
+		Ctx:     ctx,
+		Project: project,
+	}
+	mock.lockListTokenEntries.Lock()
+	mock.calls.ListTokenEntries = append(mock.calls.ListTokenEntries, callInfo)
+	mock.lockListTokenEntries.Unlock()
+	return mock.ListTokenEntriesFunc(ctx, project)
+}
+
+// ListTokenEntriesCalls gets all the calls that were made to ListTokenEntries.
+// Check the length with:
+//
+//	len(mockedClient.ListTokenEntriesCalls())
+func (mock *DBClientMock) ListTokenEntriesCalls() []struct {
+	Ctx     context.Context
+	Project string
+} {
+	var calls []struct {
+		Ctx     context.Context
+		Project string
+	}
+	mock.lockListTokenEntries.RLock()
+	calls = mock.calls.ListTokenEntries
+	mock.lockListTokenEntries.RUnlock()
+	return calls
+}
+
+// ListTokenEntriesByUrgency calls ListTokenEntriesByUrgencyFunc.
+func (mock *DBClientMock) ListTokenEntriesByUrgency(ctx context.Context, project string, now time.Time) ([]db.TokenWithTTL, error) {
+	if mock.ListTokenEntriesByUrgencyFunc == nil {
+		panic("DBClientMock.ListTokenEntriesByUrgencyFunc: method is nil but Client.ListTokenEntriesByUrgency was just called")
+	}
+	callInfo := struct {
+		Ctx     context.Context
+		Project string
+		Now     time.Time
+	}{
+		Ctx:     ctx,
+		Project: project,
+		Now:     now,
+	}
+	mock.lockListTokenEntriesByUrgency.Lock()
+	mock.calls.ListTokenEntriesByUrgency = append(mock.calls.ListTokenEntriesByUrgency, callInfo)
+	mock.lockListTokenEntriesByUrgency.Unlock()
+	return mock.ListTokenEntriesByUrgencyFunc(ctx, project, now)
+}
+
+// ListTokenEntriesByUrgencyCalls gets all the calls that were made to ListTokenEntriesByUrgency.
+// Check the length with:
+//
+//	len(mockedClient.ListTokenEntriesByUrgencyCalls())
+func (mock *DBClientMock) ListTokenEntriesByUrgencyCalls() []struct {
+	Ctx     context.Context
+	Project string
+	Now     time.Time
+} {
+	var calls []struct {
+		Ctx     context.Context
+		Project string
+		Now     time.Time
+	}
+	mock.lockListTokenEntriesByUrgency.RLock()
+	calls = mock.calls.ListTokenEntriesByUrgency
+	mock.lockListTokenEntriesByUrgency.RUnlock()
+	return calls
+}
+
+// ListTokenEntriesCreatedBetween calls ListTokenEntriesCreatedBetweenFunc.
+func (mock *DBClientMock) ListTokenEntriesCreatedBetween(ctx context.Context, project string, start time.Time, end time.Time) ([]db.TokenEntry, error) {
+	if mock.ListTokenEntriesCreatedBetweenFunc == nil {
+		panic("DBClientMock.ListTokenEntriesCreatedBetweenFunc: method is nil but Client.ListTokenEntriesCreatedBetween was just called")
+	}
+	callInfo := struct {
+		Ctx     context.Context
+		Project string
+		Start   time.Time
+		End     time.Time
+	}{
+		Ctx:     ctx,
+		Project: project,
+		Start:   start,
+		End:     end,
+	}
+	mock.lockListTokenEntriesCreatedBetween.Lock()
+	mock.calls.ListTokenEntriesCreatedBetween = append(mock.calls.ListTokenEntriesCreatedBetween, callInfo)
+	mock.lockListTokenEntriesCreatedBetween.Unlock()
+	return mock.ListTokenEntriesCreatedBetweenFunc(ctx, project, start, end)
+}
+
+// ListTokenEntriesCreatedBetweenCalls gets all the calls that were made to ListTokenEntriesCreatedBetween.
+// Check the length with:
+//
+//	len(mockedClient.ListTokenEntriesCreatedBetweenCalls())
+func (mock *DBClientMock) ListTokenEntriesCreatedBetweenCalls() []struct {
+	Ctx     context.Context
+	Project string
+	Start   time.Time
+	End     time.Time
+} {
+	var calls []struct {
+		Ctx     context.Context
+		Project string
+		Start   time.Time
+		End     time.Time
+	}
+	mock.lockListTokenEntriesCreatedBetween.RLock()
+	calls = mock.calls.ListTokenEntriesCreatedBetween
+	mock.lockListTokenEntriesCreatedBetween.RUnlock()
+	return calls
+}
+
+// ListTokenEntriesPaged calls ListTokenEntriesPagedFunc.
+func (mock *DBClientMock) ListTokenEntriesPaged(ctx context.Context, project string, limit int, cursor string) (db.ListTokenEntriesResult, error) {
+	if mock.ListTokenEntriesPagedFunc == nil {
+		panic("DBClientMock.ListTokenEntriesPagedFunc: method is nil but Client.ListTokenEntriesPaged was just called")
+	}
+	callInfo := struct {
+		Ctx     context.Context
+		Project string
+		Limit   int
+		Cursor  string
+	}{
+		Ctx:     ctx,
+		Project: project,
+		Limit:   limit,
+		Cursor:  cursor,
+	}
+	mock.lockListTokenEntriesPaged.Lock()
+	mock.calls.ListTokenEntriesPaged = append(mock.calls.ListTokenEntriesPaged, callInfo)
+	mock.lockListTokenEntriesPaged.Unlock()
+	return mock.ListTokenEntriesPagedFunc(ctx, project, limit, cursor)
+}
+
+// ListTokenEntriesPagedCalls gets all the calls that were made to ListTokenEntriesPaged.
+// Check the length with:
+//
+//	len(mockedClient.ListTokenEntriesPagedCalls())
+func (mock *DBClientMock) ListTokenEntriesPagedCalls() []struct {
+	Ctx     context.Context
+	Project string
+	Limit   int
+	Cursor  string
+} {
+	var calls []struct {
+		Ctx     context.Context
+		Project string
+		Limit   int
+		Cursor  string
+	}
+	mock.lockListTokenEntriesPaged.RLock()
+	calls = mock.calls.ListTokenEntriesPaged
+	mock.lockListTokenEntriesPaged.RUnlock()
+	return calls
+}
+
+// ListTokenEntriesWithTTL calls ListTokenEntriesWithTTLFunc.
+func (mock *DBClientMock) ListTokenEntriesWithTTL(ctx context.Context, project string, now time.Time) ([]db.TokenWithTTL, error) {
+	if mock.ListTokenEntriesWithTTLFunc == nil {
+		panic("DBClientMock.ListTokenEntriesWithTTLFunc: method is nil but Client.ListTokenEntriesWithTTL was just called")
+	}
+	callInfo := struct {
+		Ctx     context.Context
+		Project string
+		Now     time.Time
+	}{
+		Ctx:     ctx,
+		Project: project,
+		Now:     now,
+	}
+	mock.lockListTokenEntriesWithTTL.Lock()
+	mock.calls.ListTokenEntriesWithTTL = append(mock.calls.ListTokenEntriesWithTTL, callInfo)
+	mock.lockListTokenEntriesWithTTL.Unlock()
+	return mock.ListTokenEntriesWithTTLFunc(ctx, project, now)
+}
+
+// ListTokenEntriesWithTTLCalls gets all the calls that were made to ListTokenEntriesWithTTL.
+// Check the length with:
+//
+//	len(mockedClient.ListTokenEntriesWithTTLCalls())
+func (mock *DBClientMock) ListTokenEntriesWithTTLCalls() []struct {
+	Ctx     context.Context
+	Project string
+	Now     time.Time
+} {
+	var calls []struct {
+		Ctx     context.Context
+		Project string
+		Now     time.Time
+	}
+	mock.lockListTokenEntriesWithTTL.RLock()
+	calls = mock.calls.ListTokenEntriesWithTTL
+	mock.lockListTokenEntriesWithTTL.RUnlock()
+	return calls
+}
+
+// ListTokenHistory calls ListTokenHistoryFunc.
+func (mock *DBClientMock) ListTokenHistory(ctx context.Context, project string) ([]db.TokenHistoryEntry, error) {
+	if mock.ListTokenHistoryFunc == nil {
+		panic("DBClientMock.ListTokenHistoryFunc: method is nil but Client.ListTokenHistory was just called")
+	}
+	callInfo := struct {
+		Ctx     context.Context
+		Project string
+	}{
+		Ctx:     ctx,
+		Project: project,
+	}
+	mock.lockListTokenHistory.Lock()
+	mock.calls.ListTokenHistory = append(mock.calls.ListTokenHistory, callInfo)
+	mock.lockListTokenHistory.Unlock()
+	return mock.ListTokenHistoryFunc(ctx, project)
+}
+
+// ListTokenHistoryCalls gets all the calls that were made to ListTokenHistory.
+// Check the length with:
+//
+//	len(mockedClient.ListTokenHistoryCalls())
+func (mock *DBClientMock) ListTokenHistoryCalls() []struct {
+	Ctx     context.Context
+	Project string
+} {
+	var calls []struct {
+		Ctx     context.Context
+		Project string
+	}
+	mock.lockListTokenHistory.RLock()
+	calls = mock.calls.ListTokenHistory
+	mock.lockListTokenHistory.RUnlock()
+	return calls
+}
+
+// NextTokenSequence calls NextTokenSequenceFunc.
+func (mock *DBClientMock) NextTokenSequence(ctx context.Context, project string) (int64, error) {
+	if mock.NextTokenSequenceFunc == nil {
+		panic("DBClientMock.NextTokenSequenceFunc: method is nil but Client.NextTokenSequence was just called")
+	}
+	callInfo := struct {
+		Ctx     context.Context
+		Project string
+	}{
+		Ctx:     ctx,
+		Project: project,
+	}
+	mock.lockNextTokenSequence.Lock()
+	mock.calls.NextTokenSequence = append(mock.calls.NextTokenSequence, callInfo)
+	mock.lockNextTokenSequence.Unlock()
+	return mock.NextTokenSequenceFunc(ctx, project)
+}
+
+// NextTokenSequenceCalls gets all the calls that were made to NextTokenSequence.
+// Check the length with:
+//
+//	len(mockedClient.NextTokenSequenceCalls())
+func (mock *DBClientMock) NextTokenSequenceCalls() []struct {
+	Ctx     context.Context
+	Project string
+} {
+	var calls []struct {
+		Ctx     context.Context
+		Project string
+	}
+	mock.lockNextTokenSequence.RLock()
+	calls = mock.calls.NextTokenSequence
+	mock.lockNextTokenSequence.RUnlock()
+	return calls
+}
+
+// PreviewAffectedTokenCount calls PreviewAffectedTokenCountFunc.
+func (mock *DBClientMock) PreviewAffectedTokenCount(ctx context.Context, project string, predicate db.Predicate) (int, error) {
+	if mock.PreviewAffectedTokenCountFunc == nil {
+		panic("DBClientMock.PreviewAffectedTokenCountFunc: method is nil but Client.PreviewAffectedTokenCount was just called")
+	}
+	callInfo := struct {
+		Ctx       context.Context
+		Project   string
+		Predicate db.Predicate
+	}{
+		Ctx:       ctx,
+		Project:   project,
+		Predicate: predicate,
+	}
+	mock.lockPreviewAffectedTokenCount.Lock()
+	mock.calls.PreviewAffectedTokenCount = append(mock.calls.PreviewAffectedTokenCount, callInfo)
+	mock.lockPreviewAffectedTokenCount.Unlock()
+	return mock.PreviewAffectedTokenCountFunc(ctx, project, predicate)
+}
+
+// PreviewAffectedTokenCountCalls gets all the calls that were made to PreviewAffectedTokenCount.
+// Check the length with:
+//
+//	len(mockedClient.PreviewAffectedTokenCountCalls())
+func (mock *DBClientMock) PreviewAffectedTokenCountCalls() []struct {
+	Ctx       context.Context
+	Project   string
+	Predicate db.Predicate
+} {
+	var calls []struct {
+		Ctx       context.Context
+		Project   string
+		Predicate db.Predicate
+	}
+	mock.lockPreviewAffectedTokenCount.RLock()
+	calls = mock.calls.PreviewAffectedTokenCount
+	mock.lockPreviewAffectedTokenCount.RUnlock()
+	return calls
+}
+
+// PromotePendingSecret calls PromotePendingSecretFunc.
+func (mock *DBClientMock) PromotePendingSecret(ctx context.Context, token string) error {
+	if mock.PromotePendingSecretFunc == nil {
+		panic("DBClientMock.PromotePendingSecretFunc: method is nil but Client.PromotePendingSecret was just called")
+	}
+	callInfo := struct {
+		Ctx   context.Context
+		Token string
+	}{
+		Ctx:   ctx,
+		Token: token,
+	}
+	mock.lockPromotePendingSecret.Lock()
+	mock.calls.PromotePendingSecret = append(mock.calls.PromotePendingSecret, callInfo)
+	mock.lockPromotePendingSecret.Unlock()
+	return mock.PromotePendingSecretFunc(ctx, token)
+}
+
+// PromotePendingSecretCalls gets all the calls that were made to PromotePendingSecret.
+// Check the length with:
+//
+//	len(mockedClient.PromotePendingSecretCalls())
+func (mock *DBClientMock) PromotePendingSecretCalls() []struct {
+	Ctx   context.Context
+	Token string
+} {
+	var calls []struct {
+		Ctx   context.Context
+		Token string
+	}
+	mock.lockPromotePendingSecret.RLock()
+	calls = mock.calls.PromotePendingSecret
+	mock.lockPromotePendingSecret.RUnlock()
+	return calls
+}
+
+// PurgeDeletedProjects calls PurgeDeletedProjectsFunc.
+func (mock *DBClientMock) PurgeDeletedProjects(ctx context.Context, now time.Time) (int, error) {
+	if mock.PurgeDeletedProjectsFunc == nil {
+		panic("DBClientMock.PurgeDeletedProjectsFunc: method is nil but Client.PurgeDeletedProjects was just called")
+	}
+	callInfo := struct {
+		Ctx context.Context
+		Now time.Time
+	}{
+		Ctx: ctx,
+		Now: now,
+	}
+	mock.lockPurgeDeletedProjects.Lock()
+	mock.calls.PurgeDeletedProjects = append(mock.calls.PurgeDeletedProjects, callInfo)
+	mock.lockPurgeDeletedProjects.Unlock()
+	return mock.PurgeDeletedProjectsFunc(ctx, now)
+}
+
+// PurgeDeletedProjectsCalls gets all the calls that were made to PurgeDeletedProjects.
+// Check the length with:
+//
+//	len(mockedClient.PurgeDeletedProjectsCalls())
+func (mock *DBClientMock) PurgeDeletedProjectsCalls() []struct {
+	Ctx context.Context
+	Now time.Time
+} {
+	var calls []struct {
+		Ctx context.Context
+		Now time.Time
+	}
+	mock.lockPurgeDeletedProjects.RLock()
+	calls = mock.calls.PurgeDeletedProjects
+	mock.lockPurgeDeletedProjects.RUnlock()
+	return calls
+}
+
+// PurgeTokenTombstones calls PurgeTokenTombstonesFunc.
+func (mock *DBClientMock) PurgeTokenTombstones(ctx context.Context, before time.Time) (int, error) {
+	if mock.PurgeTokenTombstonesFunc == nil {
+		panic("DBClientMock.PurgeTokenTombstonesFunc: method is nil but Client.PurgeTokenTombstones was just called")
+	}
+	callInfo := struct {
+		Ctx    context.Context
+		Before time.Time
+	}{
+		Ctx:    ctx,
+		Before: before,
+	}
+	mock.lockPurgeTokenTombstones.Lock()
+	mock.calls.PurgeTokenTombstones = append(mock.calls.PurgeTokenTombstones, callInfo)
+	mock.lockPurgeTokenTombstones.Unlock()
+	return mock.PurgeTokenTombstonesFunc(ctx, before)
+}
+
+// PurgeTokenTombstonesCalls gets all the calls that were made to PurgeTokenTombstones.
+// Check the length with:
+//
+//	len(mockedClient.PurgeTokenTombstonesCalls())
+func (mock *DBClientMock) PurgeTokenTombstonesCalls() []struct {
+	Ctx    context.Context
+	Before time.Time
+} {
+	var calls []struct {
+		Ctx    context.Context
+		Before time.Time
+	}
+	mock.lockPurgeTokenTombstones.RLock()
+	calls = mock.calls.PurgeTokenTombstones
+	mock.lockPurgeTokenTombstones.RUnlock()
+	return calls
+}
+
+// ReadProjectActivity calls ReadProjectActivityFunc.
+func (mock *DBClientMock) ReadProjectActivity(ctx context.Context, project string) (db.ProjectEntry, time.Time, error) {
+	if mock.ReadProjectActivityFunc == nil {
+		panic("DBClientMock.ReadProjectActivityFunc: method is nil but Client.ReadProjectActivity was just called")
+	}
+	callInfo := struct {
+		Ctx     context.Context
+		Project string
+	}{
+		Ctx:     ctx,
+		Project: project,
+	}
+	mock.lockReadProjectActivity.Lock()
+	mock.calls.ReadProjectActivity = append(mock.calls.ReadProjectActivity, callInfo)
+	mock.lockReadProjectActivity.Unlock()
+	return mock.ReadProjectActivityFunc(ctx, project)
+}
+
+// ReadProjectActivityCalls gets all the calls that were made to ReadProjectActivity.
+// Check the length with:
+//
+//	len(mockedClient.ReadProjectActivityCalls())
+func (mock *DBClientMock) ReadProjectActivityCalls() []struct {
+	Ctx     context.Context
+	Project string
+} {
+	var calls []struct {
+		Ctx     context.Context
+		Project string
+	}
+	mock.lockReadProjectActivity.RLock()
+	calls = mock.calls.ReadProjectActivity
+	mock.lockReadProjectActivity.RUnlock()
+	return calls
+}
+
+// ReadProjectEntry calls ReadProjectEntryFunc.
+func (mock *DBClientMock) ReadProjectEntry(ctx context.Context, project string) (db.ProjectEntry, error) {
+	if mock.ReadProjectEntryFunc == nil {
+		panic("DBClientMock.ReadProjectEntryFunc: method is nil but Client.ReadProjectEntry was just called")
+	}
+	callInfo := struct {
+		Ctx     context.Context
+		Project string
+	}{
+		Ctx:     ctx,
+		Project: project,
 	}
-	lockCreateProjectEntry sync.RWMutex
-	lockCreateTokenEntry   sync.RWMutex
-	lockDeleteProjectEntry sync.RWMutex
-	lockDeleteTokenEntry   sync.RWMutex
-	lockHealth             sync.RWMutex
-	lockListTokenEntries   sync.RWMutex
-	lockReadProjectEntry   sync.RWMutex
-	lockReadTokenEntry     sync.RWMutex
+	mock.lockReadProjectEntry.Lock()
+	mock.calls.ReadProjectEntry = append(mock.calls.ReadProjectEntry, callInfo)
+	mock.lockReadProjectEntry.Unlock()
+	return mock.ReadProjectEntryFunc(ctx, project)
 }
 
-// CreateProjectEntry calls CreateProjectEntryFunc.
-func (mock *DBClientMock) CreateProjectEntry(ctx context.Context, pe db.ProjectEntry) error {
-	if mock.CreateProjectEntryFunc == nil {
-		panic("DBClientMock.CreateProjectEntryFunc: method is nil but Client.CreateProjectEntry was just called")
+// ReadProjectEntryCalls gets all the calls that were made to ReadProjectEntry.
+// Check the length with:
+//
+//	len(mockedClient.ReadProjectEntryCalls())
+func (mock *DBClientMock) ReadProjectEntryCalls() []struct {
+	Ctx     context.Context
+	Project string
+} {
+	var calls []struct {
+		Ctx     context.Context
+		Project string
+	}
+	mock.lockReadProjectEntry.RLock()
+	calls = mock.calls.ReadProjectEntry
+	mock.lockReadProjectEntry.RUnlock()
+	return calls
+}
+
+// ReadProjectEntryWithETag calls ReadProjectEntryWithETagFunc.
+func (mock *DBClientMock) ReadProjectEntryWithETag(ctx context.Context, project string) (db.ProjectEntry, string, error) {
+	if mock.ReadProjectEntryWithETagFunc == nil {
+		panic("DBClientMock.ReadProjectEntryWithETagFunc: method is nil but Client.ReadProjectEntryWithETag was just called")
+	}
+	callInfo := struct {
+		Ctx     context.Context
+		Project string
+	}{
+		Ctx:     ctx,
+		Project: project,
+	}
+	mock.lockReadProjectEntryWithETag.Lock()
+	mock.calls.ReadProjectEntryWithETag = append(mock.calls.ReadProjectEntryWithETag, callInfo)
+	mock.lockReadProjectEntryWithETag.Unlock()
+	return mock.ReadProjectEntryWithETagFunc(ctx, project)
+}
+
+// ReadProjectEntryWithETagCalls gets all the calls that were made to ReadProjectEntryWithETag.
+// Check the length with:
+//
+//	len(mockedClient.ReadProjectEntryWithETagCalls())
+func (mock *DBClientMock) ReadProjectEntryWithETagCalls() []struct {
+	Ctx     context.Context
+	Project string
+} {
+	var calls []struct {
+		Ctx     context.Context
+		Project string
+	}
+	mock.lockReadProjectEntryWithETag.RLock()
+	calls = mock.calls.ReadProjectEntryWithETag
+	mock.lockReadProjectEntryWithETag.RUnlock()
+	return calls
+}
+
+// ReadProjectsWithTokenCounts calls ReadProjectsWithTokenCountsFunc.
+func (mock *DBClientMock) ReadProjectsWithTokenCounts(ctx context.Context, ids []string) ([]db.ProjectSummary, error) {
+	if mock.ReadProjectsWithTokenCountsFunc == nil {
+		panic("DBClientMock.ReadProjectsWithTokenCountsFunc: method is nil but Client.ReadProjectsWithTokenCounts was just called")
 	}
 	callInfo := struct {
 		Ctx context.Context
-		Pe  db.ProjectEntry
+		Ids []string
 	}{
 		Ctx: ctx,
-		Pe:  pe,
+		Ids: ids,
 	}
-	mock.lockCreateProjectEntry.Lock()
-	mock.calls.CreateProjectEntry = append(mock.calls.CreateProjectEntry, callInfo)
-	mock.lockCreateProjectEntry.Unlock()
-	return mock.CreateProjectEntryFunc(ctx, pe)
+	mock.lockReadProjectsWithTokenCounts.Lock()
+	mock.calls.ReadProjectsWithTokenCounts = append(mock.calls.ReadProjectsWithTokenCounts, callInfo)
+	mock.lockReadProjectsWithTokenCounts.Unlock()
+	return mock.ReadProjectsWithTokenCountsFunc(ctx, ids)
 }
 
-// CreateProjectEntryCalls gets all the calls that were made to CreateProjectEntry.
+// ReadProjectsWithTokenCountsCalls gets all the calls that were made to ReadProjectsWithTokenCounts.
 // Check the length with:
 //
-//	len(mockedClient.CreateProjectEntryCalls())
-func (mock *DBClientMock) CreateProjectEntryCalls() []struct {
+//	len(mockedClient.ReadProjectsWithTokenCountsCalls())
+func (mock *DBClientMock) ReadProjectsWithTokenCountsCalls() []struct {
 	Ctx context.Context
-	Pe  db.ProjectEntry
+	Ids []string
 } {
 	var calls []struct {
 		Ctx context.Context
-		Pe  db.ProjectEntry
+		Ids []string
 	}
-	mock.lockCreateProjectEntry.RLock()
-	calls = mock.calls.CreateProjectEntry
-	mock.lockCreateProjectEntry.RUnlock()
+	mock.lockReadProjectsWithTokenCounts.RLock()
+	calls = mock.calls.ReadProjectsWithTokenCounts
+	mock.lockReadProjectsWithTokenCounts.RUnlock()
 	return calls
 }
 
-// CreateTokenEntry calls CreateTokenEntryFunc.
-func (mock *DBClientMock) CreateTokenEntry(ctx context.Context, token types.Token) error {
-	if mock.CreateTokenEntryFunc == nil {
-		panic("DBClientMock.CreateTokenEntryFunc: method is nil but Client.CreateTokenEntry was just called")
+// ReadTokenEntry calls ReadTokenEntryFunc.
+func (mock *DBClientMock) ReadTokenEntry(ctx context.Context, token string) (db.TokenEntry, error) {
+	if mock.ReadTokenEntryFunc == nil {
+		panic("DBClientMock.ReadTokenEntryFunc: method is nil but Client.ReadTokenEntry was just called")
 	}
 	callInfo := struct {
 		Ctx   context.Context
-		Token types.Token
+		Token string
 	}{
 		Ctx:   ctx,
 		Token: token,
 	}
-	mock.lockCreateTokenEntry.Lock()
-	mock.calls.CreateTokenEntry = append(mock.calls.CreateTokenEntry, callInfo)
-	mock.lockCreateTokenEntry.Unlock()
-	return mock.CreateTokenEntryFunc(ctx, token)
+	mock.lockReadTokenEntry.Lock()
+	mock.calls.ReadTokenEntry = append(mock.calls.ReadTokenEntry, callInfo)
+	mock.lockReadTokenEntry.Unlock()
+	return mock.ReadTokenEntryFunc(ctx, token)
 }
 
-// CreateTokenEntryCalls gets all the calls that were made to CreateTokenEntry.
+// ReadTokenEntryCalls gets all the calls that were made to ReadTokenEntry.
 // Check the length with:
 //
-//	len(mockedClient.CreateTokenEntryCalls())
-func (mock *DBClientMock) CreateTokenEntryCalls() []struct {
+//	len(mockedClient.ReadTokenEntryCalls())
+func (mock *DBClientMock) ReadTokenEntryCalls() []struct {
 	Ctx   context.Context
-	Token types.Token
+	Token string
 } {
 	var calls []struct {
 		Ctx   context.Context
-		Token types.Token
+		Token string
 	}
-	mock.lockCreateTokenEntry.RLock()
-	calls = mock.calls.CreateTokenEntry
-	mock.lockCreateTokenEntry.RUnlock()
+	mock.lockReadTokenEntry.RLock()
+	calls = mock.calls.ReadTokenEntry
+	mock.lockReadTokenEntry.RUnlock()
 	return calls
 }
 
-// DeleteProjectEntry calls DeleteProjectEntryFunc.
-func (mock *DBClientMock) DeleteProjectEntry(ctx context.Context, project string) error {
-	if mock.DeleteProjectEntryFunc == nil {
-		panic("DBClientMock.DeleteProjectEntryFunc: method is nil but Client.DeleteProjectEntry was just called")
+// ReadTokenEntryScoped calls ReadTokenEntryScopedFunc.
+func (mock *DBClientMock) ReadTokenEntryScoped(ctx context.Context, project string, token string) (db.TokenEntry, error) {
+	if mock.ReadTokenEntryScopedFunc == nil {
+		panic("DBClientMock.ReadTokenEntryScopedFunc: method is nil but Client.ReadTokenEntryScoped was just called")
 	}
 	callInfo := struct {
 		Ctx     context.Context
 		Project string
+		Token   string
 	}{
 		Ctx:     ctx,
 		Project: project,
+		Token:   token,
 	}
-	mock.lockDeleteProjectEntry.Lock()
-	mock.calls.DeleteProjectEntry = append(mock.calls.DeleteProjectEntry, callInfo)
-	mock.lockDeleteProjectEntry.Unlock()
-	return mock.DeleteProjectEntryFunc(ctx, project)
+	mock.lockReadTokenEntryScoped.Lock()
+	mock.calls.ReadTokenEntryScoped = append(mock.calls.ReadTokenEntryScoped, callInfo)
+	mock.lockReadTokenEntryScoped.Unlock()
+	return mock.ReadTokenEntryScopedFunc(ctx, project, token)
 }
 
-// DeleteProjectEntryCalls gets all the calls that were made to DeleteProjectEntry.
+// ReadTokenEntryScopedCalls gets all the calls that were made to ReadTokenEntryScoped.
 // Check the length with:
 //
-//	len(mockedClient.DeleteProjectEntryCalls())
-func (mock *DBClientMock) DeleteProjectEntryCalls() []struct {
+//	len(mockedClient.ReadTokenEntryScopedCalls())
+func (mock *DBClientMock) ReadTokenEntryScopedCalls() []struct {
 	Ctx     context.Context
 	Project string
+	Token   string
 } {
 	var calls []struct {
 		Ctx     context.Context
 		Project string
+		Token   string
 	}
-	mock.lockDeleteProjectEntry.RLock()
-	calls = mock.calls.DeleteProjectEntry
-	mock.lockDeleteProjectEntry.RUnlock()
+	mock.lockReadTokenEntryScoped.RLock()
+	calls = mock.calls.ReadTokenEntryScoped
+	mock.lockReadTokenEntryScoped.RUnlock()
 	return calls
 }
 
-// DeleteTokenEntry calls DeleteTokenEntryFunc.
-func (mock *DBClientMock) DeleteTokenEntry(ctx context.Context, token string) error {
-	if mock.DeleteTokenEntryFunc == nil {
-		panic("DBClientMock.DeleteTokenEntryFunc: method is nil but Client.DeleteTokenEntry was just called")
+// RestoreProjectEntry calls RestoreProjectEntryFunc.
+func (mock *DBClientMock) RestoreProjectEntry(ctx context.Context, project string) error {
+	if mock.RestoreProjectEntryFunc == nil {
+		panic("DBClientMock.RestoreProjectEntryFunc: method is nil but Client.RestoreProjectEntry was just called")
 	}
 	callInfo := struct {
-		Ctx   context.Context
-		Token string
+		Ctx     context.Context
+		Project string
 	}{
-		Ctx:   ctx,
-		Token: token,
+		Ctx:     ctx,
+		Project: project,
 	}
-	mock.lockDeleteTokenEntry.Lock()
-	mock.calls.DeleteTokenEntry = append(mock.calls.DeleteTokenEntry, callInfo)
-	mock.lockDeleteTokenEntry.Unlock()
-	return mock.DeleteTokenEntryFunc(ctx, token)
+	mock.lockRestoreProjectEntry.Lock()
+	mock.calls.RestoreProjectEntry = append(mock.calls.RestoreProjectEntry, callInfo)
+	mock.lockRestoreProjectEntry.Unlock()
+	return mock.RestoreProjectEntryFunc(ctx, project)
 }
 
-// DeleteTokenEntryCalls gets all the calls that were made to DeleteTokenEntry.
+// RestoreProjectEntryCalls gets all the calls that were made to RestoreProjectEntry.
 // Check the length with:
 //
-//	len(mockedClient.DeleteTokenEntryCalls())
-func (mock *DBClientMock) DeleteTokenEntryCalls() []struct {
-	Ctx   context.Context
-	Token string
+//	len(mockedClient.RestoreProjectEntryCalls())
+func (mock *DBClientMock) RestoreProjectEntryCalls() []struct {
+	Ctx     context.Context
+	Project string
 } {
 	var calls []struct {
-		Ctx   context.Context
-		Token string
+		Ctx     context.Context
+		Project string
 	}
-	mock.lockDeleteTokenEntry.RLock()
-	calls = mock.calls.DeleteTokenEntry
-	mock.lockDeleteTokenEntry.RUnlock()
+	mock.lockRestoreProjectEntry.RLock()
+	calls = mock.calls.RestoreProjectEntry
+	mock.lockRestoreProjectEntry.RUnlock()
 	return calls
 }
 
-// Health calls HealthFunc.
-func (mock *DBClientMock) Health(ctx context.Context) error {
-	if mock.HealthFunc == nil {
-		panic("DBClientMock.HealthFunc: method is nil but Client.Health was just called")
+// StreamProjectEntries calls StreamProjectEntriesFunc.
+func (mock *DBClientMock) StreamProjectEntries(ctx context.Context) (<-chan db.ProjectEntry, <-chan error) {
+	if mock.StreamProjectEntriesFunc == nil {
+		panic("DBClientMock.StreamProjectEntriesFunc: method is nil but Client.StreamProjectEntries was just called")
 	}
 	callInfo := struct {
 		Ctx context.Context
 	}{
 		Ctx: ctx,
 	}
-	mock.lockHealth.Lock()
-	mock.calls.Health = append(mock.calls.Health, callInfo)
-	mock.lockHealth.Unlock()
-	return mock.HealthFunc(ctx)
+	mock.lockStreamProjectEntries.Lock()
+	mock.calls.StreamProjectEntries = append(mock.calls.StreamProjectEntries, callInfo)
+	mock.lockStreamProjectEntries.Unlock()
+	return mock.StreamProjectEntriesFunc(ctx)
 }
 
-// HealthCalls gets all the calls that were made to Health.
+// StreamProjectEntriesCalls gets all the calls that were made to StreamProjectEntries.
 // Check the length with:
 //
-//	len(mockedClient.HealthCalls())
-func (mock *DBClientMock) HealthCalls() []struct {
+//	len(mockedClient.StreamProjectEntriesCalls())
+func (mock *DBClientMock) StreamProjectEntriesCalls() []struct {
 	Ctx context.Context
 } {
 	var calls []struct {
 		Ctx context.Context
 	}
-	mock.lockHealth.RLock()
-	calls = mock.calls.Health
-	mock.lockHealth.RUnlock()
+	mock.lockStreamProjectEntries.RLock()
+	calls = mock.calls.StreamProjectEntries
+	mock.lockStreamProjectEntries.RUnlock()
 	return calls
 }
 
-// ListTokenEntries calls ListTokenEntriesFunc.
-func (mock *DBClientMock) ListTokenEntries(ctx context.Context, project string) ([]db.TokenEntry, error) {
-	if mock.ListTokenEntriesFunc == nil {
-		panic("DBClientMock.ListTokenEntriesFunc: method is nil but Client.ListTokenEntries was just called")
+// SystemStats calls SystemStatsFunc.
+func (mock *DBClientMock) SystemStats(ctx context.Context, now time.Time) (db.SystemStats, error) {
+	if mock.SystemStatsFunc == nil {
+		panic("DBClientMock.SystemStatsFunc: method is nil but Client.SystemStats was just called")
 	}
 	callInfo := struct {
-		Ctx     context.Context
-		Project string
+		Ctx context.Context
+		Now time.Time
 	}{
-		Ctx:     ctx,
-		Project: project,
+		Ctx: ctx,
+		Now: now,
 	}
-	mock.lockListTokenEntries.Lock()
-	mock.calls.ListTokenEntries = append(mock.calls.ListTokenEntries, callInfo)
-	mock.lockListTokenEntries.Unlock()
-	return mock.ListTokenEntriesFunc(ctx, project)
+	mock.lockSystemStats.Lock()
+	mock.calls.SystemStats = append(mock.calls.SystemStats, callInfo)
+	mock.lockSystemStats.Unlock()
+	return mock.SystemStatsFunc(ctx, now)
 }
 
-// ListTokenEntriesCalls gets all the calls that were made to ListTokenEntries.
+// SystemStatsCalls gets all the calls that were made to SystemStats.
 // Check the length with:
 //
-//	len(mockedClient.ListTokenEntriesCalls())
-func (mock *DBClientMock) ListTokenEntriesCalls() []struct {
-	Ctx     context.Context
-	Project string
+//	len(mockedClient.SystemStatsCalls())
+func (mock *DBClientMock) SystemStatsCalls() []struct {
+	Ctx context.Context
+	Now time.Time
 } {
 	var calls []struct {
-		Ctx     context.Context
-		Project string
+		Ctx context.Context
+		Now time.Time
 	}
-	mock.lockListTokenEntries.RLock()
-	calls = mock.calls.ListTokenEntries
-	mock.lockListTokenEntries.RUnlock()
+	mock.lockSystemStats.RLock()
+	calls = mock.calls.SystemStats
+	mock.lockSystemStats.RUnlock()
 	return calls
 }
 
-// ReadProjectEntry calls ReadProjectEntryFunc.
-func (mock *DBClientMock) ReadProjectEntry(ctx context.Context, project string) (db.ProjectEntry, error) {
-	if mock.ReadProjectEntryFunc == nil {
-		panic("DBClientMock.ReadProjectEntryFunc: method is nil but Client.ReadProjectEntry was just called")
+// UpdateProjectEntry calls UpdateProjectEntryFunc.
+func (mock *DBClientMock) UpdateProjectEntry(ctx context.Context, pe db.ProjectEntry) error {
+	if mock.UpdateProjectEntryFunc == nil {
+		panic("DBClientMock.UpdateProjectEntryFunc: method is nil but Client.UpdateProjectEntry was just called")
+	}
+	callInfo := struct {
+		Ctx context.Context
+		Pe  db.ProjectEntry
+	}{
+		Ctx: ctx,
+		Pe:  pe,
+	}
+	mock.lockUpdateProjectEntry.Lock()
+	mock.calls.UpdateProjectEntry = append(mock.calls.UpdateProjectEntry, callInfo)
+	mock.lockUpdateProjectEntry.Unlock()
+	return mock.UpdateProjectEntryFunc(ctx, pe)
+}
+
+// UpdateProjectEntryCalls gets all the calls that were made to UpdateProjectEntry.
+// Check the length with:
+//
+//	len(mockedClient.UpdateProjectEntryCalls())
+func (mock *DBClientMock) UpdateProjectEntryCalls() []struct {
+	Ctx context.Context
+	Pe  db.ProjectEntry
+} {
+	var calls []struct {
+		Ctx context.Context
+		Pe  db.ProjectEntry
+	}
+	mock.lockUpdateProjectEntry.RLock()
+	calls = mock.calls.UpdateProjectEntry
+	mock.lockUpdateProjectEntry.RUnlock()
+	return calls
+}
+
+// UpdateProjectEntryIfMatch calls UpdateProjectEntryIfMatchFunc.
+func (mock *DBClientMock) UpdateProjectEntryIfMatch(ctx context.Context, pe db.ProjectEntry, etag string) error {
+	if mock.UpdateProjectEntryIfMatchFunc == nil {
+		panic("DBClientMock.UpdateProjectEntryIfMatchFunc: method is nil but Client.UpdateProjectEntryIfMatch was just called")
+	}
+	callInfo := struct {
+		Ctx  context.Context
+		Pe   db.ProjectEntry
+		Etag string
+	}{
+		Ctx:  ctx,
+		Pe:   pe,
+		Etag: etag,
+	}
+	mock.lockUpdateProjectEntryIfMatch.Lock()
+	mock.calls.UpdateProjectEntryIfMatch = append(mock.calls.UpdateProjectEntryIfMatch, callInfo)
+	mock.lockUpdateProjectEntryIfMatch.Unlock()
+	return mock.UpdateProjectEntryIfMatchFunc(ctx, pe, etag)
+}
+
+// UpdateProjectEntryIfMatchCalls gets all the calls that were made to UpdateProjectEntryIfMatch.
+// Check the length with:
+//
+//	len(mockedClient.UpdateProjectEntryIfMatchCalls())
+func (mock *DBClientMock) UpdateProjectEntryIfMatchCalls() []struct {
+	Ctx  context.Context
+	Pe   db.ProjectEntry
+	Etag string
+} {
+	var calls []struct {
+		Ctx  context.Context
+		Pe   db.ProjectEntry
+		Etag string
+	}
+	mock.lockUpdateProjectEntryIfMatch.RLock()
+	calls = mock.calls.UpdateProjectEntryIfMatch
+	mock.lockUpdateProjectEntryIfMatch.RUnlock()
+	return calls
+}
+
+// VerifyAndTouch calls VerifyAndTouchFunc.
+func (mock *DBClientMock) VerifyAndTouch(ctx context.Context, project string, token string, secret string) (db.TokenEntry, error) {
+	if mock.VerifyAndTouchFunc == nil {
+		panic("DBClientMock.VerifyAndTouchFunc: method is nil but Client.VerifyAndTouch was just called")
 	}
 	callInfo := struct {
 		Ctx     context.Context
 		Project string
+		Token   string
+		Secret  string
 	}{
 		Ctx:     ctx,
 		Project: project,
+		Token:   token,
+		Secret:  secret,
 	}
-	mock.lockReadProjectEntry.Lock()
-	mock.calls.ReadProjectEntry = append(mock.calls.ReadProjectEntry, callInfo)
-	mock.lockReadProjectEntry.Unlock()
-	return mock.ReadProjectEntryFunc(ctx, project)
+	mock.lockVerifyAndTouch.Lock()
+	mock.calls.VerifyAndTouch = append(mock.calls.VerifyAndTouch, callInfo)
+	mock.lockVerifyAndTouch.Unlock()
+	return mock.VerifyAndTouchFunc(ctx, project, token, secret)
 }
 
-// ReadProjectEntryCalls gets all the calls that were made to ReadProjectEntry.
+// VerifyAndTouchCalls gets all the calls that were made to VerifyAndTouch.
 // Check the length with:
 //
-//	len(mockedClient.ReadProjectEntryCalls())
-func (mock *DBClientMock) ReadProjectEntryCalls() []struct {
+//	len(mockedClient.VerifyAndTouchCalls())
+func (mock *DBClientMock) VerifyAndTouchCalls() []struct {
 	Ctx     context.Context
 	Project string
+	Token   string
+	Secret  string
 } {
 	var calls []struct {
 		Ctx     context.Context
 		Project string
+		Token   string
+		Secret  string
 	}
-	mock.lockReadProjectEntry.RLock()
-	calls = mock.calls.ReadProjectEntry
-	mock.lockReadProjectEntry.RUnlock()
+	mock.lockVerifyAndTouch.RLock()
+	calls = mock.calls.VerifyAndTouch
+	mock.lockVerifyAndTouch.RUnlock()
 	return calls
 }
 
-// ReadTokenEntry calls ReadTokenEntryFunc.
-func (mock *DBClientMock) ReadTokenEntry(ctx context.Context, token string) (db.TokenEntry, error) {
-	if mock.ReadTokenEntryFunc == nil {
-		panic("DBClientMock.ReadTokenEntryFunc: method is nil but Client.ReadTokenEntry was just called")
+// VerifyTokenSecret calls VerifyTokenSecretFunc.
+func (mock *DBClientMock) VerifyTokenSecret(ctx context.Context, token string, secret string) (db.TokenEntry, error) {
+	if mock.VerifyTokenSecretFunc == nil {
+		panic("DBClientMock.VerifyTokenSecretFunc: method is nil but Client.VerifyTokenSecret was just called")
 	}
 	callInfo := struct {
-		Ctx   context.Context
-		Token string
+		Ctx    context.Context
+		Token  string
+		Secret string
 	}{
-		Ctx:   ctx,
-		Token: token,
+		Ctx:    ctx,
+		Token:  token,
+		Secret: secret,
 	}
-	mock.lockReadTokenEntry.Lock()
-	mock.calls.ReadTokenEntry = append(mock.calls.ReadTokenEntry, callInfo)
-	mock.lockReadTokenEntry.Unlock()
-	return mock.ReadTokenEntryFunc(ctx, token)
+	mock.lockVerifyTokenSecret.Lock()
+	mock.calls.VerifyTokenSecret = append(mock.calls.VerifyTokenSecret, callInfo)
+	mock.lockVerifyTokenSecret.Unlock()
+	return mock.VerifyTokenSecretFunc(ctx, token, secret)
 }
 
-// ReadTokenEntryCalls gets all the calls that were made to ReadTokenEntry.
+// VerifyTokenSecretCalls gets all the calls that were made to VerifyTokenSecret.
 // Check the length with:
 //
-//	len(mockedClient.ReadTokenEntryCalls())
-func (mock *DBClientMock) ReadTokenEntryCalls() []struct {
-	Ctx   context.Context
-	Token string
+//	len(mockedClient.VerifyTokenSecretCalls())
+func (mock *DBClientMock) VerifyTokenSecretCalls() []struct {
+	Ctx    context.Context
+	Token  string
+	Secret string
 } {
 	var calls []struct {
-		Ctx   context.Context
-		Token string
+		Ctx    context.Context
+		Token  string
+		Secret string
 	}
-	mock.lockReadTokenEntry.RLock()
-	calls = mock.calls.ReadTokenEntry
-	mock.lockReadTokenEntry.RUnlock()
+	mock.lockVerifyTokenSecret.RLock()
+	calls = mock.calls.VerifyTokenSecret
+	mock.lockVerifyTokenSecret.RUnlock()
 	return calls
 }